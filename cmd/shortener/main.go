@@ -4,23 +4,47 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"shortener/internal/analytics"
+	"shortener/internal/audit"
+	"shortener/internal/blocklist"
+	"shortener/internal/cache"
 	"shortener/internal/config"
 	"shortener/internal/grpcserver"
 	"shortener/internal/handlers"
+	"shortener/internal/health"
 	"shortener/internal/logger"
+	"shortener/internal/metrics"
+	"shortener/internal/ratelimit"
 	"shortener/internal/service"
+	"shortener/internal/shortcode"
 	"shortener/internal/storage"
 	"shortener/internal/tasks"
+	"shortener/internal/tracing"
+)
+
+// tracingFlushTimeout limits the export of the spans left on shutdown.
+const tracingFlushTimeout = 5 * time.Second
+
+// grpcStopTimeout limits the wait for the running gRPC calls on shutdown, the rest are cancelled.
+const grpcStopTimeout = 10 * time.Second
+
+// The certificate and the key of the HTTPS server, see cmd/certgenerator.
+const (
+	tlsCertFile = "tls/server.crt"
+	tlsKeyFile  = "tls/server.key"
 )
 
 var (
@@ -46,6 +70,7 @@ func main() {
 // initApp initializes the URL shortener application.
 //
 // It loads the configuration, initializes the storage, and starts the HTTP server.
+// With the export or import subcommand it transfers the records instead and returns.
 func initApp(log *logger.Log) error {
 	ctx, cancelCtx := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancelCtx()
@@ -53,30 +78,152 @@ func initApp(log *logger.Log) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	cfg := config.LoadConfig()
+	logOutput, closeLog, err := logger.OpenOutput(commandLogOutput(cfg.App.LogOutput, flag.Args()))
+	if err != nil {
+		return fmt.Errorf("failed to open log output: %w", err)
+	}
+	defer func() {
+		if err := closeLog(); err != nil {
+			log.Err("failed to close log output: ", err)
+		}
+	}()
+	if err = log.Setup(logger.Options{
+		Output: logOutput,
+		Format: cfg.App.LogFormat,
+		Level:  cfg.App.LogLevel,
+	}); err != nil {
+		return fmt.Errorf("failed to setup logger: %w", err)
+	}
 	store, err := storage.LoadStorage(ctx, cfg, log)
 	if err != nil {
 		return fmt.Errorf("failed to load storage: %w", err)
 	}
+	if args := flag.Args(); len(args) > 0 {
+		defer func() {
+			if err = store.Close(); err != nil {
+				log.Err("failed to close the connection: ", err)
+			}
+		}()
+		svc := &service.Service{Storage: store, Log: log}
+		if cfg.App.BlocklistPath != "" {
+			if svc.Blocklist, err = blocklist.Load(cfg.App.BlocklistPath, log); err != nil {
+				return fmt.Errorf("failed to load blocklist: %w", err)
+			}
+		}
+		return runCommand(ctx, svc, log, args)
+	}
+	if pool, ok := store.(metrics.PoolStater); ok {
+		if err = metrics.RegisterPool(pool); err != nil {
+			return fmt.Errorf("failed to register pool metrics: %w", err)
+		}
+	}
+	checks := health.New(health.DefaultTimeout)
+	checks.Add("storage", store.Ping)
+	if s, ok := store.(health.MigrationChecker); ok {
+		checks.Add("migrations", s.CheckMigrations)
+	}
+	if s, ok := store.(health.WritableChecker); ok {
+		checks.Add("file", s.CheckWritable)
+	}
+	store = tracing.NewStorage(metrics.NewStorage(store, storage.Backend(cfg)), storage.Backend(cfg))
+	if cfg.App.OTLPEndpoint != "" {
+		shutdownTracing, err := tracing.Setup(ctx, cfg.App.OTLPEndpoint, buildVersion)
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+			defer cancel()
+			if err = shutdownTracing(flushCtx); err != nil {
+				log.Err("failed to flush traces: ", err)
+			}
+		}()
+		log.Info("tracing enabled..", slog.String("endpoint", cfg.App.OTLPEndpoint))
+	}
+	if cfg.App.CacheType != "" {
+		c, err := cache.New(cfg.App.CacheType, cfg.App.CacheAddr, cfg.App.CacheSize)
+		if err != nil {
+			return fmt.Errorf("failed to create cache: %w", err)
+		}
+		log.Info("using cache..", slog.String("type", cfg.App.CacheType))
+		store = cache.NewStorage(store, c, log, cfg.App.CacheTTL, cfg.App.CacheNegativeTTL)
+	}
+	if cfg.App.UserStatusTTL > 0 {
+		store = cache.NewUserStatusStorage(store, cfg.App.CacheSize, cfg.App.UserStatusTTL)
+	}
 	defer func() {
 		if err = store.Close(); err != nil {
 			log.Err("failed to close the connection: ", err)
 		}
 	}()
 
+	clicks := analytics.NewRecorder(store, log, analytics.DefaultBufferSize, analytics.DefaultFlushInterval)
+	g.Go(func() error {
+		clicks.Run(ctx)
+		return nil
+	})
+
+	codes, err := shortcode.New(cfg.App.ShortCodeStrategy, store, cfg.App.ShortCodeLength, cfg.App.ShortCodeSalt)
+	if err != nil {
+		return fmt.Errorf("failed to create short code generator: %w", err)
+	}
+
 	svc := &service.Service{
 		Storage:         store,
+		Clicks:          clicks,
+		Codes:           codes,
 		BaseURL:         cfg.App.BaseURL,
 		FileStoragePath: cfg.App.FileStoragePath,
 		DatabaseDSN:     cfg.App.DatabaseDSN,
 		Log:             log,
 		SecretKey:       cfg.Service.SecretKey,
 		TrustedSubnet:   cfg.App.TrustedSubnet,
+		TrustedProxies:  cfg.App.TrustedProxies,
+		Health:          checks,
+		RateLimits: ratelimit.Limits{
+			Save:     ratelimit.New(cfg.App.RateLimitSave, cfg.App.RateLimitSaveBurst),
+			Batch:    ratelimit.New(cfg.App.RateLimitBatch, cfg.App.RateLimitBatchBurst),
+			Redirect: ratelimit.New(cfg.App.RateLimitRedirect, cfg.App.RateLimitRedirectBurst),
+			Login:    ratelimit.New(cfg.App.RateLimitLogin, cfg.App.RateLimitLoginBurst),
+		},
+	}
+
+	if cfg.App.AuditLogPath != "" {
+		auditFile, err := audit.Open(cfg.App.AuditLogPath)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer func() {
+			if err = auditFile.Close(); err != nil {
+				log.Err("failed to close audit log: ", err)
+			}
+		}()
+		svc.Audit = audit.New(auditFile, log)
+	}
+
+	if cfg.App.BlocklistPath != "" {
+		blocked, err := blocklist.Load(cfg.App.BlocklistPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to load blocklist: %w", err)
+		}
+		svc.Blocklist = blocked
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		interval := cfg.App.BlocklistReloadInterval
+		g.Go(func() error {
+			defer signal.Stop(hup)
+			blocked.Run(ctx, interval, hup)
+			return nil
+		})
 	}
 
 	if cfg.Service.BackgroundCleanup {
 		interval := cfg.Service.BackgroundCleanupInterval
+		beat := &health.Heartbeat{}
+		// A run may take up to an interval of its own before the next beat.
+		checks.Add("cleanup", beat.Check(2*interval))
 		g.Go(func() error {
-			tasks.Run(ctx, store, log, interval)
+			tasks.Run(ctx, store, log, interval, beat)
 			<-ctx.Done()
 			return nil
 		})
@@ -95,6 +242,10 @@ func initApp(log *logger.Log) error {
 		Handler: r,
 	}
 
+	if cfg.App.DebugAddress != "" {
+		serveDebug(ctx, g, log, cfg.App.DebugAddress)
+	}
+
 	// enabling graceful shutdown
 	readyToShutdown := make(chan struct{})
 	sigint := make(chan os.Signal, 1)
@@ -103,11 +254,20 @@ func initApp(log *logger.Log) error {
 		gracefulShutdown(ctx, log, sigint, srv)
 	}()
 
+	grpcSrv, err := grpcserver.New(svc, grpcOptions(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC server: %w", err)
+	}
+	grpcListener, err := net.Listen("tcp", cfg.App.GRPCAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen gRPC address: %w", err)
+	}
 	g.Go(func() error {
-		err = grpcserver.GRPCServe(svc, log)
-		if err != nil {
-			return err
-		}
+		return grpcSrv.Serve(grpcListener)
+	})
+	g.Go(func() error {
+		<-ctx.Done()
+		stopGRPC(grpcSrv, log)
 		return nil
 	})
 
@@ -121,7 +281,7 @@ func initApp(log *logger.Log) error {
 				}
 			}
 		} else {
-			if err = srv.ListenAndServeTLS("tls/server.crt", "tls/server.key"); err != nil {
+			if err = srv.ListenAndServeTLS(tlsCertFile, tlsKeyFile); err != nil {
 				if !errors.Is(err, http.ErrServerClosed) {
 					return fmt.Errorf("failed listen and serve: %w", err)
 				}
@@ -149,3 +309,57 @@ func gracefulShutdown(ctx context.Context, log *logger.Log, sigint chan os.Signa
 	}
 	log.Debug("graceful shutdown complete..")
 }
+
+// serveDebug runs the server of the profiler and the metrics until the context is done.
+func serveDebug(ctx context.Context, g *errgroup.Group, log *logger.Log, addr string) {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: handlers.NewDebugRouter(),
+	}
+	g.Go(func() error {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve debug address: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Err("failed to shutdown debug server", err)
+		}
+		return nil
+	})
+}
+
+// grpcOptions returns the gRPC server options of the config.
+func grpcOptions(cfg *config.Config) grpcserver.Options {
+	opts := grpcserver.Options{
+		TLSCert:          cfg.App.GRPCTLSCert,
+		TLSKey:           cfg.App.GRPCTLSKey,
+		Reflection:       cfg.App.GRPCReflection,
+		MaxMessageSize:   cfg.App.GRPCMaxMessageSize,
+		KeepaliveTime:    cfg.App.GRPCKeepaliveTime,
+		KeepaliveTimeout: cfg.App.GRPCKeepaliveTimeout,
+		KeepaliveMinTime: cfg.App.GRPCKeepaliveMinTime,
+	}
+	if opts.TLSCert == "" && opts.TLSKey == "" && cfg.App.EnableHTTPS {
+		opts.TLSCert, opts.TLSKey = tlsCertFile, tlsKeyFile
+	}
+	return opts
+}
+
+// stopGRPC waits grpcStopTimeout for the running gRPC calls and then cancels them.
+func stopGRPC(srv *grpcserver.Server, log *logger.Log) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Debug("gRPC server stopped..")
+	case <-time.After(grpcStopTimeout):
+		log.Warn("gRPC calls still running, stopping forcibly", "timeout", grpcStopTimeout)
+		srv.Stop()
+	}
+}
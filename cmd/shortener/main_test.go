@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"shortener/internal/config"
 	"shortener/internal/logger"
 )
 
@@ -43,3 +44,35 @@ func Test_initApp(t *testing.T) {
 		})
 	}
 }
+
+func Test_grpcOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      config.AppConfig
+		wantCert string
+		wantKey  string
+	}{
+		{
+			name: "plaintext",
+		},
+		{
+			name:     "HTTPS certificate",
+			app:      config.AppConfig{EnableHTTPS: true},
+			wantCert: tlsCertFile,
+			wantKey:  tlsKeyFile,
+		},
+		{
+			name:     "own certificate",
+			app:      config.AppConfig{EnableHTTPS: true, GRPCTLSCert: "grpc.crt", GRPCTLSKey: "grpc.key"},
+			wantCert: "grpc.crt",
+			wantKey:  "grpc.key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := grpcOptions(&config.Config{App: tt.app})
+			assert.Equal(t, tt.wantCert, opts.TLSCert)
+			assert.Equal(t, tt.wantKey, opts.TLSKey)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"shortener/internal/logger"
+	"shortener/internal/service"
+	"shortener/internal/transfer"
+)
+
+const (
+	commandExport = "export"
+	commandImport = "import"
+)
+
+// commandLogOutput returns the log output for the command line arguments. The export and import
+// subcommands write the records to stdout, so their log lines go to stderr instead of stdout.
+func commandLogOutput(output string, args []string) string {
+	if len(args) > 0 && (output == "" || output == logger.OutputStdout) {
+		return logger.OutputStderr
+	}
+	return output
+}
+
+// runCommand runs the export or import subcommand against the storage of the service.
+//
+// Records are read from stdin and written to stdout unless -file is set. Progress and the log lines
+// go to stderr, see commandLogOutput.
+// Imported records are validated by the service as the ones imported over HTTP.
+func runCommand(ctx context.Context, svc *service.Service, log *logger.Log, args []string) error {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	format := fs.String("format", transfer.FormatJSONL, "Records format: jsonl or csv")
+	file := fs.String("file", "", "File to read or write, stdin or stdout by default")
+	policyName := fs.String("policy", string(transfer.DefaultPolicy), "Import conflict policy: skip, overwrite or fail")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("failed to parse %s flags: %w", args[0], err)
+	}
+	progress := func(processed int) {
+		fmt.Fprintf(os.Stderr, "%s: %d records processed\n", args[0], processed)
+	}
+
+	switch args[0] {
+	case commandExport:
+		var w io.Writer = os.Stdout
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return fmt.Errorf("failed to create file: %w", err)
+			}
+			defer func() {
+				if err = f.Close(); err != nil {
+					log.Err("failed to close file: ", err)
+				}
+			}()
+			w = f
+		}
+		n, err := svc.ExportURLs(ctx, w, *format, progress)
+		if err != nil {
+			return err
+		}
+		log.Info("urls exported", "count", n)
+		return nil
+	case commandImport:
+		policy, err := transfer.ParsePolicy(*policyName)
+		if err != nil {
+			return err
+		}
+		var r io.Reader = os.Stdin
+		if *file != "" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer func() {
+				if err = f.Close(); err != nil {
+					log.Err("failed to close file: ", err)
+				}
+			}()
+			r = f
+		}
+		res, err := svc.ImportURLs(ctx, r, *format, policy, progress)
+		log.Info("urls imported", "imported", res.Imported, "overwritten", res.Overwritten, "skipped", res.Skipped)
+		return err
+	}
+	return errors.New("unknown command " + args[0] + ", expected export or import")
+}
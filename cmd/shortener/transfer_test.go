@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+	"shortener/internal/transfer"
+)
+
+func Test_commandLogOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		args   []string
+		want   string
+	}{
+		{name: "server", output: logger.OutputStdout, want: logger.OutputStdout},
+		{name: "command", output: logger.OutputStdout, args: []string{commandExport}, want: logger.OutputStderr},
+		{name: "command default", args: []string{commandImport}, want: logger.OutputStderr},
+		{name: "command to file", output: "app.log", args: []string{commandExport}, want: "app.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, commandLogOutput(tt.output, tt.args))
+		})
+	}
+}
+
+func Test_runCommand_ExportToStdout(t *testing.T) {
+	ctx := context.Background()
+	newService := func(log *logger.Log) *service.Service {
+		store, err := storage.LoadStorage(ctx, &config.Config{}, log)
+		require.NoError(t, err)
+		return &service.Service{Storage: store, Log: log}
+	}
+
+	// the log output is opened after stdout is replaced, as initApp opens it on start
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{commandExport}
+	logOutput, closeLog, err := logger.OpenOutput(commandLogOutput(logger.OutputStdout, args))
+	require.NoError(t, err)
+	defer closeLog() //nolint:errcheck // standard stream
+	log := &logger.Log{}
+	require.NoError(t, log.Setup(logger.Options{Output: logOutput, Level: "INFO"}))
+
+	svc := newService(log)
+	userCtx := context.WithValue(ctx, models.CtxUserIDKey, "user1")
+	require.NoError(t, svc.Storage.Save(userCtx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, svc.Storage.Save(userCtx, "bbb", "https://example.com/b", nil))
+
+	dump := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		dump <- data
+	}()
+	require.NoError(t, runCommand(ctx, svc, log, args))
+	require.NoError(t, w.Close())
+	data := <-dump
+	os.Stdout = stdout
+
+	res, err := newService(log).ImportURLs(ctx, bytes.NewReader(data), transfer.FormatJSONL, transfer.DefaultPolicy, nil)
+	require.NoError(t, err, "stdout holds the records only:\n%s", data)
+	assert.Equal(t, 2, res.Imported)
+}
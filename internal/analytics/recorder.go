@@ -0,0 +1,95 @@
+// Package analytics collects redirect events and writes them to the storage asynchronously.
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+const (
+	// DefaultBufferSize is the capacity of the click events channel.
+	DefaultBufferSize = 1024
+	// DefaultFlushInterval is how often buffered events are written even if the batch is not full.
+	DefaultFlushInterval = time.Second
+
+	batchSize    = 100
+	flushTimeout = 5 * time.Second
+)
+
+// ClickStore contains contract for persisting click events.
+type ClickStore interface {
+	SaveClicks(ctx context.Context, clicks []models.Click) error
+}
+
+// Recorder buffers click events and flushes them to the store in batches.
+type Recorder struct {
+	store         ClickStore
+	log           *logger.Log
+	events        chan models.Click
+	flushInterval time.Duration
+}
+
+// NewRecorder creates a new click recorder with the given buffer size.
+func NewRecorder(store ClickStore, log *logger.Log, bufferSize int, flushInterval time.Duration) *Recorder {
+	return &Recorder{
+		store:         store,
+		log:           log,
+		events:        make(chan models.Click, bufferSize),
+		flushInterval: flushInterval,
+	}
+}
+
+// Record enqueues the click event without blocking the caller.
+//
+// When the buffer is full the event is dropped, so a slow storage never delays redirects.
+func (r *Recorder) Record(click models.Click) {
+	select {
+	case r.events <- click:
+	default:
+		r.log.Warn("click buffer is full, dropping event", "short", click.Short)
+	}
+}
+
+// Run consumes click events until the context is cancelled and flushes the rest on exit.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Click, 0, batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.store.SaveClicks(ctx, batch); err != nil {
+			r.log.Err("failed to save clicks", err)
+		}
+		batch = make([]models.Click, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			defer cancel()
+			for {
+				select {
+				case click := <-r.events:
+					batch = append(batch, click)
+				default:
+					flush(drainCtx)
+					return
+				}
+			}
+		case click := <-r.events:
+			batch = append(batch, click)
+			if len(batch) >= batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+type fakeStore struct {
+	mux    sync.Mutex
+	clicks []models.Click
+}
+
+func (f *fakeStore) SaveClicks(_ context.Context, clicks []models.Click) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.clicks = append(f.clicks, clicks...)
+	return nil
+}
+
+func (f *fakeStore) saved() int {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return len(f.clicks)
+}
+
+func TestRecorder_FlushOnShutdown(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	store := &fakeStore{}
+	rec := NewRecorder(store, log, 10, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		rec.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		rec.Record(models.Click{Short: "short1", Time: time.Now()})
+	}
+	cancel()
+	<-done
+
+	assert.Equal(t, 3, store.saved())
+}
+
+func TestRecorder_FlushOnInterval(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	store := &fakeStore{}
+	rec := NewRecorder(store, log, 10, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rec.Run(ctx)
+
+	rec.Record(models.Click{Short: "short1", Time: time.Now()})
+	assert.Eventually(t, func() bool { return store.saved() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestRecorder_DropsWhenFull(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	store := &fakeStore{}
+	rec := NewRecorder(store, log, 1, time.Hour)
+
+	rec.Record(models.Click{Short: "short1"})
+	rec.Record(models.Click{Short: "short2"})
+
+	assert.Len(t, rec.events, 1)
+}
@@ -0,0 +1,49 @@
+// Package audit writes actions of trusted subnet operators to an append-only JSON lines file.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+// Log appends audit entries to a writer, one JSON object per line.
+type Log struct {
+	mux sync.Mutex
+	w   io.Writer
+	log *logger.Log
+}
+
+// New creates an audit log writing to w.
+func New(w io.Writer, log *logger.Log) *Log {
+	return &Log{w: w, log: log}
+}
+
+// Open opens the audit log file for appending, creating it with owner only permissions.
+func Open(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return file, nil
+}
+
+// Record writes the entry. A failed write is logged, the operator action has already happened.
+func (l *Log) Record(entry models.AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		l.log.Err("failed to marshal audit entry: ", err)
+		return
+	}
+	data = append(data, '\n')
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if _, err = l.w.Write(data); err != nil {
+		l.log.Err("failed to write audit entry: ", err)
+	}
+}
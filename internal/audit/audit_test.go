@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+func TestLog(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	path := filepath.Join(t.TempDir(), "audit.log")
+	file, err := Open(path)
+	require.NoError(t, err)
+	audit := New(file, log)
+
+	const entries = 50
+	var wg sync.WaitGroup
+	for i := 0; i < entries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			audit.Record(models.AuditEntry{Time: time.Now().UTC(), Actor: "10.0.0.1", Action: "delete_url", Target: "abc"})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, file.Close())
+
+	file, err = Open(path)
+	require.NoError(t, err)
+	audit = New(file, log)
+	audit.Record(models.AuditEntry{Actor: "10.0.0.2", Action: "disable_user", Target: "user", Error: "boom"})
+	require.NoError(t, file.Close())
+
+	read, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, read.Close())
+	}()
+	var got []models.AuditEntry
+	scanner := bufio.NewScanner(read)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		got = append(got, entry)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, entries+1)
+	assert.Equal(t, "delete_url", got[0].Action)
+	assert.Equal(t, models.AuditEntry{Actor: "10.0.0.2", Action: "disable_user", Target: "user", Error: "boom"}, got[entries])
+}
@@ -0,0 +1,192 @@
+// Package blocklist matches destination URLs against operator maintained rules and reloads them on change.
+//
+// The rules file has one rule per line, empty lines and lines starting with # are skipped:
+//
+//	phishing.example       exact host
+//	*.phishing.example     any subdomain of the host
+//	re:^https?://[^/]+/login\.php   regular expression over the whole canonical URL
+package blocklist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"shortener/internal/logger"
+)
+
+const (
+	// DefaultReloadInterval is how often the rules file is checked for changes.
+	DefaultReloadInterval = 10 * time.Second
+
+	wildcardPrefix = "*."
+	regexPrefix    = "re:"
+)
+
+// ErrInvalidRule error indicates a line of the rules file can not be parsed.
+var ErrInvalidRule = errors.New("invalid blocklist rule")
+
+// Rules is a parsed set of blocklist rules.
+type Rules struct {
+	hosts    map[string]struct{}
+	suffixes []string
+	patterns []*regexp.Regexp
+}
+
+// Parse reads rules from r.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{hosts: make(map[string]struct{})}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, regexPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(line, regexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w: %w", n, ErrInvalidRule, err)
+			}
+			rules.patterns = append(rules.patterns, re)
+		case strings.HasPrefix(line, wildcardPrefix):
+			host := normalizeHost(strings.TrimPrefix(line, wildcardPrefix))
+			if !validHost(host) {
+				return nil, fmt.Errorf("line %d: %w: %q", n, ErrInvalidRule, line)
+			}
+			rules.suffixes = append(rules.suffixes, "."+host)
+		default:
+			host := normalizeHost(line)
+			if !validHost(host) {
+				return nil, fmt.Errorf("line %d: %w: %q", n, ErrInvalidRule, line)
+			}
+			rules.hosts[host] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Len returns the number of rules.
+func (r *Rules) Len() int {
+	return len(r.hosts) + len(r.suffixes) + len(r.patterns)
+}
+
+// Match returns the rule blocking the URL. Invalid URLs are matched by the patterns only.
+func (r *Rules) Match(rawURL string) (string, bool) {
+	if u, err := url.Parse(rawURL); err == nil {
+		host := normalizeHost(u.Hostname())
+		if _, ok := r.hosts[host]; ok {
+			return host, true
+		}
+		for _, suffix := range r.suffixes {
+			if strings.HasSuffix(host, suffix) {
+				return "*" + suffix, true
+			}
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(rawURL) {
+			return regexPrefix + re.String(), true
+		}
+	}
+	return "", false
+}
+
+// List holds the rules loaded from a file and swaps them atomically on reload.
+type List struct {
+	rules   atomic.Pointer[Rules]
+	log     *logger.Log
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// Load reads the rules file and returns the list.
+func Load(path string, log *logger.Log) (*List, error) {
+	l := &List{path: path, log: log}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Blocked returns the rule blocking the URL.
+func (l *List) Blocked(rawURL string) (string, bool) {
+	return l.rules.Load().Match(rawURL)
+}
+
+// Reload reads the rules file again. On error the previous rules stay in effect.
+func (l *List) Reload() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close() //nolint:errcheck // read only
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat blocklist: %w", err)
+	}
+	rules, err := Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse blocklist %s: %w", l.path, err)
+	}
+	l.rules.Store(rules)
+	l.modTime, l.size = info.ModTime(), info.Size()
+	l.log.Info("blocklist loaded", "path", l.path, "rules", rules.Len())
+	return nil
+}
+
+// Run reloads the rules on every signal from hup and when the file changes, until ctx is done.
+func (l *List) Run(ctx context.Context, interval time.Duration, hup <-chan os.Signal) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			l.reload()
+		case <-ticker.C:
+			if l.changed() {
+				l.reload()
+			}
+		}
+	}
+}
+
+func (l *List) reload() {
+	if err := l.Reload(); err != nil {
+		l.log.Err("failed to reload blocklist, keeping previous rules: ", err)
+	}
+}
+
+// changed reports whether the file modification time or size differs from the loaded one.
+func (l *List) changed() bool {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		l.log.Err("failed to stat blocklist: ", err)
+		return false
+	}
+	return !info.ModTime().Equal(l.modTime) || info.Size() != l.size
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func validHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/:*?# \t")
+}
@@ -0,0 +1,126 @@
+package blocklist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/logger"
+)
+
+const rulesFile = `# phishing
+evil.example
+*.malware.example
+re:^https://[^/]+/wp-login\.php
+
+Bad.Example.
+`
+
+func TestRulesMatch(t *testing.T) {
+	rules, err := Parse(strings.NewReader(rulesFile))
+	require.NoError(t, err)
+	assert.Equal(t, 4, rules.Len())
+
+	tests := []struct {
+		name     string
+		url      string
+		wantRule string
+		want     bool
+	}{
+		{name: "exact host", url: "https://evil.example/", wantRule: "evil.example", want: true},
+		{name: "exact host with port", url: "http://evil.example:8080/a", wantRule: "evil.example", want: true},
+		{name: "exact host case insensitive", url: "https://BAD.example./x", wantRule: "bad.example", want: true},
+		{name: "exact host does not cover subdomains", url: "https://www.evil.example/", want: false},
+		{name: "wildcard subdomain", url: "https://cdn.malware.example/x.exe", wantRule: "*.malware.example", want: true},
+		{name: "wildcard nested subdomain", url: "https://a.b.malware.example/", wantRule: "*.malware.example", want: true},
+		{name: "wildcard does not cover the host", url: "https://malware.example/", want: false},
+		{name: "wildcard does not cover lookalikes", url: "https://notmalware.example/", want: false},
+		{name: "regex", url: "https://blog.example/wp-login.php", wantRule: `re:^https://[^/]+/wp-login\.php`, want: true},
+		{name: "regex not matched", url: "http://blog.example/wp-login.php", want: false},
+		{name: "allowed", url: "https://example.org/", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := rules.Match(tt.url)
+			assert.Equal(t, tt.want, ok)
+			assert.Equal(t, tt.wantRule, rule)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "bad regex", line: "re:(("},
+		{name: "empty wildcard", line: "*."},
+		{name: "url instead of host", line: "https://evil.example/"},
+		{name: "wildcard in the middle", line: "evil.*.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader("ok.example\n" + tt.line + "\n"))
+			require.ErrorIs(t, err, ErrInvalidRule)
+			assert.Contains(t, err.Error(), "line 2")
+		})
+	}
+}
+
+func TestListReload(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("evil.example\n"), 0o600))
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.txt"), log)
+	require.Error(t, err)
+
+	list, err := Load(path, log)
+	require.NoError(t, err)
+	_, ok := list.Blocked("https://evil.example/")
+	assert.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		list.Run(ctx, 10*time.Millisecond, hup)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// the file change is picked up by the poller
+	require.NoError(t, os.WriteFile(path, []byte("other.example\nmore.example\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		_, ok := list.Blocked("https://other.example/")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	_, ok = list.Blocked("https://evil.example/")
+	assert.False(t, ok)
+
+	// broken rules keep the previous ones in effect
+	require.NoError(t, os.WriteFile(path, []byte("re:((\n"), 0o600))
+	hup <- syscall.SIGHUP
+	time.Sleep(50 * time.Millisecond)
+	_, ok = list.Blocked("https://other.example/")
+	assert.True(t, ok)
+
+	// SIGHUP reloads the file even when the poller has not noticed the change yet
+	require.NoError(t, os.WriteFile(path, []byte("evil.example\n"), 0o600))
+	hup <- syscall.SIGHUP
+	assert.Eventually(t, func() bool {
+		_, ok := list.Blocked("https://evil.example/")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,190 @@
+// Package cache contains a read-through cache for the URL storage.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+const (
+	// TypeLRU selects the in-process LRU cache.
+	TypeLRU = "lru"
+	// TypeRESP selects the cache served by a Redis-compatible server.
+	TypeRESP = "redis"
+
+	keyPrefix = "shortener:url:"
+	// missMarker is cached for short links that do not exist.
+	missMarker = "\x00"
+)
+
+// Cache contains contracts for communicate with a key-value cache.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// Storage wraps any URLStorage and serves Get from the cache.
+//
+// Found links are cached for ttl and missing ones for negativeTTL. Writes invalidate
+// the affected short links, so a link deleted or purged by Cleanup stops resolving at once.
+// A link that expires is cached no longer than until its expiry, so it stops resolving on time.
+type Storage struct {
+	service.URLStorage
+	cache       Cache
+	log         *logger.Log
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewStorage creates a cached URLStorage.
+func NewStorage(store service.URLStorage, c Cache, log *logger.Log, ttl, negativeTTL time.Duration) *Storage {
+	return &Storage{URLStorage: store, cache: c, log: log, ttl: ttl, negativeTTL: negativeTTL}
+}
+
+// Get retrieves a URL by its short link from the cache falling back to the storage.
+func (s *Storage) Get(ctx context.Context, shortLink string) (string, error) {
+	key := keyPrefix + shortLink
+	cached, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.log.Err("failed to get url from cache", err)
+	}
+	if ok {
+		if cached == missMarker {
+			return "", service.ErrURLNotFound
+		}
+		return cached, nil
+	}
+
+	// a single read gives both the long URL and the expiry capping the ttl
+	record, err := s.URLStorage.GetURLRecord(ctx, shortLink)
+	if err != nil {
+		if errors.Is(err, service.ErrURLNotFound) {
+			s.set(ctx, key, missMarker, s.negativeTTL)
+		}
+		return "", err
+	}
+	if record.Deleted || record.Expired(time.Now()) {
+		// the storage tells a deleted link from an expired one, both are rare and never cached
+		return s.URLStorage.Get(ctx, shortLink)
+	}
+	if ttl := s.linkTTL(record); ttl >= time.Millisecond {
+		s.set(ctx, key, record.OriginalURL, ttl)
+	}
+	return record.OriginalURL, nil
+}
+
+// Save saves a new URL record and drops a cached miss for the short link.
+func (s *Storage) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
+	if err := s.URLStorage.Save(ctx, shortLink, longLink, expiresAt); err != nil {
+		return err
+	}
+	s.invalidate(ctx, []string{shortLink})
+	return nil
+}
+
+// BatchSave saves multiple URL records and drops cached misses for the short links.
+func (s *Storage) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
+	saved, err := s.URLStorage.BatchSave(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	shorts := make([]string, 0, len(input))
+	for _, in := range input {
+		shorts = append(shorts, in.ShortURL)
+	}
+	s.invalidate(ctx, shorts)
+	return saved, nil
+}
+
+// DeleteURLs marks URLs as deleted and invalidates them in the cache.
+func (s *Storage) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
+	if err := s.URLStorage.DeleteURLs(ctx, input); err != nil {
+		return err
+	}
+	s.invalidate(ctx, input)
+	return nil
+}
+
+// Cleanup removes deleted and expired URLs and invalidates them in the cache.
+func (s *Storage) Cleanup(ctx context.Context) ([]string, error) {
+	cleaned, err := s.URLStorage.Cleanup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, cleaned)
+	return cleaned, nil
+}
+
+// ImportURLs stores imported records and invalidates them in the cache.
+func (s *Storage) ImportURLs(
+	ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	res, err := s.URLStorage.ImportURLs(ctx, records, policy)
+	shorts := make([]string, 0, len(records))
+	for _, r := range records {
+		shorts = append(shorts, r.ShortURL)
+	}
+	// a failed batch may still have stored some records
+	s.invalidate(ctx, shorts)
+	return res, err
+}
+
+// SetURLDeleted marks the link deleted or live and invalidates it in the cache.
+func (s *Storage) SetURLDeleted(ctx context.Context, shortLink string, deleted bool) error {
+	if err := s.URLStorage.SetURLDeleted(ctx, shortLink, deleted); err != nil {
+		return err
+	}
+	s.invalidate(ctx, []string{shortLink})
+	return nil
+}
+
+// Close closes the cache and the underlying storage.
+func (s *Storage) Close() error {
+	return errors.Join(s.cache.Close(), s.URLStorage.Close())
+}
+
+// linkTTL returns the ttl of a found link capped by its expiry.
+func (s *Storage) linkTTL(record models.URLRecord) time.Duration {
+	if record.ExpiresAt == nil {
+		return s.ttl
+	}
+	return min(s.ttl, time.Until(*record.ExpiresAt))
+}
+
+func (s *Storage) set(ctx context.Context, key, value string, ttl time.Duration) {
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		s.log.Err("failed to set url into cache", err)
+	}
+}
+
+func (s *Storage) invalidate(ctx context.Context, shorts []string) {
+	if len(shorts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(shorts))
+	for _, short := range shorts {
+		keys = append(keys, keyPrefix+short)
+	}
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		s.log.Err("failed to invalidate cache", err)
+	}
+}
+
+// New creates the cache of the given type.
+func New(cacheType, addr string, size int) (Cache, error) {
+	switch cacheType {
+	case TypeLRU:
+		return NewLRU(size), nil
+	case TypeRESP:
+		return NewRESP(addr, defaultPoolSize), nil
+	}
+	return nil, fmt.Errorf("unknown cache type %q", cacheType)
+}
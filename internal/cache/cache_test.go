@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"shortener/internal/cache/resptest"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/service/mocks"
+)
+
+func TestStorage(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	srv, err := resptest.NewServer()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, srv.Close())
+	}()
+
+	caches := map[string]func() Cache{
+		TypeLRU:  func() Cache { return NewLRU(100) },
+		TypeRESP: func() Cache { return NewRESP(srv.Addr(), 2) },
+	}
+	for name, newCache := range caches {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mocks.NewMockURLStorage(ctrl)
+			store := NewStorage(mockStore, newCache(), log, time.Minute, time.Minute)
+
+			// read-through: the storage is hit once per short link
+			mockStore.EXPECT().GetURLRecord(ctx, "short1").Times(1).Return(models.URLRecord{OriginalURL: "https://example.org/1"}, nil)
+			mockStore.EXPECT().GetURLRecord(ctx, "missing").Times(1).Return(models.URLRecord{}, service.ErrURLNotFound)
+			for i := 0; i < 3; i++ {
+				long, err := store.Get(ctx, "short1")
+				assert.NoError(t, err)
+				assert.Equal(t, "https://example.org/1", long)
+				_, err = store.Get(ctx, "missing")
+				assert.ErrorIs(t, err, service.ErrURLNotFound)
+			}
+
+			// saving a link drops the cached miss
+			mockStore.EXPECT().Save(ctx, "missing", "https://example.org/2", nil).Return(nil)
+			mockStore.EXPECT().GetURLRecord(ctx, "missing").Times(1).Return(models.URLRecord{OriginalURL: "https://example.org/2"}, nil)
+			assert.NoError(t, store.Save(ctx, "missing", "https://example.org/2", nil))
+			long, err := store.Get(ctx, "missing")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.org/2", long)
+
+			// deleting a link invalidates it
+			mockStore.EXPECT().DeleteURLs(ctx, models.DeleteURLs{"short1"}).Return(nil)
+			mockStore.EXPECT().GetURLRecord(ctx, "short1").Times(1).Return(models.URLRecord{}, service.ErrURLNotFound)
+			assert.NoError(t, store.DeleteURLs(ctx, models.DeleteURLs{"short1"}))
+			_, err = store.Get(ctx, "short1")
+			assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+			// cleanup invalidates purged links
+			mockStore.EXPECT().Cleanup(ctx).Return([]string{"missing"}, nil)
+			mockStore.EXPECT().GetURLRecord(ctx, "missing").Times(1).Return(models.URLRecord{}, service.ErrURLNotFound)
+			_, err = store.Cleanup(ctx)
+			assert.NoError(t, err)
+			_, err = store.Get(ctx, "missing")
+			assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+			// restoring a link by an operator drops the cached miss
+			mockStore.EXPECT().SetURLDeleted(ctx, "missing", false).Return(nil)
+			mockStore.EXPECT().GetURLRecord(ctx, "missing").Times(1).Return(models.URLRecord{OriginalURL: "https://example.org/2"}, nil)
+			assert.NoError(t, store.SetURLDeleted(ctx, "missing", false))
+			long, err = store.Get(ctx, "missing")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.org/2", long)
+
+			mockStore.EXPECT().Close().Return(nil)
+			assert.NoError(t, store.Close())
+		})
+	}
+}
+
+func TestStorage_CacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewStorage(mockStore, NewRESP("127.0.0.1:1", 1), log, time.Minute, time.Minute)
+
+	mockStore.EXPECT().GetURLRecord(ctx, "short1").Times(2).Return(models.URLRecord{OriginalURL: "https://example.org/1"}, nil)
+	for i := 0; i < 2; i++ {
+		long, err := store.Get(ctx, "short1")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.org/1", long)
+	}
+}
+
+func TestStorage_ExpiringLink(t *testing.T) {
+	ctx := context.Background()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewStorage(mockStore, NewLRU(100), log, time.Minute, time.Minute)
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	record := models.URLRecord{OriginalURL: "https://example.org/1", ExpiresAt: &expiresAt}
+	mockStore.EXPECT().GetURLRecord(ctx, "short1").Times(2).Return(record, nil)
+	for i := 0; i < 2; i++ {
+		long, err := store.Get(ctx, "short1")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.org/1", long)
+	}
+
+	// the entry lives no longer than the link, which the storage reports expired afterwards
+	time.Sleep(time.Until(expiresAt) + 10*time.Millisecond)
+	errExpired := errors.New("expired")
+	mockStore.EXPECT().Get(ctx, "short1").Times(1).Return("", errExpired)
+	_, err := store.Get(ctx, "short1")
+	assert.ErrorIs(t, err, errExpired)
+}
+
+func TestStorage_DeletedLink(t *testing.T) {
+	ctx := context.Background()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewStorage(mockStore, NewLRU(100), log, time.Minute, time.Minute)
+
+	// the storage tells why the link is gone, and the link is not cached
+	errDeleted := errors.New("deleted")
+	record := models.URLRecord{OriginalURL: "https://example.org/1", Deleted: true}
+	mockStore.EXPECT().GetURLRecord(ctx, "short1").Times(2).Return(record, nil)
+	mockStore.EXPECT().Get(ctx, "short1").Times(2).Return("", errDeleted)
+	for i := 0; i < 2; i++ {
+		_, err := store.Get(ctx, "short1")
+		assert.ErrorIs(t, err, errDeleted)
+	}
+}
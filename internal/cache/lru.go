@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache evicting the least recently used entries.
+type LRU struct {
+	mux      sync.Mutex
+	items    map[string]*list.Element
+	order    *list.List
+	capacity int
+}
+
+type lruEntry struct {
+	expiresAt time.Time
+	key       string
+	value     string
+}
+
+// NewLRU creates a new LRU cache holding up to capacity entries.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+	}
+}
+
+// Get returns the cached value if it is present and not stale.
+func (c *LRU) Get(_ context.Context, key string) (string, bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return "", false, nil
+	}
+	entry := el.Value.(*lruEntry) //nolint:forcetypeassert // only lruEntry is stored
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(el)
+		return "", false, nil
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+// Set stores the value for ttl evicting the least recently used entry when full.
+func (c *LRU) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry) //nolint:forcetypeassert // only lruEntry is stored
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the keys from the cache.
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// Close does nothing for the in-process cache.
+func (c *LRU) Close() error {
+	return nil
+}
+
+// Len returns the number of cached entries.
+func (c *LRU) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key) //nolint:forcetypeassert // only lruEntry is stored
+}
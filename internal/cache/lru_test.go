@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	assert.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	assert.NoError(t, c.Set(ctx, "b", "2", time.Minute))
+	// touch "a" so "b" becomes the least recently used entry
+	v, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+
+	assert.NoError(t, c.Set(ctx, "c", "3", time.Minute))
+	assert.Equal(t, 2, c.Len())
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRU_TTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	assert.NoError(t, c.Set(ctx, "a", "1", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
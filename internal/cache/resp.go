@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPoolSize    = 4
+	defaultDialTimeout = time.Second
+	defaultIOTimeout   = time.Second
+)
+
+// RESP is a cache client speaking the Redis serialization protocol.
+type RESP struct {
+	conns chan *respConn
+	addr  string
+}
+
+type respConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRESP creates a client for the Redis-compatible server at addr keeping up to poolSize idle connections.
+func NewRESP(addr string, poolSize int) *RESP {
+	return &RESP{addr: addr, conns: make(chan *respConn, poolSize)}
+}
+
+// Get returns the cached value using the GET command.
+func (c *RESP) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return "", false, err
+	}
+	if reply == nil {
+		return "", false, nil
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return "", false, fmt.Errorf("unexpected GET reply %v", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores the value for ttl using the SET command with the PX option.
+func (c *RESP) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, err := c.do(ctx, "SET", key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Delete removes the keys using the DEL command.
+func (c *RESP) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Close closes the idle connections.
+func (c *RESP) Close() error {
+	var errs []error
+	for {
+		select {
+		case rc := <-c.conns:
+			errs = append(errs, rc.conn.Close())
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// do sends the command and reads a single reply.
+//
+// A connection that failed in the middle of a command is closed instead of being returned to the pool.
+func (c *RESP) do(ctx context.Context, args ...string) (any, error) {
+	rc, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultIOTimeout)
+	}
+	if err = rc.conn.SetDeadline(deadline); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to set deadline: %w", err), rc.conn.Close())
+	}
+	if _, err = rc.conn.Write(encodeCommand(args)); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to write command: %w", err), rc.conn.Close())
+	}
+	reply, err := readReply(rc.reader)
+	if err != nil {
+		var respErr *ServerError
+		if !errors.As(err, &respErr) {
+			return nil, errors.Join(fmt.Errorf("failed to read reply: %w", err), rc.conn.Close())
+		}
+	}
+	c.release(rc)
+	return reply, err
+}
+
+func (c *RESP) acquire(ctx context.Context) (*respConn, error) {
+	select {
+	case rc := <-c.conns:
+		return rc, nil
+	default:
+	}
+	d := net.Dialer{Timeout: defaultDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", c.addr, err)
+	}
+	return &respConn{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+func (c *RESP) release(rc *respConn) {
+	select {
+	case c.conns <- rc:
+	default:
+		_ = rc.conn.Close()
+	}
+}
+
+// ServerError is an error reply sent by the server.
+type ServerError struct {
+	Message string
+}
+
+// Error ...
+func (e *ServerError) Error() string {
+	return e.Message
+}
+
+// encodeCommand encodes the command as an array of bulk strings.
+func encodeCommand(args []string) []byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// readReply decodes a single reply. Nil bulk strings and arrays are returned as nil.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	payload := line[1:]
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, &ServerError{Message: payload}
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer reply: %w", err)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("failed to read bulk string: %w", err)
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", line[0])
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read line: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
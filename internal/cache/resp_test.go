@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/cache/resptest"
+)
+
+func TestRESP(t *testing.T) {
+	ctx := context.Background()
+	srv, err := resptest.NewServer()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, srv.Close())
+	}()
+	c := NewRESP(srv.Addr(), 2)
+	defer func() {
+		assert.NoError(t, c.Close())
+	}()
+
+	_, ok, err := c.Get(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "key", "https://example.org", time.Minute))
+	v, ok, err := c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.org", v)
+
+	assert.NoError(t, c.Delete(ctx, "key", "missing"))
+	_, ok, err = c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "short-lived", "v", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = c.Get(ctx, "short-lived")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRESP_DialError(t *testing.T) {
+	c := NewRESP("127.0.0.1:1", 1)
+	_, _, err := c.Get(context.Background(), "key")
+	assert.Error(t, err)
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{name: "simple string", input: "+OK\r\n", want: "OK"},
+		{name: "integer", input: ":42\r\n", want: int64(42)},
+		{name: "bulk string", input: "$5\r\nhello\r\n", want: "hello"},
+		{name: "nil bulk string", input: "$-1\r\n", want: nil},
+		{name: "array", input: "*2\r\n$1\r\na\r\n:1\r\n", want: []any{"a", int64(1)}},
+		{name: "server error", input: "-ERR boom\r\n", wantErr: true},
+		{name: "malformed", input: "+OK\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncodeCommand(t *testing.T) {
+	assert.Equal(t, "*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n", string(encodeCommand([]string{"GET", "key"})))
+}
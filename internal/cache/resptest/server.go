@@ -0,0 +1,168 @@
+// Package resptest provides an in-memory Redis-compatible server for tests.
+package resptest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an in-memory server supporting the GET, SET (with PX/EX), DEL and PING commands.
+type Server struct {
+	listener net.Listener
+	data     map[string]entry
+	mux      sync.Mutex
+	wg       sync.WaitGroup
+}
+
+type entry struct {
+	expiresAt time.Time
+	value     string
+}
+
+// NewServer starts a server on a random local port.
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	s := &Server{listener: l, data: make(map[string]entry)}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close() //nolint:errcheck // test server
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				_, _ = fmt.Fprintf(conn, "-ERR %s\r\n", err)
+			}
+			return
+		}
+		if _, err = conn.Write(s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(args []string) []byte {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(args) == 0 {
+		return []byte("-ERR empty command\r\n")
+	}
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return []byte("+PONG\r\n")
+	case "GET":
+		if len(args) != 2 {
+			return []byte("-ERR wrong number of arguments for 'get' command\r\n")
+		}
+		e, ok := s.data[args[1]]
+		if !ok || (!e.expiresAt.IsZero() && time.Now().After(e.expiresAt)) {
+			delete(s.data, args[1])
+			return []byte("$-1\r\n")
+		}
+		return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(e.value), e.value))
+	case "SET":
+		if len(args) != 3 && len(args) != 5 {
+			return []byte("-ERR syntax error\r\n")
+		}
+		e := entry{value: args[2]}
+		if len(args) == 5 {
+			n, err := strconv.ParseInt(args[4], 10, 64)
+			if err != nil {
+				return []byte("-ERR value is not an integer or out of range\r\n")
+			}
+			switch strings.ToUpper(args[3]) {
+			case "PX":
+				e.expiresAt = time.Now().Add(time.Duration(n) * time.Millisecond)
+			case "EX":
+				e.expiresAt = time.Now().Add(time.Duration(n) * time.Second)
+			default:
+				return []byte("-ERR syntax error\r\n")
+			}
+		}
+		s.data[args[1]] = e
+		return []byte("+OK\r\n")
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", deleted))
+	}
+	return []byte(fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0]))
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid array length: %w", err)
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bulk header: %w", err)
+		}
+		header = strings.TrimRight(header, "\r\n")
+		if !strings.HasPrefix(header, "$") {
+			return nil, fmt.Errorf("expected bulk string, got %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length: %w", err)
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read bulk string: %w", err)
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
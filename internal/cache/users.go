@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"shortener/internal/service"
+)
+
+const (
+	userKeyPrefix = "shortener:user-disabled:"
+	userDisabled  = "1"
+	userEnabled   = "0"
+)
+
+// UserStatusStorage wraps any URLStorage and keeps the disabled flags of the users in process for ttl,
+// as every authenticated request checks the flag of its user.
+//
+// SetUserDisabled drops the flag of the user at once, the other instances sharing the storage
+// see the change within ttl.
+type UserStatusStorage struct {
+	service.URLStorage
+	users *LRU
+	ttl   time.Duration
+}
+
+// NewUserStatusStorage creates a URLStorage caching up to capacity user flags for ttl.
+func NewUserStatusStorage(store service.URLStorage, capacity int, ttl time.Duration) *UserStatusStorage {
+	return &UserStatusStorage{URLStorage: store, users: NewLRU(capacity), ttl: ttl}
+}
+
+// IsUserDisabled reports whether the user is disabled from the cache falling back to the storage.
+func (s *UserStatusStorage) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	key := userKeyPrefix + userID
+	if cached, ok, _ := s.users.Get(ctx, key); ok {
+		return cached == userDisabled, nil
+	}
+	disabled, err := s.URLStorage.IsUserDisabled(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	value := userEnabled
+	if disabled {
+		value = userDisabled
+	}
+	_ = s.users.Set(ctx, key, value, s.ttl) // the in-process cache never fails
+	return disabled, nil
+}
+
+// SetUserDisabled disables or enables the user and drops the cached flag.
+func (s *UserStatusStorage) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	err := s.URLStorage.SetUserDisabled(ctx, userID, disabled)
+	// a failed change may still be stored
+	_ = s.users.Delete(ctx, userKeyPrefix+userID)
+	return err
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"shortener/internal/service/mocks"
+)
+
+func TestUserStatusStorage(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewUserStatusStorage(mockStore, 100, time.Minute)
+
+	// the storage is hit once per user
+	mockStore.EXPECT().IsUserDisabled(ctx, "user1").Times(1).Return(false, nil)
+	mockStore.EXPECT().IsUserDisabled(ctx, "user2").Times(1).Return(true, nil)
+	for i := 0; i < 3; i++ {
+		disabled, err := store.IsUserDisabled(ctx, "user1")
+		assert.NoError(t, err)
+		assert.False(t, disabled)
+		disabled, err = store.IsUserDisabled(ctx, "user2")
+		assert.NoError(t, err)
+		assert.True(t, disabled)
+	}
+
+	// disabling the user drops the cached flag
+	mockStore.EXPECT().SetUserDisabled(ctx, "user1", true).Return(nil)
+	mockStore.EXPECT().IsUserDisabled(ctx, "user1").Times(1).Return(true, nil)
+	assert.NoError(t, store.SetUserDisabled(ctx, "user1", true))
+	disabled, err := store.IsUserDisabled(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+
+	// errors are not cached
+	storageErr := errors.New("connection lost")
+	mockStore.EXPECT().IsUserDisabled(ctx, "user3").Times(2).Return(false, storageErr)
+	for i := 0; i < 2; i++ {
+		_, err = store.IsUserDisabled(ctx, "user3")
+		assert.ErrorIs(t, err, storageErr)
+	}
+}
+
+func TestUserStatusStorage_TTL(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewUserStatusStorage(mockStore, 100, 20*time.Millisecond)
+
+	// another instance disables the user, the change is seen once the flag expires
+	gomock.InOrder(
+		mockStore.EXPECT().IsUserDisabled(ctx, "user1").Return(false, nil),
+		mockStore.EXPECT().IsUserDisabled(ctx, "user1").Return(true, nil),
+	)
+	disabled, err := store.IsUserDisabled(ctx, "user1")
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Eventually(t, func() bool {
+		disabled, err := store.IsUserDisabled(ctx, "user1")
+		return err == nil && disabled
+	}, time.Second, 10*time.Millisecond)
+}
@@ -34,12 +34,69 @@ type ServiceConfig struct {
 type AppConfig struct {
 	ServerAddress    string `env:"SERVER_ADDRESS" envDefault:":8080"`
 	ServerAddressTLS string `env:"SERVER_ADDRESS_TLS" envDefault:":8443"`
-	BaseURL          string `env:"BASE_URL" envDefault:"http://localhost:8080"`
-	FileStoragePath  string `env:"FILE_STORAGE_PATH" envDefault:"/tmp/short-url-db.json"`
-	DatabaseDSN      string `env:"DATABASE_DSN"`
-	ConfigFilePath   string `env:"CONFIG" envDefault:""`
-	TrustedSubnet    string `env:"TRUSTED_SUBNET"`
-	EnableHTTPS      bool   `env:"ENABLE_HTTPS" envDefault:"0"`
+	// DebugAddress serves /metrics and /debug apart from the API. Empty value disables them.
+	DebugAddress    string `env:"DEBUG_ADDRESS" envDefault:"localhost:6060"`
+	BaseURL         string `env:"BASE_URL" envDefault:"http://localhost:8080"`
+	FileStoragePath string `env:"FILE_STORAGE_PATH" envDefault:"/tmp/short-url-db.json"`
+	// FileStorageFsync is the fsync policy of the file storage log: "always", "interval" or "never".
+	FileStorageFsync         string        `env:"FILE_STORAGE_FSYNC" envDefault:"always"`
+	FileStorageFsyncInterval time.Duration `env:"FILE_STORAGE_FSYNC_INTERVAL" envDefault:"1s"`
+	DatabaseDSN              string        `env:"DATABASE_DSN"`
+	// KVStoragePath is the embedded key-value storage file, it takes precedence over the file storage.
+	KVStoragePath  string `env:"KV_STORAGE_PATH"`
+	ConfigFilePath string `env:"CONFIG" envDefault:""`
+	TrustedSubnet  string `env:"TRUSTED_SUBNET"`
+	// TrustedProxies are the comma separated addresses and subnets of the proxies allowed to set X-Real-IP
+	// and X-Forwarded-For, the trusted subnet is allowed as well.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	EnableHTTPS    bool     `env:"ENABLE_HTTPS" envDefault:"0"`
+	// CacheType enables the read-through cache: "lru" or "redis". Empty value disables it.
+	CacheType        string        `env:"CACHE_TYPE"`
+	CacheAddr        string        `env:"CACHE_ADDR" envDefault:"localhost:6379"`
+	CacheSize        int           `env:"CACHE_SIZE" envDefault:"10000"`
+	CacheTTL         time.Duration `env:"CACHE_TTL" envDefault:"5m"`
+	CacheNegativeTTL time.Duration `env:"CACHE_NEGATIVE_TTL" envDefault:"30s"`
+	// UserStatusTTL is how long the disabled flag of a user is kept in process. Zero checks the storage
+	// on every request.
+	UserStatusTTL time.Duration `env:"USER_STATUS_TTL" envDefault:"5s"`
+	// ShortCodeStrategy selects the short link generator: "random", "sequence" or "hashid".
+	ShortCodeStrategy string `env:"SHORT_CODE_STRATEGY" envDefault:"random"`
+	ShortCodeLength   int    `env:"SHORT_CODE_LENGTH" envDefault:"8"`
+	ShortCodeSalt     string `env:"SHORT_CODE_SALT"`
+	// AuditLogPath is the JSON lines file of admin API actions. Empty value writes them to the service log.
+	AuditLogPath string `env:"AUDIT_LOG_PATH"`
+	// BlocklistPath is the file of blocked destinations, reloaded on SIGHUP and on change. Empty value disables it.
+	BlocklistPath           string        `env:"BLOCKLIST_PATH"`
+	BlocklistReloadInterval time.Duration `env:"BLOCKLIST_RELOAD_INTERVAL" envDefault:"10s"`
+	// RateLimitSave and the others are tokens per second refilled per user and per IP address, 0 disables the limit.
+	RateLimitSave          float64 `env:"RATE_LIMIT_SAVE" envDefault:"10"`
+	RateLimitSaveBurst     int     `env:"RATE_LIMIT_SAVE_BURST" envDefault:"50"`
+	RateLimitBatch         float64 `env:"RATE_LIMIT_BATCH" envDefault:"1"`
+	RateLimitBatchBurst    int     `env:"RATE_LIMIT_BATCH_BURST" envDefault:"10"`
+	RateLimitRedirect      float64 `env:"RATE_LIMIT_REDIRECT" envDefault:"100"`
+	RateLimitRedirectBurst int     `env:"RATE_LIMIT_REDIRECT_BURST" envDefault:"200"`
+	RateLimitLogin         float64 `env:"RATE_LIMIT_LOGIN" envDefault:"0.2"`
+	RateLimitLoginBurst    int     `env:"RATE_LIMIT_LOGIN_BURST" envDefault:"5"`
+	// OTLPEndpoint is the OTLP gRPC collector URL receiving the traces. Empty value disables the export.
+	OTLPEndpoint string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// LogFormat is text or json, LogLevel is DEBUG, INFO, WARN or ERROR.
+	LogFormat string `env:"LOG_FORMAT" envDefault:"text"`
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"INFO"`
+	// LogOutput is stdout, stderr or a file path to append the log to.
+	LogOutput string `env:"LOG_OUTPUT" envDefault:"stdout"`
+	// GRPCAddress is the gRPC listener. GRPCTLSCert and GRPCTLSKey enable TLS on it,
+	// with ENABLE_HTTPS it falls back to the HTTPS certificate.
+	GRPCAddress    string `env:"GRPC_ADDRESS" envDefault:":50051"`
+	GRPCTLSCert    string `env:"GRPC_TLS_CERT"`
+	GRPCTLSKey     string `env:"GRPC_TLS_KEY"`
+	GRPCReflection bool   `env:"GRPC_REFLECTION" envDefault:"true"`
+	// GRPCMaxMessageSize limits the received and sent messages in bytes.
+	GRPCMaxMessageSize int `env:"GRPC_MAX_MESSAGE_SIZE" envDefault:"4194304"`
+	// GRPCKeepaliveTime pings an idle client, which is dropped after GRPCKeepaliveTimeout without a reply.
+	// Clients pinging more often than GRPCKeepaliveMinTime are disconnected.
+	GRPCKeepaliveTime    time.Duration `env:"GRPC_KEEPALIVE_TIME" envDefault:"2h"`
+	GRPCKeepaliveTimeout time.Duration `env:"GRPC_KEEPALIVE_TIMEOUT" envDefault:"20s"`
+	GRPCKeepaliveMinTime time.Duration `env:"GRPC_KEEPALIVE_MIN_TIME" envDefault:"5m"`
 }
 
 // Config contains main config structures.
@@ -0,0 +1,119 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+	pb "shortener/pkg/service/proto"
+)
+
+// AdminGetURL returns the owner and the metadata of any short link.
+func (g *GRPCServer) AdminGetURL(ctx context.Context, in *pb.AdminURLRequest) (*pb.AdminURLResponse, error) {
+	actor, err := g.trustedPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := g.svc.AdminGetURL(ctx, actor, in.GetShort())
+	if err != nil {
+		if errors.Is(err, service.ErrURLNotFound) {
+			return nil, status.Error(codes.NotFound, "Requested URL not found")
+		}
+		g.svc.Log.ErrContext(ctx, "failed to get url", err)
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &pb.AdminURLResponse{Url: adminURL(res)}, nil
+}
+
+// AdminDeleteURL marks any short link deleted.
+func (g *GRPCServer) AdminDeleteURL(ctx context.Context, in *pb.AdminURLRequest) (*pb.AdminEmpty, error) {
+	actor, err := g.trustedPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.svc.AdminDeleteURL(ctx, actor, in.GetShort()); err != nil {
+		if errors.Is(err, service.ErrURLNotFound) {
+			return nil, status.Error(codes.NotFound, "Requested URL not found")
+		}
+		g.svc.Log.ErrContext(ctx, "failed to delete url", err)
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &pb.AdminEmpty{}, nil
+}
+
+// AdminRestoreURL brings back a deleted short link.
+func (g *GRPCServer) AdminRestoreURL(ctx context.Context, in *pb.AdminURLRequest) (*pb.AdminEmpty, error) {
+	actor, err := g.trustedPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.svc.AdminRestoreURL(ctx, actor, in.GetShort()); err != nil {
+		switch {
+		case errors.Is(err, service.ErrURLNotFound):
+			return nil, status.Error(codes.NotFound, "Requested URL not found")
+		case errors.Is(err, service.ErrRestoreConflict):
+			return nil, status.Error(codes.AlreadyExists, "URL conflicts with a live one")
+		default:
+			g.svc.Log.ErrContext(ctx, "failed to restore url", err)
+			return nil, status.Error(codes.Internal, "")
+		}
+	}
+	return &pb.AdminEmpty{}, nil
+}
+
+// AdminUserURLs returns every link of the user, deleted ones included.
+func (g *GRPCServer) AdminUserURLs(ctx context.Context, in *pb.AdminUserRequest) (*pb.AdminUserURLsResponse, error) {
+	actor, err := g.trustedPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := g.svc.AdminUserURLs(ctx, actor, in.GetUserId())
+	if err != nil {
+		g.svc.Log.ErrContext(ctx, "failed to list user urls", err)
+		return nil, status.Error(codes.Internal, "")
+	}
+	res := &pb.AdminUserURLsResponse{}
+	for _, u := range urls {
+		res.Urls = append(res.Urls, adminURL(u))
+	}
+	return res, nil
+}
+
+// AdminSetUserDisabled disables or enables the user.
+func (g *GRPCServer) AdminSetUserDisabled(
+	ctx context.Context, in *pb.AdminSetUserDisabledRequest,
+) (*pb.AdminEmpty, error) {
+	actor, err := g.trustedPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if in.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "User ID is required")
+	}
+	if err = g.svc.AdminSetUserDisabled(ctx, actor, in.GetUserId(), in.GetDisabled()); err != nil {
+		g.svc.Log.ErrContext(ctx, "failed to update user", err)
+		return nil, status.Error(codes.Internal, "")
+	}
+	return &pb.AdminEmpty{}, nil
+}
+
+func adminURL(u models.AdminURL) *pb.AdminURL {
+	res := &pb.AdminURL{
+		Uuid:         u.UUID,
+		ShortUrl:     u.ShortURL,
+		OriginalUrl:  u.OriginalURL,
+		UserId:       u.UserID,
+		IsDeleted:    u.Deleted,
+		IsExpired:    u.Expired,
+		UserDisabled: u.UserDisabled,
+	}
+	if u.ExpiresAt != nil {
+		res.ExpiresAt = timestamppb.New(*u.ExpiresAt)
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb "shortener/pkg/service/proto"
+)
+
+func TestServer_TokenRoundTrip(t *testing.T) {
+	svc := newTestService(t)
+	s, err := New(svc, Options{})
+	require.NoError(t, err)
+	client := dial(t, s.Server)
+
+	var header metadata.MD
+	_, err = client.Save(context.Background(), wrapperspb.String("https://example.com/auth"), grpc.Header(&header))
+	require.NoError(t, err)
+	tokens := header.Get("token")
+	require.Len(t, tokens, 1)
+	token := tokens[0]
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		wantURLs int
+		wantCode codes.Code
+	}{
+		{name: "token metadata", md: metadata.Pairs("token", token), wantURLs: 1},
+		{name: "bearer token", md: metadata.Pairs("authorization", "Bearer "+token), wantURLs: 1},
+		{name: "no token is a new user", md: metadata.MD{}, wantURLs: 0},
+		{name: "invalid token", md: metadata.Pairs("token", "invalid"), wantCode: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+
+			var header metadata.MD
+			res, err := client.SavedByUser(ctx, &pb.SavedByUserRequest{}, grpc.Header(&header))
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, res.GetUrls(), tt.wantURLs)
+			}
+
+			stream, err := client.ListUserURLs(ctx, &pb.ListUserURLsRequest{})
+			require.NoError(t, err)
+			var got int
+			for {
+				_, err = stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if tt.wantCode != codes.OK {
+					assert.Equal(t, tt.wantCode, status.Code(err))
+					return
+				}
+				require.NoError(t, err)
+				got++
+			}
+			assert.Equal(t, tt.wantURLs, got)
+
+			streamHeader, err := stream.Header()
+			require.NoError(t, err)
+			minted := len(tt.md.Get("token")) == 0 && len(tt.md.Get("authorization")) == 0
+			assert.Equal(t, minted, len(header.Get("token")) == 1, "unary token header")
+			assert.Equal(t, minted, len(streamHeader.Get("token")) == 1, "stream token header")
+		})
+	}
+}
@@ -6,16 +6,23 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"shortener/internal/interceptors"
-	"shortener/internal/logger"
+	"shortener/internal/metrics"
 	"shortener/internal/models"
 	"shortener/internal/service"
 	"shortener/internal/storage"
@@ -27,18 +34,126 @@ type GRPCServer struct {
 	svc *service.Service
 }
 
-// GRPCServe runs the gRPC server.
-func GRPCServe(svc *service.Service, log *logger.Log) error {
-	listen, err := net.Listen("tcp", ":50051")
-	if err != nil {
-		return fmt.Errorf("failed to listen a port: %w", err)
+// Options configure the gRPC server.
+type Options struct {
+	// TLSCert and TLSKey are the certificate files, TLS is disabled without them.
+	TLSCert string
+	TLSKey  string
+	// Reflection registers the reflection service for grpcurl and the like.
+	Reflection bool
+	// MaxMessageSize limits the received and sent messages in bytes, the gRPC default if not positive.
+	MaxMessageSize int
+	// KeepaliveTime, KeepaliveTimeout and KeepaliveMinTime are the keepalive settings, gRPC defaults if zero.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	KeepaliveMinTime time.Duration
+}
+
+// Server is the gRPC server of the service.
+type Server struct {
+	*grpc.Server
+	svc    *service.Service
+	health *health.Server
+}
+
+// New creates the gRPC server with the service, the health and, if enabled, the reflection registered.
+func New(svc *service.Service, opts Options) (*Server, error) {
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			interceptors.RequestIDUnaryInterceptor(svc),
+			interceptors.TracingUnaryInterceptor,
+			interceptors.MetricsUnaryInterceptor,
+			interceptors.UserIDUnaryInterceptor(svc),
+			interceptors.RateLimitUnaryInterceptor(svc),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.RequestIDStreamInterceptor(svc),
+			interceptors.TracingStreamInterceptor,
+			interceptors.MetricsStreamInterceptor,
+			interceptors.UserIDStreamInterceptor(svc),
+			interceptors.RateLimitStreamInterceptor(svc),
+		),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    opts.KeepaliveTime,
+			Timeout: opts.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             opts.KeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if opts.MaxMessageSize > 0 {
+		serverOpts = append(serverOpts,
+			grpc.MaxRecvMsgSize(opts.MaxMessageSize),
+			grpc.MaxSendMsgSize(opts.MaxMessageSize),
+		)
+	}
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(opts.TLSCert, opts.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	s := &Server{
+		Server: grpc.NewServer(serverOpts...),
+		svc:    svc,
+		health: health.NewServer(),
+	}
+	pb.RegisterURLShortenerServiceServer(s.Server, &GRPCServer{svc: svc})
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	if opts.Reflection {
+		reflection.Register(s.Server)
+	}
+	return s, nil
+}
+
+// Serve accepts the calls on the listener until the server is stopped, reporting its health meanwhile.
+func (s *Server) Serve(listen net.Listener) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go reportHealth(s.svc, s.health, stop)
+
+	s.svc.Log.Debug("Starting gRPC server..", "address", listen.Addr().String())
+	if err := s.Server.Serve(listen); err != nil {
+		return fmt.Errorf("failed to serve gRPC: %w", err)
 	}
-	s := grpc.NewServer(grpc.UnaryInterceptor(interceptors.UserIDUnaryInterceptor(svc)))
-	pb.RegisterURLShortenerServiceServer(s, &GRPCServer{svc: svc})
-	reflection.Register(s)
-	log.Debug("Starting gRPC server..")
+	return nil
+}
+
+// GracefulStop reports NOT_SERVING to the health checks and waits for the running calls to finish.
+func (s *Server) GracefulStop() {
+	s.health.Shutdown()
+	s.Server.GracefulStop()
+}
+
+// healthInterval is the period of the readiness checks behind the gRPC health service.
+const healthInterval = 10 * time.Second
 
-	return s.Serve(listen)
+// reportHealth serves the readiness of the service to the gRPC health checks until the stop is closed.
+func reportHealth(svc *service.Service, healthServer *health.Server, stop <-chan struct{}) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		// a round of the checks must not outlast the interval, whatever the checks themselves do
+		ctx, cancel := context.WithTimeout(context.Background(), healthInterval)
+		report := svc.Health.Ready(ctx)
+		cancel()
+		if !report.OK() {
+			svc.Log.Warn("service not ready", "checks", report.Checks)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.URLShortenerService_ServiceDesc.ServiceName, status)
+		select {
+		case <-stop:
+			healthServer.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 // Save method saves long url and replies short one.
@@ -47,13 +162,19 @@ func (g *GRPCServer) Save(ctx context.Context, long *wrapperspb.StringValue) (*w
 		return nil, status.Error(codes.InvalidArgument, "Invalid URL passed")
 	}
 
-	short, err := g.svc.SaveURL(ctx, long.String())
+	short, err := g.svc.SaveURL(ctx, models.ShortenRequest{URL: long.GetValue()})
 	if err != nil {
 		var duplicateError *storage.DuplicateRecordError
 		if errors.As(err, &duplicateError) {
 			return nil, status.Error(codes.AlreadyExists, duplicateError.Message)
 		}
-		g.svc.Log.Err("failed to save URL", err)
+		if errors.Is(err, service.ErrInvalidURL) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid URL passed")
+		}
+		if errors.Is(err, service.ErrURLBlocked) {
+			return nil, status.Error(codes.PermissionDenied, "Destination is blocked")
+		}
+		g.svc.Log.ErrContext(ctx, "failed to save URL", err)
 		return nil, status.Error(codes.Internal, "failed to save URL")
 	}
 
@@ -63,7 +184,7 @@ func (g *GRPCServer) Save(ctx context.Context, long *wrapperspb.StringValue) (*w
 // Ping checks if connection to database can be established.
 func (g *GRPCServer) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
 	if err := g.svc.Storage.Ping(ctx); err != nil {
-		g.svc.Log.Err("failed to ping database", err)
+		g.svc.Log.ErrContext(ctx, "failed to ping database", err)
 		return nil, status.Error(codes.Unavailable, "")
 	}
 
@@ -74,7 +195,7 @@ func (g *GRPCServer) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingRespo
 func (g *GRPCServer) SavedByUser(ctx context.Context, _ *pb.SavedByUserRequest) (*pb.SavedByUserResponse, error) {
 	urls, err := g.svc.GetUserURLs(ctx)
 	if err != nil {
-		g.svc.Log.Err("failed to get user urls", err)
+		g.svc.Log.ErrContext(ctx, "failed to get user urls", err)
 		return nil, status.Error(codes.Internal, "")
 	}
 	result := &pb.SavedByUserResponse{}
@@ -91,30 +212,61 @@ func (g *GRPCServer) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetRespons
 	if err != nil {
 		switch {
 		case errors.Is(err, storage.ErrURLDeleted):
-			g.svc.Log.Info("requested deleted url", "short", in.GetShort())
+			g.svc.Log.InfoContext(ctx, "requested deleted url", "short", in.GetShort())
+			metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
 			return nil, status.Error(codes.Unavailable, "Requested deleted URL")
+		case errors.Is(err, storage.ErrURLExpired):
+			g.svc.Log.InfoContext(ctx, "requested expired url", "short", in.GetShort())
+			metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+			return nil, status.Error(codes.FailedPrecondition, "Requested URL has expired")
+		case errors.Is(err, service.ErrURLBlocked):
+			metrics.Redirects.WithLabelValues(metrics.RedirectBlocked).Inc()
+			return nil, status.Error(codes.PermissionDenied, "Destination is blocked")
 		case errors.Is(err, service.ErrURLNotFound):
+			metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
 			return nil, status.Error(codes.NotFound, "Requested URL not found")
 		default:
-			g.svc.Log.Err("failed to get URL", err)
+			g.svc.Log.ErrContext(ctx, "failed to get URL", err)
 			return nil, status.Error(codes.Internal, "")
 		}
 	}
 
+	metrics.Redirects.WithLabelValues(metrics.RedirectHit).Inc()
+	g.svc.RecordClick(models.Click{
+		Time:      time.Now().UTC(),
+		Short:     in.GetShort(),
+		UserAgent: firstMetadata(ctx, "user-agent"),
+		ClientIP:  peerIP(ctx),
+	})
 	return &pb.GetResponse{Long: g.svc.BaseURL + "/" + long}, nil
 }
 
+// ClickStats returns click totals and the per-day histogram for a link owned by the user.
+func (g *GRPCServer) ClickStats(ctx context.Context, in *pb.ClickStatsRequest) (*pb.ClickStatsResponse, error) {
+	stats, err := g.svc.GetClickStats(ctx, in.GetShort())
+	if err != nil {
+		if errors.Is(err, service.ErrURLNotFound) {
+			return nil, status.Error(codes.NotFound, "Requested URL not found")
+		}
+		g.svc.Log.ErrContext(ctx, "failed to get click stats", err)
+		return nil, status.Error(codes.Internal, "")
+	}
+	res := &pb.ClickStatsResponse{Short: stats.Short, Total: int64(stats.Total)}
+	for _, day := range stats.Days {
+		res.Days = append(res.Days, &pb.DayClicks{Day: day.Day, Clicks: int64(day.Clicks)})
+	}
+	return res, nil
+}
+
 // Batch saves many urls for the one call.
 func (g *GRPCServer) Batch(ctx context.Context, in *pb.BatchRequest) (*pb.BatchResponse, error) {
 	req := make([]models.BatchRequest, 0)
 	for _, u := range in.GetUrls() {
-		req = append(req, models.BatchRequest{
-			OriginalURL: u.GetOriginalUrl(), CorrelationID: u.GetCorrelationId()},
-		)
+		req = append(req, batchRequest(u))
 	}
 	saved, err := g.svc.SaveURLs(ctx, req)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "")
+		return nil, batchError(err)
 	}
 	res := &pb.BatchResponse{}
 	for _, u := range saved {
@@ -129,10 +281,44 @@ func (g *GRPCServer) Batch(ctx context.Context, in *pb.BatchRequest) (*pb.BatchR
 	return &pb.BatchResponse{Urls: res.GetUrls()}, nil
 }
 
+// batchRequest converts the batch entity of the call.
+func batchRequest(u *pb.BatchRequestEntity) models.BatchRequest {
+	return models.BatchRequest{
+		OriginalURL:   u.GetOriginalUrl(),
+		CorrelationID: u.GetCorrelationId(),
+		Alias:         u.GetAlias(),
+		ExpiresAt:     expiresAt(u.GetExpiresAt()),
+		TTL:           u.GetTtlSeconds(),
+	}
+}
+
+// batchError returns the status error of a failed batch save.
+func batchError(err error) error {
+	var duplicateErr *storage.DuplicateRecordError
+	switch {
+	case errors.As(err, &duplicateErr):
+		return status.Error(codes.AlreadyExists, "URL is already shortened")
+	case errors.Is(err, service.ErrDuplicateURL):
+		return status.Error(codes.AlreadyExists, "URL repeated in batch with an alias")
+	case errors.Is(err, service.ErrInvalidURL):
+		return status.Error(codes.InvalidArgument, "Invalid URL")
+	case errors.Is(err, service.ErrURLBlocked):
+		return status.Error(codes.PermissionDenied, "Destination is blocked")
+	case errors.Is(err, service.ErrInvalidAlias):
+		return status.Error(codes.InvalidArgument, "Invalid alias")
+	case errors.Is(err, service.ErrInvalidExpiry):
+		return status.Error(codes.InvalidArgument, "Invalid expiration")
+	case errors.Is(err, service.ErrShortURLExists):
+		return status.Error(codes.AlreadyExists, "Alias is already taken")
+	default:
+		return status.Error(codes.Internal, "")
+	}
+}
+
 // DeleteMany deletes many urls for the one call.
 func (g *GRPCServer) DeleteMany(ctx context.Context, in *pb.DeleteRequest) (*pb.DeleteResponse, error) {
 	if err := g.svc.DeleteURLs(ctx, in.GetUrls()); err != nil {
-		g.svc.Log.Err("failed to delete URLs", err)
+		g.svc.Log.ErrContext(ctx, "failed to delete URLs", err)
 		return nil, status.Error(codes.Internal, "")
 	}
 
@@ -141,15 +327,31 @@ func (g *GRPCServer) DeleteMany(ctx context.Context, in *pb.DeleteRequest) (*pb.
 
 // Shorten method saves long and returns short url.
 func (g *GRPCServer) Shorten(ctx context.Context, in *pb.ShortenRequest) (*pb.ShortenResponse, error) {
-	short, err := g.svc.SaveURL(ctx, in.GetUrl())
+	short, err := g.svc.SaveURL(ctx, models.ShortenRequest{
+		URL:       in.GetUrl(),
+		Alias:     in.GetAlias(),
+		ExpiresAt: expiresAt(in.GetExpiresAt()),
+		TTL:       in.GetTtlSeconds(),
+	})
 	if err != nil {
 		var duplicateErr *storage.DuplicateRecordError
-		if errors.As(err, &duplicateErr) {
-			g.svc.Log.Warn("failed to save url", err)
+		switch {
+		case errors.As(err, &duplicateErr):
+			g.svc.Log.WarnContext(ctx, "failed to save url", "err", err)
 			duplicate := g.svc.BaseURL + "/" + duplicateErr.Message
 			return nil, status.Error(codes.AlreadyExists, duplicate)
-		} else {
-			g.svc.Log.Err("failed to save url", err)
+		case errors.Is(err, service.ErrInvalidURL):
+			return nil, status.Error(codes.InvalidArgument, "Invalid URL")
+		case errors.Is(err, service.ErrURLBlocked):
+			return nil, status.Error(codes.PermissionDenied, "Destination is blocked")
+		case errors.Is(err, service.ErrInvalidAlias):
+			return nil, status.Error(codes.InvalidArgument, "Invalid alias")
+		case errors.Is(err, service.ErrInvalidExpiry):
+			return nil, status.Error(codes.InvalidArgument, "Invalid expiration")
+		case errors.Is(err, service.ErrShortURLExists):
+			return nil, status.Error(codes.AlreadyExists, "Alias is already taken")
+		default:
+			g.svc.Log.ErrContext(ctx, "failed to save url", err)
 			return nil, status.Error(codes.Internal, "")
 		}
 	}
@@ -159,25 +361,66 @@ func (g *GRPCServer) Shorten(ctx context.Context, in *pb.ShortenRequest) (*pb.Sh
 
 // Stats method shows internal info about saved users and urls.
 func (g *GRPCServer) Stats(ctx context.Context, _ *pb.StatsRequest) (*pb.StatsResponse, error) {
+	if _, err := g.trustedPeer(ctx); err != nil {
+		return nil, err
+	}
+
+	stats, err := g.svc.GetStats(ctx)
+	if err != nil {
+		g.svc.Log.ErrContext(ctx, "failed to get stats", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &pb.StatsResponse{
+		Urls:    strconv.Itoa(stats.URLs),
+		Users:   strconv.Itoa(stats.Users),
+		Blocked: strconv.Itoa(stats.Blocked),
+	}, nil
+}
+
+// trustedPeer returns the IP address of the calling client if it belongs to the trusted subnet.
+func (g *GRPCServer) trustedPeer(ctx context.Context) (string, error) {
 	const permissionDeniedMsg = "Untrusted subnet"
 	p, ok := peer.FromContext(ctx)
 	if !ok {
-		return nil, status.Error(codes.PermissionDenied, permissionDeniedMsg)
+		return "", status.Error(codes.PermissionDenied, permissionDeniedMsg)
 	}
 	tcpAddr, ok := p.Addr.(*net.TCPAddr)
 	if !ok {
-		return nil, status.Error(codes.PermissionDenied, permissionDeniedMsg)
+		return "", status.Error(codes.PermissionDenied, permissionDeniedMsg)
 	}
+	ip := tcpAddr.IP.String()
+	if !g.svc.IsSubnetTrusted(ip) {
+		return "", status.Error(codes.PermissionDenied, permissionDeniedMsg)
+	}
+	return ip, nil
+}
 
-	if !g.svc.IsSubnetTrusted(tcpAddr.IP.String()) {
-		return nil, status.Error(codes.PermissionDenied, permissionDeniedMsg)
+// expiresAt converts the optional protobuf timestamp into the model deadline.
+func expiresAt(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
 	}
+	t := ts.AsTime()
+	return &t
+}
 
-	stats, err := g.svc.GetStats(ctx)
-	if err != nil {
-		g.svc.Log.Err("failed to get stats", err)
-		return nil, status.Error(codes.Internal, "Internal server error")
+// firstMetadata returns the first incoming metadata value for the key.
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
 	}
+	return ""
+}
 
-	return &pb.StatsResponse{Urls: strconv.Itoa(stats.URLs), Users: strconv.Itoa(stats.Users)}, nil
+// peerIP returns the IP address of the calling client.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if tcpAddr, ok := p.Addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+	return p.Addr.String()
 }
@@ -0,0 +1,154 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"shortener/internal/models"
+	pb "shortener/pkg/service/proto"
+)
+
+const (
+	// batchStreamChunk is the number of URLs BatchStream saves and replies at once.
+	batchStreamChunk = 500
+	// savedCountKey is the trailer of a failed BatchStream with the number of the URLs saved before.
+	savedCountKey = "saved-count"
+	// defaultPageSize and maxPageSize bound the storage pages of ListUserURLs.
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// BatchStream saves the streamed URLs in chunks and replies the short ones of each chunk as soon as it is saved,
+// so a reply never grows with the stream.
+//
+// The chunks saved before a failed one are kept. The failure tells how many URLs were saved in its message
+// and in the saved-count trailer.
+func (g *GRPCServer) BatchStream(stream grpc.BidiStreamingServer[pb.BatchRequestEntity, pb.BatchResponse]) error {
+	ctx := stream.Context()
+	if err := requireUser(ctx); err != nil {
+		return err
+	}
+	saved := 0
+	chunk := make([]models.BatchRequest, 0, batchStreamChunk)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		urls, err := g.svc.SaveURLs(ctx, chunk)
+		if err != nil {
+			g.svc.Log.WarnContext(ctx, "failed to save urls chunk", "saved", saved, "err", err)
+			stream.SetTrailer(metadata.Pairs(savedCountKey, strconv.Itoa(saved)))
+			st := status.Convert(batchError(err))
+			return status.Errorf(st.Code(), "%s, %d urls saved before", st.Message(), saved)
+		}
+		res := &pb.BatchResponse{Urls: make([]*pb.BatchResponseEntity, 0, len(urls))}
+		for _, u := range urls {
+			res.Urls = append(res.Urls, &pb.BatchResponseEntity{CorrelationId: u.CorrelationID, ShortUrl: u.ShortURL})
+		}
+		if err = stream.Send(res); err != nil {
+			return err
+		}
+		saved += len(urls)
+		chunk = chunk[:0]
+		return nil
+	}
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return flush()
+		}
+		if err != nil {
+			stream.SetTrailer(metadata.Pairs(savedCountKey, strconv.Itoa(saved)))
+			return err
+		}
+		chunk = append(chunk, batchRequest(in))
+		if len(chunk) == batchStreamChunk {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ShortenStream saves each streamed URL and replies its short one right away.
+//
+// A URL that can not be saved, such as an invalid or a blocked one, gets the error in its reply and
+// the stream goes on. A storage failure ends the stream.
+func (g *GRPCServer) ShortenStream(stream grpc.BidiStreamingServer[pb.BatchRequestEntity, pb.ShortenStreamResponse]) error {
+	ctx := stream.Context()
+	if err := requireUser(ctx); err != nil {
+		return err
+	}
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res := &pb.ShortenStreamResponse{CorrelationId: in.GetCorrelationId()}
+		saved, err := g.svc.SaveURLs(ctx, []models.BatchRequest{batchRequest(in)})
+		if err != nil {
+			st := status.Convert(batchError(err))
+			if st.Code() == codes.Internal {
+				g.svc.Log.ErrContext(ctx, "failed to save URL", err)
+				return st.Err()
+			}
+			res.Error = st.Message()
+		} else if len(saved) > 0 {
+			res.ShortUrl = saved[0].ShortURL
+		}
+		if err = stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// ListUserURLs streams the URLs of the user reading them from the storage by pages.
+func (g *GRPCServer) ListUserURLs(in *pb.ListUserURLsRequest, stream grpc.ServerStreamingServer[pb.URL]) error {
+	ctx := stream.Context()
+	if err := requireUser(ctx); err != nil {
+		return err
+	}
+	pageSize := int(in.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return status.Error(codes.InvalidArgument, "Invalid page size")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+	err := g.svc.UserURLPages(ctx, pageSize, func(page models.UserURLs) error {
+		for _, u := range page {
+			if err := stream.Send(&pb.URL{ShortUrl: u.ShortURL, OriginalUrl: u.OriginalURL}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		g.svc.Log.ErrContext(ctx, "failed to list user urls", err)
+		return status.Error(codes.Internal, "")
+	}
+	return nil
+}
+
+// requireUser returns Unauthenticated unless UserIDStreamInterceptor put the user into the context.
+func requireUser(ctx context.Context) error {
+	if userID, _ := ctx.Value(models.CtxUserIDKey).(string); userID == "" {
+		return status.Error(codes.Unauthenticated, "Access denied")
+	}
+	return nil
+}
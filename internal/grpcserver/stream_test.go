@@ -0,0 +1,283 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"shortener/internal/config"
+	"shortener/internal/interceptors"
+	"shortener/internal/logger"
+	"shortener/internal/metrics"
+	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+	pb "shortener/pkg/service/proto"
+)
+
+const testUser = "stream-user"
+
+// withUser stands in for the authentication of the streams.
+func withUser(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &userStream{ServerStream: ss})
+}
+
+type userStream struct {
+	grpc.ServerStream
+}
+
+func (s *userStream) Context() context.Context {
+	return context.WithValue(s.ServerStream.Context(), models.CtxUserIDKey, testUser)
+}
+
+func newStreamClient(t *testing.T, opts ...grpc.ServerOption) (pb.URLShortenerServiceClient, *service.Service) {
+	t.Helper()
+	svc := newTestService(t)
+	s := grpc.NewServer(opts...)
+	pb.RegisterURLShortenerServiceServer(s, &GRPCServer{svc: svc})
+	return dial(t, s), svc
+}
+
+func newTestService(t *testing.T) *service.Service {
+	t.Helper()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	store, err := storage.LoadStorage(context.Background(), cfg, log)
+	require.NoError(t, err)
+	return &service.Service{Storage: store, BaseURL: "http://localhost:8080", Log: log, SecretKey: "secret"}
+}
+
+// dial serves the server on an in-memory listener and returns its client.
+func dial(t *testing.T, s *grpc.Server) pb.URLShortenerServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, conn.Close())
+	})
+	return pb.NewURLShortenerServiceClient(conn)
+}
+
+// batchStream sends the URLs while receiving the replies and returns them with the trailer and the status.
+func batchStream(t *testing.T, client pb.URLShortenerServiceClient, urls []string) (
+	[]*pb.BatchResponse, metadata.MD, error) {
+	t.Helper()
+	stream, err := client.BatchStream(context.Background())
+	require.NoError(t, err)
+	go func() {
+		for i, u := range urls {
+			// the server may end the stream early, the reason comes with the replies
+			if err := stream.Send(&pb.BatchRequestEntity{CorrelationId: fmt.Sprint(i), OriginalUrl: u}); err != nil {
+				return
+			}
+		}
+		_ = stream.CloseSend()
+	}()
+	var replies []*pb.BatchResponse
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return replies, stream.Trailer(), nil
+		}
+		if err != nil {
+			return replies, stream.Trailer(), err
+		}
+		replies = append(replies, res)
+	}
+}
+
+func TestGRPCServer_BatchStream(t *testing.T) {
+	client, _ := newStreamClient(t, grpc.StreamInterceptor(withUser))
+	tests := []struct {
+		name        string
+		count       int
+		invalidAt   int
+		wantReplies int
+		wantCode    codes.Code
+		wantSaved   string
+	}{
+		{name: "less than a chunk", count: 3, invalidAt: -1, wantReplies: 1},
+		{name: "several chunks", count: 2*batchStreamChunk + 1, invalidAt: -1, wantReplies: 3},
+		{name: "invalid url", count: 1, invalidAt: 0, wantCode: codes.InvalidArgument, wantSaved: "0"},
+		{
+			name:        "invalid url after a chunk",
+			count:       batchStreamChunk + 2,
+			invalidAt:   batchStreamChunk + 1,
+			wantReplies: 1,
+			wantCode:    codes.InvalidArgument,
+			wantSaved:   fmt.Sprint(batchStreamChunk),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls := make([]string, 0, tt.count)
+			for i := 0; i < tt.count; i++ {
+				u := fmt.Sprintf("https://example.com/%s/%d", t.Name(), i)
+				if i == tt.invalidAt {
+					u = "not a url"
+				}
+				urls = append(urls, u)
+			}
+			replies, trailer, err := batchStream(t, client, urls)
+			require.Len(t, replies, tt.wantReplies)
+			var got []*pb.BatchResponseEntity
+			for _, res := range replies {
+				assert.LessOrEqual(t, len(res.GetUrls()), batchStreamChunk)
+				got = append(got, res.GetUrls()...)
+			}
+			for i, u := range got {
+				assert.Equal(t, fmt.Sprint(i), u.GetCorrelationId())
+				assert.NotEmpty(t, u.GetShortUrl())
+			}
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				assert.Equal(t, []string{tt.wantSaved}, trailer.Get(savedCountKey))
+				assert.Contains(t, status.Convert(err).Message(), tt.wantSaved+" urls saved before")
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, got, tt.count)
+		})
+	}
+}
+
+func TestGRPCServer_BatchStreamRateLimit(t *testing.T) {
+	svc := newTestService(t)
+	svc.RateLimits.Batch = ratelimit.New(0.001, 3)
+	s := grpc.NewServer(grpc.ChainStreamInterceptor(
+		interceptors.MetricsStreamInterceptor,
+		withUser,
+		interceptors.RateLimitStreamInterceptor(svc),
+	))
+	pb.RegisterURLShortenerServiceServer(s, &GRPCServer{svc: svc})
+	client := dial(t, s)
+	exhausted := metrics.GRPCRequests.WithLabelValues(pb.URLShortenerService_BatchStream_FullMethodName,
+		codes.ResourceExhausted.String())
+	before := testutil.ToFloat64(exhausted)
+
+	urls := make([]string, 0, 5)
+	for i := 0; i < cap(urls); i++ {
+		urls = append(urls, fmt.Sprintf("https://example.com/limited/%d", i))
+	}
+	replies, trailer, err := batchStream(t, client, urls)
+	assert.Empty(t, replies)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "every message takes a token")
+	assert.NotEmpty(t, trailer.Get("retry-after"))
+	assert.Equal(t, []string{"0"}, trailer.Get(savedCountKey))
+	assert.Equal(t, before+1, testutil.ToFloat64(exhausted))
+}
+
+func TestGRPCServer_ShortenStream(t *testing.T) {
+	client, _ := newStreamClient(t, grpc.StreamInterceptor(withUser))
+	stream, err := client.ShortenStream(context.Background())
+	require.NoError(t, err)
+
+	requests := []*pb.BatchRequestEntity{
+		{CorrelationId: "1", OriginalUrl: "https://example.com/bidi/1"},
+		{CorrelationId: "2", OriginalUrl: "not a url"},
+		{CorrelationId: "3", OriginalUrl: "https://example.com/bidi/3"},
+		{CorrelationId: "4", OriginalUrl: "HTTPS://example.com/bidi/1#top"},
+	}
+	wantErrors := map[string]string{"2": "Invalid URL", "4": "URL is already shortened"}
+	for _, req := range requests {
+		require.NoError(t, stream.Send(req))
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, req.GetCorrelationId(), res.GetCorrelationId())
+		if wantErr, ok := wantErrors[req.GetCorrelationId()]; ok {
+			assert.Empty(t, res.GetShortUrl())
+			assert.Equal(t, wantErr, res.GetError())
+			continue
+		}
+		assert.NotEmpty(t, res.GetShortUrl())
+		assert.Empty(t, res.GetError())
+	}
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestGRPCServer_ListUserURLs(t *testing.T) {
+	client, svc := newStreamClient(t, grpc.StreamInterceptor(withUser))
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, testUser)
+	const count = 5
+	for i := 0; i < count; i++ {
+		_, err := svc.SaveURL(ctx, models.ShortenRequest{
+			URL:   fmt.Sprintf("https://example.com/list/%d", i),
+			Alias: fmt.Sprintf("list-%d", i),
+		})
+		require.NoError(t, err)
+	}
+	otherCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "other-user")
+	_, err := svc.SaveURL(otherCtx, models.ShortenRequest{URL: "https://example.com/other", Alias: "list-other"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		pageSize int32
+		wantCode codes.Code
+	}{
+		{name: "default page", pageSize: 0},
+		{name: "pages smaller than the set", pageSize: 2},
+		{name: "page of the set size", pageSize: count},
+		{name: "negative page", pageSize: -1, wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.ListUserURLs(context.Background(), &pb.ListUserURLsRequest{PageSize: tt.pageSize})
+			require.NoError(t, err)
+			var got []string
+			for {
+				u, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if tt.wantCode != codes.OK {
+					assert.Equal(t, tt.wantCode, status.Code(err))
+					return
+				}
+				require.NoError(t, err)
+				got = append(got, u.GetShortUrl())
+			}
+			want := make([]string, 0, count)
+			for i := 0; i < count; i++ {
+				want = append(want, fmt.Sprintf("http://localhost:8080/list-%d", i))
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestGRPCServer_StreamsRequireUser(t *testing.T) {
+	client, _ := newStreamClient(t)
+	stream, err := client.ListUserURLs(context.Background(), &pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// RegisterHandler creates an account and starts its session.
+func RegisterHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.CredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		session, err := svc.Register(r.Context(), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidLogin):
+				http.Error(w, "Invalid login", http.StatusBadRequest)
+			case errors.Is(err, service.ErrInvalidPassword):
+				http.Error(w, "Password must be 8 to 72 bytes long", http.StatusBadRequest)
+			case errors.Is(err, service.ErrLoginTaken):
+				http.Error(w, "Login already taken", http.StatusConflict)
+			default:
+				svc.Log.ErrContext(r.Context(), "failed to register: ", err)
+				http.Error(w, "", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeSession(svc, w, r, http.StatusCreated, session.Token, session)
+	}
+}
+
+// LoginHandler checks the credentials and starts a new session of the account.
+func LoginHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.CredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		session, err := svc.Login(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCredentials) {
+				http.Error(w, "Invalid login or password", http.StatusUnauthorized)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to login: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		writeSession(svc, w, r, http.StatusOK, session.Token, session)
+	}
+}
+
+// ClaimHandler moves the links of the current user onto the account and starts a session of the account.
+func ClaimHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.CredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		claim, err := svc.Claim(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCredentials) {
+				http.Error(w, "Invalid login or password", http.StatusUnauthorized)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to claim urls: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		writeSession(svc, w, r, http.StatusOK, claim.Token, claim)
+	}
+}
+
+// writeSession replaces the token cookie the same way the auth middleware issues it and writes the body.
+//
+// An anonymous token the middleware has just issued for the request is dropped.
+func writeSession(svc *service.Service, w http.ResponseWriter, r *http.Request, status int, token string, body any) {
+	w.Header().Set("Authorization", token)
+	w.Header().Del("Set-Cookie")
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: token})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+	}
+}
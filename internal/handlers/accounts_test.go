@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+func TestAccounts(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log, SecretKey: cfg.Service.SecretKey}
+	router := NewRouter(svc)
+
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Cookie", "token="+token)
+			r.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	anonymous, err := svc.BuildJWTString()
+	require.NoError(t, err)
+	w := do(http.MethodPost, "/api/shorten", `{"url":"https://example.com/orphan","alias":"orphan-link"}`, anonymous)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   int
+	}{
+		{name: "register", target: "/api/auth/register", body: `{"login":"Alice","password":"correct horse"}`, want: http.StatusCreated},
+		{name: "login taken", target: "/api/auth/register", body: `{"login":"alice","password":"another one"}`, want: http.StatusConflict},
+		{name: "short password", target: "/api/auth/register", body: `{"login":"bob","password":"short"}`, want: http.StatusBadRequest},
+		{name: "bad login", target: "/api/auth/register", body: `{"login":"b o b","password":"long enough"}`, want: http.StatusBadRequest},
+		{name: "wrong password", target: "/api/auth/login", body: `{"login":"alice","password":"wrong horse"}`, want: http.StatusUnauthorized},
+		{name: "unknown login", target: "/api/auth/login", body: `{"login":"carol","password":"correct horse"}`, want: http.StatusUnauthorized},
+		{name: "bad body", target: "/api/auth/login", body: `{`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodPost, tt.target, tt.body, "")
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	t.Run("claim anonymous links", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/user/claim", `{"login":"alice","password":"wrong horse"}`, anonymous)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = do(http.MethodPost, "/api/user/claim", `{"login":"alice","password":"correct horse"}`, anonymous)
+		require.Equal(t, http.StatusOK, w.Code)
+		var claim models.ClaimResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&claim))
+		assert.Equal(t, 1, claim.Claimed)
+		assert.Equal(t, claim.Token, w.Header().Get("Authorization"))
+
+		w = do(http.MethodGet, "/api/user/urls", "", anonymous)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("login sees claimed links", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/auth/login", `{"login":" ALICE ","password":"correct horse"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var session models.SessionResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&session))
+		res := w.Result()
+		require.Len(t, res.Cookies(), 1)
+		assert.Equal(t, session.Token, res.Cookies()[0].Value)
+		assert.NoError(t, res.Body.Close())
+
+		w = do(http.MethodGet, "/api/user/urls", "", session.Token)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://example.com/orphan")
+	})
+}
+
+func TestAccounts_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{
+		Storage:    s,
+		BaseURL:    cfg.App.BaseURL,
+		Log:        log,
+		SecretKey:  cfg.Service.SecretKey,
+		RateLimits: ratelimit.Limits{Login: ratelimit.New(0.001, 2)},
+	}
+	router := NewRouter(svc)
+
+	login := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"login":"bob","password":"guess"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	assert.Equal(t, http.StatusUnauthorized, login().Code)
+	assert.Equal(t, http.StatusUnauthorized, login().Code)
+	w := login()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "the guesses from one address are throttled")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
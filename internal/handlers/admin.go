@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	mw "shortener/internal/middleware"
+	"shortener/internal/service"
+)
+
+// AdminGetURLHandler returns the owner and the metadata of any short link.
+func AdminGetURLHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := svc.AdminGetURL(r.Context(), mw.ClientIP(svc, r), chi.URLParam(r, "short"))
+		if err != nil {
+			if errors.Is(err, service.ErrURLNotFound) {
+				http.Error(w, "URL not found", http.StatusNotFound)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to get url: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(svc, w, r, res)
+	}
+}
+
+// AdminDeleteURLHandler marks any short link deleted.
+func AdminDeleteURLHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.AdminDeleteURL(r.Context(), mw.ClientIP(svc, r), chi.URLParam(r, "short"))
+		if err != nil {
+			if errors.Is(err, service.ErrURLNotFound) {
+				http.Error(w, "URL not found", http.StatusNotFound)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to delete url: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AdminRestoreURLHandler brings back a deleted short link.
+func AdminRestoreURLHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.AdminRestoreURL(r.Context(), mw.ClientIP(svc, r), chi.URLParam(r, "short"))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrURLNotFound):
+				http.Error(w, "URL not found", http.StatusNotFound)
+			case errors.Is(err, service.ErrRestoreConflict):
+				http.Error(w, "URL conflicts with a live one", http.StatusConflict)
+			default:
+				svc.Log.ErrContext(r.Context(), "failed to restore url: ", err)
+				http.Error(w, "", http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AdminUserURLsHandler returns every link of the user, deleted ones included.
+func AdminUserURLsHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := svc.AdminUserURLs(r.Context(), mw.ClientIP(svc, r), chi.URLParam(r, "id"))
+		if err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to list user urls: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(svc, w, r, res)
+	}
+}
+
+// AdminSetUserDisabledHandler disables or enables the user.
+func AdminSetUserDisabledHandler(svc *service.Service, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.AdminSetUserDisabled(r.Context(), mw.ClientIP(svc, r), chi.URLParam(r, "id"), disabled)
+		if err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to update user: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeJSON(svc *service.Service, w http.ResponseWriter, r *http.Request, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+	}
+}
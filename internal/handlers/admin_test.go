@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+type auditRecorder struct {
+	mux     sync.Mutex
+	entries []models.AuditEntry
+}
+
+func (a *auditRecorder) Record(entry models.AuditEntry) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func TestAdmin(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	audit := &auditRecorder{}
+	svc := &service.Service{
+		Storage:        s,
+		Audit:          audit,
+		BaseURL:        cfg.App.BaseURL,
+		Log:            log,
+		SecretKey:      cfg.Service.SecretKey,
+		TrustedSubnet:  "10.0.0.0/8",
+		TrustedProxies: []string{"192.168.1.1"},
+	}
+	router := NewRouter(svc)
+
+	token, err := svc.BuildJWTString()
+	require.NoError(t, err)
+	userID := svc.GetUserID(token, svc.SecretKey, log)
+	do := func(method, target, remoteAddr, realIP string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		if remoteAddr != "" {
+			r.RemoteAddr = remoteAddr
+		}
+		if realIP != "" {
+			r.Header.Set("X-Real-IP", realIP)
+		} else {
+			r.Header.Set("Cookie", "token="+token)
+			r.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/shorten",
+		strings.NewReader(`{"url":"https://example.com/admin","alias":"admin-link"}`))
+	r.Header.Set("Cookie", "token="+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	const (
+		operator = "10.1.2.3"
+		proxy    = "192.168.1.1:80"
+	)
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		remoteAddr string
+		realIP     string
+		want       int
+	}{
+		{name: "untrusted", method: http.MethodGet, target: "/api/internal/admin/urls/admin-link", remoteAddr: proxy, realIP: "8.8.8.8", want: http.StatusForbidden},
+		{
+			name:       "spoofed real ip",
+			method:     http.MethodDelete,
+			target:     "/api/internal/admin/urls/admin-link",
+			remoteAddr: "203.0.113.5:4321",
+			realIP:     operator,
+			want:       http.StatusForbidden,
+		},
+		{name: "no real ip", method: http.MethodDelete, target: "/api/internal/admin/urls/admin-link", realIP: "", want: http.StatusForbidden},
+		{name: "lookup", method: http.MethodGet, target: "/api/internal/admin/urls/admin-link", remoteAddr: proxy, realIP: operator, want: http.StatusOK},
+		{name: "direct operator", method: http.MethodGet, target: "/api/internal/admin/urls/missing", remoteAddr: operator + ":5555", want: http.StatusNotFound},
+		{name: "lookup missing", method: http.MethodGet, target: "/api/internal/admin/urls/missing", remoteAddr: proxy, realIP: operator, want: http.StatusNotFound},
+		{name: "force delete", method: http.MethodDelete, target: "/api/internal/admin/urls/admin-link", remoteAddr: proxy, realIP: operator, want: http.StatusNoContent},
+		{name: "deleted link is gone", method: http.MethodGet, target: "/admin-link", want: http.StatusGone},
+		{name: "restore", method: http.MethodPost, target: "/api/internal/admin/urls/admin-link/restore", remoteAddr: proxy, realIP: operator, want: http.StatusNoContent},
+		{name: "restore missing", method: http.MethodPost, target: "/api/internal/admin/urls/missing/restore", remoteAddr: proxy, realIP: operator, want: http.StatusNotFound},
+		{name: "restored link redirects", method: http.MethodGet, target: "/admin-link", want: http.StatusTemporaryRedirect},
+		{name: "disable user", method: http.MethodPost, target: "/api/internal/admin/users/" + userID + "/disable", remoteAddr: proxy, realIP: operator, want: http.StatusNoContent},
+		{name: "disabled user is rejected", method: http.MethodGet, target: "/api/user/urls", want: http.StatusForbidden},
+		{name: "enable user", method: http.MethodPost, target: "/api/internal/admin/users/" + userID + "/enable", remoteAddr: proxy, realIP: operator, want: http.StatusNoContent},
+		{name: "enabled user is back", method: http.MethodGet, target: "/api/user/urls", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.method, tt.target, tt.remoteAddr, tt.realIP)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	t.Run("user links", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/internal/admin/users/"+userID+"/urls", proxy, operator)
+		require.Equal(t, http.StatusOK, w.Code)
+		var urls []models.AdminURL
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&urls))
+		require.Len(t, urls, 1)
+		assert.Equal(t, "admin-link", urls[0].ShortURL)
+		assert.Equal(t, userID, urls[0].UserID)
+		assert.False(t, urls[0].Deleted)
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		actions := make([]string, 0, len(audit.entries))
+		for _, entry := range audit.entries {
+			assert.Equal(t, operator, entry.Actor)
+			actions = append(actions, entry.Action)
+		}
+		assert.Equal(t, []string{
+			service.AuditGetURL, service.AuditGetURL, service.AuditGetURL, service.AuditDeleteURL, service.AuditRestoreURL,
+			service.AuditRestoreURL, service.AuditDisableUser, service.AuditEnableUser, service.AuditUserURLs,
+		}, actions)
+		assert.NotEmpty(t, audit.entries[1].Error)
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// CreateAPIKeyHandler creates a named API key for the user and returns it once.
+func CreateAPIKeyHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.APIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		key, err := svc.CreateAPIKey(r.Context(), req.Name)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKeyName) {
+				http.Error(w, "Invalid key name", http.StatusBadRequest)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to create api key: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err = json.NewEncoder(w).Encode(key); err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+		}
+	}
+}
+
+// ListAPIKeysHandler returns the API keys of the user without the keys themselves.
+func ListAPIKeysHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := svc.ListAPIKeys(r.Context())
+		if err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to list api keys: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(keys); err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RevokeAPIKeyHandler revokes an API key of the user.
+func RevokeAPIKeyHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := svc.RevokeAPIKey(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, service.ErrAPIKeyNotFound) {
+				http.Error(w, "API key not found", http.StatusNotFound)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to revoke api key: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log, SecretKey: cfg.Service.SecretKey}
+	router := NewRouter(svc)
+
+	token, err := svc.BuildJWTString()
+	require.NoError(t, err)
+	do := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	cookieAuth := map[string]string{"Cookie": "token=" + token, "Authorization": token}
+
+	w := do(http.MethodPost, "/api/user/keys", `{"name":"billing backend"}`, cookieAuth)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.APIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.True(t, service.IsAPIKey(created.Key))
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+
+	w = do(http.MethodPost, "/api/user/keys", `{"name":""}`, cookieAuth)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	t.Run("key owns links of the cookie user", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/shorten", `{"url":"https://example.com/api-key","alias":"api-key-link"}`,
+			map[string]string{"Authorization": "Bearer " + created.Key})
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Result().Cookies(), "api key requests get no token")
+		assert.NoError(t, w.Result().Body.Close())
+
+		w = do(http.MethodGet, "/api/user/urls", "", cookieAuth)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "https://example.com/api-key")
+	})
+
+	t.Run("list without secrets", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/user/keys", "", map[string]string{"X-API-Key": created.Key})
+		require.Equal(t, http.StatusOK, w.Code)
+		var keys []models.APIKeyResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&keys))
+		require.Len(t, keys, 1)
+		assert.Equal(t, created.ID, keys[0].ID)
+		assert.Equal(t, "billing backend", keys[0].Name)
+		assert.Empty(t, keys[0].Key)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/user/keys", "", map[string]string{"X-API-Key": "sk_unknown"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		w := do(http.MethodDelete, "/api/user/keys/missing", "", cookieAuth)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do(http.MethodDelete, "/api/user/keys/"+created.ID, "", cookieAuth)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = do(http.MethodGet, "/api/user/keys", "", map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
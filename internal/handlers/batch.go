@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"shortener/internal/models"
 	"shortener/internal/service"
+	"shortener/internal/storage"
 )
 
 // BatchHandler represents a handler for batch URL shortening requests.
@@ -27,13 +29,13 @@ func BatchHandler(svc *service.Service) http.HandlerFunc {
 		ctx := r.Context()
 		decoder := json.NewDecoder(r.Body)
 		if err := decoder.Decode(&req); err != nil {
-			svc.Log.Err("failed to decode request body: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to decode request body: ", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 		defer func() {
 			if err := r.Body.Close(); err != nil {
-				svc.Log.Err("failed to close request body: ", err)
+				svc.Log.ErrContext(r.Context(), "failed to close request body: ", err)
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
@@ -45,8 +47,26 @@ func BatchHandler(svc *service.Service) http.HandlerFunc {
 
 		saved, err := svc.SaveURLs(ctx, req)
 		if err != nil {
-			svc.Log.Err("failed to save urls: ", err)
-			http.Error(w, "", http.StatusInternalServerError)
+			var duplicateErr *storage.DuplicateRecordError
+			switch {
+			case errors.As(err, &duplicateErr):
+				http.Error(w, "URL is already shortened", http.StatusConflict)
+			case errors.Is(err, service.ErrDuplicateURL):
+				http.Error(w, "URL repeated in batch with an alias", http.StatusConflict)
+			case errors.Is(err, service.ErrInvalidURL):
+				http.Error(w, "Invalid URL", http.StatusBadRequest)
+			case errors.Is(err, service.ErrURLBlocked):
+				http.Error(w, "Destination is blocked", http.StatusUnavailableForLegalReasons)
+			case errors.Is(err, service.ErrInvalidAlias):
+				http.Error(w, "Invalid alias", http.StatusBadRequest)
+			case errors.Is(err, service.ErrInvalidExpiry):
+				http.Error(w, "Invalid expiration", http.StatusBadRequest)
+			case errors.Is(err, service.ErrShortURLExists):
+				http.Error(w, "Alias is already taken", http.StatusConflict)
+			default:
+				svc.Log.ErrContext(r.Context(), "failed to save urls: ", err)
+				http.Error(w, "", http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -55,7 +75,7 @@ func BatchHandler(svc *service.Service) http.HandlerFunc {
 		enc := json.NewEncoder(w)
 		err = enc.Encode(saved)
 		if err != nil {
-			svc.Log.Err("failed to encode response: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
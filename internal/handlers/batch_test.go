@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"shortener/internal/config"
 	"shortener/internal/logger"
@@ -41,11 +42,38 @@ func TestBatchHandler(t *testing.T) {
 			name:   "Positive #1",
 			userID: "100500",
 			body: []models.BatchRequest{
-				{CorrelationID: "id1", OriginalURL: "t.me"},
-				{CorrelationID: "id2", OriginalURL: "t.him"},
+				{CorrelationID: "id1", OriginalURL: "https://t.me"},
+				{CorrelationID: "id2", OriginalURL: "https://t.him"},
 			},
 			wantStatus: http.StatusCreated,
 		},
+		{
+			name:   "Negative invalid url #1",
+			userID: "100500",
+			body: []models.BatchRequest{
+				{CorrelationID: "id3", OriginalURL: "https://t.me/valid"},
+				{CorrelationID: "id4", OriginalURL: "t.me"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Negative already shortened #1",
+			userID: "100500",
+			body: []models.BatchRequest{
+				{CorrelationID: "id5", OriginalURL: "https://t.me/new"},
+				{CorrelationID: "id6", OriginalURL: "HTTPS://T.ME"},
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "Negative repeated url with alias #1",
+			userID: "100500",
+			body: []models.BatchRequest{
+				{CorrelationID: "id7", OriginalURL: "https://t.me/repeated"},
+				{CorrelationID: "id8", OriginalURL: "https://t.me/repeated#top", Alias: "repeated"},
+			},
+			wantStatus: http.StatusConflict,
+		},
 		{
 			name:       "Negative #1",
 			userID:     "100500",
@@ -56,8 +84,8 @@ func TestBatchHandler(t *testing.T) {
 			name:   "Negative #2",
 			userID: "",
 			body: []models.BatchRequest{
-				{CorrelationID: "id13", OriginalURL: "t.me"},
-				{CorrelationID: "id21", OriginalURL: "t.him"},
+				{CorrelationID: "id13", OriginalURL: "https://t.me"},
+				{CorrelationID: "id21", OriginalURL: "https://t.him"},
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
@@ -85,3 +113,36 @@ func TestBatchHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchHandler_RepeatedURL(t *testing.T) {
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	ctx := context.Background()
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log}
+
+	body := `[
+		{"correlation_id": "1", "original_url": "HTTP://A.com"},
+		{"correlation_id": "2", "original_url": "https://b.com"},
+		{"correlation_id": "3", "original_url": "http://a.com/"},
+		{"correlation_id": "4", "original_url": "http://a.com:80/#top"}
+	]`
+	r := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), models.CtxUserIDKey, "100500"))
+	w := httptest.NewRecorder()
+	BatchHandler(svc)(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp models.BatchResponseArray
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	shorts := make(map[string]string, len(resp))
+	for _, item := range resp {
+		shorts[item.CorrelationID] = item.ShortURL
+	}
+	require.Len(t, shorts, 4)
+	assert.Equal(t, shorts["1"], shorts["3"], "the canonical url is shortened once")
+	assert.Equal(t, shorts["1"], shorts["4"])
+	assert.NotEqual(t, shorts["1"], shorts["2"])
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+// hostBlocklist blocks the destinations by exact host.
+type hostBlocklist map[string]struct{}
+
+func (b hostBlocklist) Blocked(long string) (string, bool) {
+	u, err := url.Parse(long)
+	if err != nil {
+		return "", false
+	}
+	_, ok := b[u.Hostname()]
+	return u.Hostname(), ok
+}
+
+func TestBlocklist(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	blocked := hostBlocklist{"evil.example": {}}
+	svc := &service.Service{Storage: s, Blocklist: blocked, BaseURL: cfg.App.BaseURL, Log: log}
+	router := NewRouter(svc)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{name: "save blocked", target: "/", body: "https://EVIL.example/login", status: http.StatusUnavailableForLegalReasons},
+		{name: "shorten blocked", target: "/api/shorten", body: `{"url":"https://evil.example/"}`, status: http.StatusUnavailableForLegalReasons},
+		{
+			name:   "batch with one blocked",
+			target: "/api/shorten/batch",
+			body:   `[{"correlation_id":"1","original_url":"https://ok.example/"},{"correlation_id":"2","original_url":"https://evil.example/"}]`,
+			status: http.StatusUnavailableForLegalReasons,
+		},
+		{name: "shorten allowed", target: "/api/shorten", body: `{"url":"https://later.example/","alias":"later-bad"}`, status: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/later-bad", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	// a link saved before its destination got blocked stops redirecting
+	blocked["later.example"] = struct{}{}
+	r = httptest.NewRequest(http.MethodGet, "/later-bad", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+
+	stats, err := svc.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Blocked)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"shortener/internal/service"
+)
+
+// ClickStatsHandler returns click totals and the per-day histogram for a link owned by the user.
+func ClickStatsHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		short := chi.URLParam(r, "short")
+
+		stats, err := svc.GetClickStats(ctx, short)
+		if err != nil {
+			if errors.Is(err, service.ErrURLNotFound) {
+				http.Error(w, "URL not found", http.StatusNotFound)
+				return
+			}
+			svc.Log.ErrContext(r.Context(), "failed to get click stats: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(stats); err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+}
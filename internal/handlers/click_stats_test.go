@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+func TestClickStatsHandler(t *testing.T) {
+	const (
+		owner = "click-owner"
+		short = "clicked1"
+	)
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log}
+
+	ownerCtx := context.WithValue(ctx, models.CtxUserIDKey, owner)
+	require.NoError(t, s.Save(ownerCtx, short, "https://example.org/clicked", nil))
+	day := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveClicks(ctx, []models.Click{
+		{Short: short, Time: day},
+		{Short: short, Time: day.Add(time.Hour)},
+		{Short: short, Time: day.Add(24 * time.Hour)},
+	}))
+
+	tests := []struct {
+		name       string
+		userID     string
+		wantStatus int
+		wantStats  models.ClickStats
+	}{
+		{
+			name:       "Positive #1",
+			userID:     owner,
+			wantStatus: http.StatusOK,
+			wantStats: models.ClickStats{
+				Short: short,
+				Total: 3,
+				Days: []models.DayClicks{
+					{Day: "2024-05-01", Clicks: 2},
+					{Day: "2024-05-02", Clicks: 1},
+				},
+			},
+		},
+		{
+			name:       "Negative not owner #1",
+			userID:     "someone-else",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Get("/api/user/urls/{short}/stats", ClickStatsHandler(svc))
+			r := httptest.NewRequest(http.MethodGet, "/api/user/urls/"+short+"/stats", http.NoBody)
+			r = r.WithContext(context.WithValue(r.Context(), models.CtxUserIDKey, tt.userID))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got models.ClickStats
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.wantStats, got)
+		})
+	}
+}
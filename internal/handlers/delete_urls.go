@@ -15,14 +15,14 @@ func DeleteURLsHandler(svc *service.Service) http.HandlerFunc {
 
 		var req models.DeleteURLs
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			svc.Log.Err("failed to decode request body: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to decode request body: ", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
 		err := svc.DeleteURLs(ctx, req)
 		if err != nil {
-			svc.Log.Err("failed to delete URLs: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to delete URLs: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -31,7 +31,7 @@ func DeleteURLsHandler(svc *service.Service) http.HandlerFunc {
 		w.WriteHeader(http.StatusAccepted)
 		err = json.NewEncoder(w).Encode(req)
 		if err != nil {
-			svc.Log.Err("failed to encode response body: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to encode response body: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -4,9 +4,13 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"shortener/internal/metrics"
+	mw "shortener/internal/middleware"
+	"shortener/internal/models"
 	"shortener/internal/service"
 	"shortener/internal/storage"
 )
@@ -18,21 +22,41 @@ func GetHandler(svc *service.Service) http.HandlerFunc {
 		short := chi.URLParam(r, "id")
 		long, err := svc.GetURL(ctx, short)
 		if err != nil {
-			if errors.Is(err, storage.ErrURLDeleted) {
-				svc.Log.Info("requested deleted url", "short", short)
+			switch {
+			case errors.Is(err, storage.ErrURLDeleted):
+				svc.Log.InfoContext(r.Context(), "requested deleted url", "short", short)
+				metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
 				w.WriteHeader(http.StatusGone)
-			} else {
-				svc.Log.Err("failed to get URL: ", err)
+			case errors.Is(err, storage.ErrURLExpired):
+				svc.Log.InfoContext(r.Context(), "requested expired url", "short", short)
+				metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+				w.WriteHeader(http.StatusGone)
+			case errors.Is(err, service.ErrURLBlocked):
+				metrics.Redirects.WithLabelValues(metrics.RedirectBlocked).Inc()
+				http.Error(w, "Destination is blocked", http.StatusUnavailableForLegalReasons)
+			case errors.Is(err, service.ErrURLNotFound):
+				metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				svc.Log.ErrContext(r.Context(), "failed to get URL: ", err)
 				w.WriteHeader(http.StatusBadRequest)
 			}
 			return
 		}
 		origin, err := url.JoinPath(svc.BaseURL, short)
 		if err != nil {
-			svc.Log.Err("failed to join path to get redirect URL: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to join path to get redirect URL: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
+		metrics.Redirects.WithLabelValues(metrics.RedirectHit).Inc()
+		svc.RecordClick(models.Click{
+			Time:      time.Now().UTC(),
+			Short:     short,
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			ClientIP:  mw.ClientIP(svc, r),
+		})
 		w.Header().Set("Location", origin)
 		http.Redirect(w, r, long, http.StatusTemporaryRedirect)
 	}
@@ -72,6 +72,18 @@ func TestGetHandler(t *testing.T) {
 				status:   http.StatusGone,
 			},
 		},
+		{
+			name:      "Negative GET expired #1",
+			route:     "/Expired1",
+			callTimes: 1,
+			longURL:   "Expired",
+			method:    GET,
+			want: want{
+				response: "",
+				respErr:  storage.ErrURLExpired,
+				status:   http.StatusGone,
+			},
+		},
 		{
 			name:      "Negative GET #2",
 			route:     "/BadRequestShort",
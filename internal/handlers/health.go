@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"shortener/internal/health"
+	"shortener/internal/service"
+)
+
+// HealthzHandler replies while the process is able to serve requests, it checks no dependencies.
+func HealthzHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(svc, w, r, health.Report{Status: health.StatusOK})
+	}
+}
+
+// ReadyzHandler runs the readiness checks and replies 503 with the failed ones.
+func ReadyzHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := svc.Health.Ready(r.Context())
+		if !report.OK() {
+			svc.Log.WarnContext(r.Context(), "service not ready", "checks", report.Checks)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		writeJSON(svc, w, r, report)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/health"
+	"shortener/internal/logger"
+	"shortener/internal/service"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		route      string
+		storageErr error
+		wantStatus int
+		wantReport health.Report
+	}{
+		{
+			name:       "live while storage is down",
+			route:      "/healthz",
+			storageErr: errDown,
+			wantStatus: http.StatusOK,
+			wantReport: health.Report{Status: health.StatusOK},
+		},
+		{
+			name:       "ready",
+			route:      "/readyz",
+			wantStatus: http.StatusOK,
+			wantReport: health.Report{Status: health.StatusOK, Checks: map[string]health.Result{
+				"storage": {Status: health.StatusOK},
+			}},
+		},
+		{
+			name:       "not ready",
+			route:      "/readyz",
+			storageErr: errDown,
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: health.Report{Status: health.StatusFail, Checks: map[string]health.Result{
+				"storage": {Status: health.StatusFail, Error: errDown.Error()},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := health.New(health.DefaultTimeout)
+			checks.Add("storage", func(context.Context) error { return tt.storageErr })
+			svc := &service.Service{Log: log, Health: checks}
+
+			r := httptest.NewRequest(http.MethodGet, tt.route, nil)
+			w := httptest.NewRecorder()
+			NewRouter(svc).ServeHTTP(w, r)
+
+			res := w.Result()
+			defer func() {
+				assert.NoError(t, res.Body.Close())
+			}()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			var got health.Report
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+			for name, check := range got.Checks {
+				assert.NotEmpty(t, check.Duration)
+				check.Duration = ""
+				got.Checks[name] = check
+			}
+			assert.Equal(t, tt.wantReport, got)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log}
+	srv := httptest.NewServer(NewRouter(svc))
+	defer srv.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	do := func(method, target, body string) string {
+		req, err := http.NewRequest(method, srv.URL+target, strings.NewReader(body))
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, res.Body.Close())
+		}()
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	do(http.MethodPost, "/api/shorten", `{"url":"https://example.com/metrics","alias":"metrics-link"}`)
+	do(http.MethodGet, "/metrics-link", "")
+	do(http.MethodGet, "/no-such-link", "")
+
+	debug := httptest.NewServer(NewDebugRouter())
+	defer debug.Close()
+	res, err := http.Get(debug.URL + "/metrics")
+	require.NoError(t, err)
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	got := string(b)
+	for _, want := range []string{
+		`shortener_http_requests_total{code="201",method="POST",route="/api/shorten"}`,
+		`shortener_http_requests_total{code="307",method="GET",route="/{id}"}`,
+		`shortener_http_request_duration_seconds_count{method="GET",route="/{id}"}`,
+		`shortener_redirects_total{result="hit"}`,
+		`shortener_redirects_total{result="miss"}`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, got, want)
+	}
+}
+
+func TestNewRouter_DebugRoutes(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	router := NewRouter(&service.Service{Storage: s, BaseURL: cfg.App.BaseURL, Log: log})
+
+	for _, target := range []string{"/metrics", "/debug/pprof/", "/debug/pprof/goroutine"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.NotEqual(t, http.StatusOK, w.Code, target)
+		assert.NotContains(t, w.Body.String(), "go_goroutines", target)
+	}
+}
@@ -11,7 +11,7 @@ func PingHandler(svc *service.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		if err := svc.Storage.Ping(ctx); err != nil {
-			svc.Log.Err("failed to ping Pool: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to ping Pool: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -5,6 +5,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"shortener/internal/metrics"
 	mw "shortener/internal/middleware"
 	"shortener/internal/service"
 )
@@ -13,28 +14,69 @@ import (
 func NewRouter(svc *service.Service) *chi.Mux {
 	router := chi.NewRouter()
 
+	router.Use(mw.RequestID)
+	router.Use(mw.Tracing)
+	router.Use(mw.Metrics)
 	router.Use(middleware.Recoverer)
-	router.Use(mw.Auth(svc).Middleware)
-	router.Use(mw.Gzip(svc.Log).Middleware)
-	router.Use(mw.Log(svc.Log).Middleware)
-	router.Route("/", func(r chi.Router) {
-		r.Get("/{id}", GetHandler(svc))
-		r.Post("/", SaveHandler(svc))
-	})
-	router.Route("/api", func(r chi.Router) {
-		r.Route("/shorten", func(r chi.Router) {
-			r.Post("/", ShortenHandler(svc))
-			r.Post("/batch", BatchHandler(svc))
+	// The probes skip the authorization and the request log.
+	router.Get("/healthz", HealthzHandler(svc))
+	router.Get("/readyz", ReadyzHandler(svc))
+	router.Group(func(router chi.Router) {
+		router.Use(mw.Auth(svc).Middleware)
+		router.Use(mw.Gzip(svc.Log).Middleware)
+		router.Use(mw.Log(svc.Log).Middleware)
+		limitSave := mw.RateLimit(svc, svc.RateLimits.Save).Middleware
+		limitBatch := mw.RateLimit(svc, svc.RateLimits.Batch).Middleware
+		limitRedirect := mw.RateLimit(svc, svc.RateLimits.Redirect).Middleware
+		limitLogin := mw.RateLimit(svc, svc.RateLimits.Login).Middleware
+		router.Route("/", func(r chi.Router) {
+			r.With(limitRedirect).Get("/{id}", GetHandler(svc))
+			r.With(limitSave).Post("/", SaveHandler(svc))
+		})
+		router.Route("/api", func(r chi.Router) {
+			r.Route("/shorten", func(r chi.Router) {
+				r.With(limitSave).Post("/", ShortenHandler(svc))
+				r.With(limitBatch).Post("/batch", BatchHandler(svc))
+			})
+			r.Route("/auth", func(r chi.Router) {
+				r.Use(limitLogin)
+				r.Post("/register", RegisterHandler(svc))
+				r.Post("/login", LoginHandler(svc))
+			})
+			r.Route("/user", func(r chi.Router) {
+				r.Use(mw.CheckAuth(svc.Log).Middleware)
+				r.Get("/urls", GetURLsHandler(svc))
+				r.Get("/urls/{short}/stats", ClickStatsHandler(svc))
+				r.Post("/keys", CreateAPIKeyHandler(svc))
+				r.Get("/keys", ListAPIKeysHandler(svc))
+				r.Delete("/keys/{id}", RevokeAPIKeyHandler(svc))
+				r.With(limitLogin).Post("/claim", ClaimHandler(svc))
+			})
 		})
-		r.Route("/user", func(r chi.Router) {
-			r.Use(mw.CheckAuth(svc.Log).Middleware)
-			r.Get("/urls", GetURLsHandler(svc))
+		router.Delete("/api/user/urls", DeleteURLsHandler(svc))
+		router.Get("/api/internal/stats", StatsHandler(svc))
+		router.Route("/api/internal/admin", func(r chi.Router) {
+			r.Use(mw.TrustedSubnet(svc).Middleware)
+			r.Get("/urls/{short}", AdminGetURLHandler(svc))
+			r.Delete("/urls/{short}", AdminDeleteURLHandler(svc))
+			r.Post("/urls/{short}/restore", AdminRestoreURLHandler(svc))
+			r.Get("/users/{id}/urls", AdminUserURLsHandler(svc))
+			r.Post("/users/{id}/disable", AdminSetUserDisabledHandler(svc, true))
+			r.Post("/users/{id}/enable", AdminSetUserDisabledHandler(svc, false))
 		})
+		router.Get("/api/internal/export", ExportHandler(svc))
+		router.Post("/api/internal/import", ImportHandler(svc))
+		router.Get("/ping", PingHandler(svc))
 	})
-	router.Delete("/api/user/urls", DeleteURLsHandler(svc))
-	router.Get("/api/internal/stats", StatsHandler(svc))
-	router.Get("/ping", PingHandler(svc))
-	router.Mount("/debug", middleware.Profiler())
 
 	return router
 }
+
+// NewDebugRouter creates the router of the profiler and the metrics. It is served on its own address
+// so that they are not exposed to the clients.
+func NewDebugRouter() *chi.Mux {
+	router := chi.NewRouter()
+	router.Mount("/debug", middleware.Profiler())
+	router.Handle("/metrics", metrics.Handler())
+	return router
+}
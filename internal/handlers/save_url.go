@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"shortener/internal/models"
 	"shortener/internal/service"
 	"shortener/internal/storage"
 )
@@ -16,18 +17,24 @@ func SaveHandler(svc *service.Service) http.HandlerFunc {
 		ctx := r.Context()
 		long, err := io.ReadAll(r.Body)
 		if err != nil {
-			svc.Log.Err("failed to read body: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to read body: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
-		short, err := svc.SaveURL(ctx, string(long))
+		short, err := svc.SaveURL(ctx, models.ShortenRequest{URL: string(long)})
 		if err != nil {
 			var duplicateErr *storage.DuplicateRecordError
 			if errors.As(err, &duplicateErr) {
 				w.WriteHeader(http.StatusConflict)
 				short = duplicateErr.Message
+			} else if errors.Is(err, service.ErrInvalidURL) {
+				http.Error(w, "Invalid URL", http.StatusBadRequest)
+				return
+			} else if errors.Is(err, service.ErrURLBlocked) {
+				http.Error(w, "Destination is blocked", http.StatusUnavailableForLegalReasons)
+				return
 			} else {
-				svc.Log.Err("failed to save url: ", err)
+				svc.Log.ErrContext(r.Context(), "failed to save url: ", err)
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
@@ -38,13 +45,13 @@ func SaveHandler(svc *service.Service) http.HandlerFunc {
 
 		resultURL, err := url.JoinPath(svc.BaseURL, short)
 		if err != nil {
-			svc.Log.Err("failed to join path to get result URL: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to join path to get result URL: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
 		_, err = w.Write([]byte(resultURL))
 		if err != nil {
-			svc.Log.Err("failed to write the full URL response to client: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to write the full URL response to client: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -18,20 +18,36 @@ func ShortenHandler(svc *service.Service) http.HandlerFunc {
 		ctx := r.Context()
 		dec := json.NewDecoder(r.Body)
 		if err := dec.Decode(&req); err != nil {
-			svc.Log.Err("failed to decode request body: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to decode request body: ", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 
-		short, err := svc.SaveURL(ctx, req.URL)
+		short, err := svc.SaveURL(ctx, req)
 		if err != nil {
 			var duplicateErr *storage.DuplicateRecordError
-			if errors.As(err, &duplicateErr) {
+			switch {
+			case errors.As(err, &duplicateErr):
 				w.WriteHeader(http.StatusConflict)
 				short = duplicateErr.Message
-			} else {
-				svc.Log.Err("failed to save url: ", err)
+			case errors.Is(err, service.ErrInvalidURL):
+				http.Error(w, "Invalid URL", http.StatusBadRequest)
+				return
+			case errors.Is(err, service.ErrURLBlocked):
+				http.Error(w, "Destination is blocked", http.StatusUnavailableForLegalReasons)
+				return
+			case errors.Is(err, service.ErrInvalidAlias):
+				http.Error(w, "Invalid alias", http.StatusBadRequest)
+				return
+			case errors.Is(err, service.ErrInvalidExpiry):
+				http.Error(w, "Invalid expiration", http.StatusBadRequest)
+				return
+			case errors.Is(err, service.ErrShortURLExists):
+				http.Error(w, "Alias is already taken", http.StatusConflict)
+				return
+			default:
+				svc.Log.ErrContext(r.Context(), "failed to save url: ", err)
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
@@ -41,7 +57,7 @@ func ShortenHandler(svc *service.Service) http.HandlerFunc {
 
 		resultURL, err := url.JoinPath(svc.BaseURL, short)
 		if err != nil {
-			svc.Log.Err("failed to join path to get result URL: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to join path to get result URL: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -50,7 +66,7 @@ func ShortenHandler(svc *service.Service) http.HandlerFunc {
 		}
 		enc := json.NewEncoder(w)
 		if err = enc.Encode(resp); err != nil {
-			svc.Log.Err("failed to encode response: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
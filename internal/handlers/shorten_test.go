@@ -46,12 +46,111 @@ func TestShortenHandler(t *testing.T) {
 		{
 			name:   "Positive #1",
 			method: http.MethodPost,
+			body:   `{"url": "HTTPS://WWW.Kinopoisk.ru:443/#top"}`,
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: ct,
+			},
+		},
+		{
+			name:   "Negative missing url #1",
+			method: http.MethodPost,
 			body:   `{"request": {"type": "SimpleRequest", "url": "https://www.kinopoisk.ru/"}}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative relative url #1",
+			method: http.MethodPost,
+			body:   `{"url": "/relative/path"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Positive alias #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/spring", "alias": "spring-sale"}`,
 			want: want{
 				statusCode:  http.StatusCreated,
 				contentType: ct,
 			},
 		},
+		{
+			name:   "Negative alias taken #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/autumn", "alias": "spring-sale"}`,
+			want: want{
+				statusCode:  http.StatusConflict,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative reserved alias #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/api", "alias": "API"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative reserved alias #2",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/healthz", "alias": "healthz"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative reserved alias #3",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/readyz", "alias": "ReadyZ"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Positive ttl #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/campaign", "ttl": 3600}`,
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: ct,
+			},
+		},
+		{
+			name:   "Negative ttl overflow #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/forever", "ttl": 10000000000}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative expiration in the past #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/past", "expires_at": "2000-01-01T00:00:00Z"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:   "Negative alias charset #1",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.org/sale", "alias": "spring sale!"}`,
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -20,14 +20,14 @@ func StatsHandler(svc *service.Service) http.HandlerFunc {
 
 		stats, err := svc.GetStats(ctx)
 		if err != nil {
-			svc.Log.Err("failed to get stats", err)
+			svc.Log.ErrContext(r.Context(), "failed to get stats", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 
 		if err = json.NewEncoder(w).Encode(stats); err != nil {
-			svc.Log.Err("failed to encode response: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
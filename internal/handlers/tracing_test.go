@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"shortener/internal/cache"
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+	"shortener/internal/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prev)
+
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{
+		Storage:   cache.NewUserStatusStorage(tracing.NewStorage(s, storage.BackendMemory), 100, time.Minute),
+		BaseURL:   cfg.App.BaseURL,
+		Log:       log,
+		SecretKey: cfg.Service.SecretKey,
+	}
+	router := NewRouter(svc)
+	token, err := svc.BuildJWTString()
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/shorten",
+		strings.NewReader(`{"url":"https://example.com/traced","alias":"traced-link"}`))
+	r.Header.Set("Cookie", "token="+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	exporter.Reset()
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	r = httptest.NewRequest(http.MethodGet, "/traced-link", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	r.Header.Set("Cookie", "token="+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	// the disabled flag of the user is cached by the first request, so only the handler reaches the storage
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	storageSpan, httpSpan := spans[0], spans[1]
+
+	assert.Equal(t, "GET /{id}", httpSpan.Name)
+	assert.Equal(t, traceID, httpSpan.SpanContext.TraceID().String())
+	assert.Equal(t, parentSpanID, httpSpan.Parent.SpanID().String())
+	assert.Contains(t, httpSpan.Attributes, attribute.String("http.route", "/{id}"))
+	assert.Contains(t, httpSpan.Attributes, attribute.Int("http.response.status_code", http.StatusTemporaryRedirect))
+
+	assert.Equal(t, "storage.Get", storageSpan.Name)
+	assert.Equal(t, traceID, storageSpan.SpanContext.TraceID().String())
+	assert.Equal(t, httpSpan.SpanContext.SpanID(), storageSpan.Parent.SpanID())
+}
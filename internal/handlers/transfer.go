@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	mw "shortener/internal/middleware"
+	"shortener/internal/service"
+	"shortener/internal/transfer"
+)
+
+// ExportHandler streams every stored record as JSONL or CSV selected by the format query parameter.
+func ExportHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !svc.IsSubnetTrusted(mw.ClientIP(svc, r)) {
+			http.Error(w, "Untrusted subnet", http.StatusForbidden)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		switch format {
+		case transfer.FormatCSV:
+			w.Header().Set("Content-Type", "text/csv")
+		case transfer.FormatJSONL, "":
+			format = transfer.FormatJSONL
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		default:
+			http.Error(w, "Unknown format", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="urls.`+format+`"`)
+
+		n, err := svc.ExportURLs(r.Context(), w, format, func(processed int) {
+			svc.Log.DebugContext(r.Context(), "export progress", "processed", processed)
+		})
+		if err != nil {
+			// the status is already sent, so the client gets a truncated body
+			svc.Log.ErrContext(r.Context(), "failed to export urls: ", err)
+			return
+		}
+		svc.Log.InfoContext(r.Context(), "urls exported", "count", n)
+	}
+}
+
+// ImportHandler stores records streamed in the request body as JSONL or CSV.
+//
+// The format and policy query parameters select the body format and the conflict policy.
+func ImportHandler(svc *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !svc.IsSubnetTrusted(mw.ClientIP(svc, r)) {
+			http.Error(w, "Untrusted subnet", http.StatusForbidden)
+			return
+		}
+		policy, err := transfer.ParsePolicy(r.URL.Query().Get("policy"))
+		if err != nil {
+			http.Error(w, "Unknown conflict policy", http.StatusBadRequest)
+			return
+		}
+
+		res, err := svc.ImportURLs(r.Context(), r.Body, r.URL.Query().Get("format"), policy, func(processed int) {
+			svc.Log.DebugContext(r.Context(), "import progress", "processed", processed)
+		})
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case err == nil:
+			svc.Log.InfoContext(r.Context(), "urls imported",
+				"imported", res.Imported, "overwritten", res.Overwritten, "skipped", res.Skipped)
+			w.WriteHeader(http.StatusOK)
+		case errors.Is(err, transfer.ErrUnknownFormat):
+			http.Error(w, "Unknown format", http.StatusBadRequest)
+			return
+		case errors.Is(err, transfer.ErrInvalidRecord):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, service.ErrImportConflict):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			svc.Log.ErrContext(r.Context(), "failed to import urls: ", err)
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		// batches stored before a failure are reported as well
+		if err = json.NewEncoder(w).Encode(res); err != nil {
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
+		}
+	}
+}
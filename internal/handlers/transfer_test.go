@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+func TestImportExportHandlers(t *testing.T) {
+	const (
+		trustedIP = "10.0.0.1"
+		body      = `{"short_url":"imported1","original_url":"https://example.org/1","user_id":"u1"}
+{"short_url":"imported2","original_url":"https://example.org/2","user_id":"u2","is_deleted":true}
+`
+	)
+	ctx := context.Background()
+	cfg := config.LoadConfig()
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	s, err := storage.LoadStorage(ctx, cfg, log)
+	require.NoError(t, err)
+	svc := &service.Service{
+		Storage:       s,
+		BaseURL:       cfg.App.BaseURL,
+		Log:           log,
+		TrustedSubnet: "10.0.0.0/8",
+		// httptest requests come from 192.0.2.1, which stands for the proxy setting X-Real-IP
+		TrustedProxies: []string{"192.0.2.1"},
+		Blocklist:      hostBlocklist{"evil.example": {}},
+	}
+
+	importTests := []struct {
+		name       string
+		query      string
+		remoteAddr string
+		realIP     string
+		body       string
+		wantStatus int
+		wantResult models.ImportResult
+	}{
+		{
+			name:       "Positive #1",
+			query:      "?format=jsonl",
+			realIP:     trustedIP,
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantResult: models.ImportResult{Imported: 2},
+		},
+		{
+			name:       "Conflict skipped",
+			realIP:     trustedIP,
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantResult: models.ImportResult{Skipped: 2},
+		},
+		{
+			name:       "Conflict fails",
+			query:      "?policy=fail",
+			realIP:     trustedIP,
+			body:       body,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Invalid record",
+			realIP:     trustedIP,
+			body:       `{"short_url":"no-long"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Canonicalized",
+			realIP:     trustedIP,
+			body:       `{"short_url":"imported3","original_url":"HTTPS://Example.org:443/3#top","user_id":"u3"}`,
+			wantStatus: http.StatusOK,
+			wantResult: models.ImportResult{Imported: 1},
+		},
+		{
+			name:       "Invalid url",
+			realIP:     trustedIP,
+			body:       `{"short_url":"bad","original_url":"ftp://example.org/file"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Blocked destination",
+			realIP:     trustedIP,
+			body:       `{"short_url":"evil","original_url":"https://evil.example/"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown policy",
+			query:      "?policy=merge",
+			realIP:     trustedIP,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Untrusted subnet",
+			realIP:     "192.168.0.1",
+			body:       body,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Spoofed real ip",
+			remoteAddr: "203.0.113.5:4321",
+			realIP:     trustedIP,
+			body:       body,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range importTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/internal/import"+tt.query, strings.NewReader(tt.body))
+			if tt.remoteAddr != "" {
+				r.RemoteAddr = tt.remoteAddr
+			}
+			r.Header.Set("X-Real-IP", tt.realIP)
+			w := httptest.NewRecorder()
+			ImportHandler(svc)(w, r)
+			res := w.Result()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus == http.StatusOK {
+				var got models.ImportResult
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+				assert.Equal(t, tt.wantResult, got)
+			}
+			assert.NoError(t, res.Body.Close())
+		})
+	}
+
+	t.Run("Export csv", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/internal/export?format=csv", nil)
+		r.Header.Set("X-Real-IP", trustedIP)
+		w := httptest.NewRecorder()
+		ExportHandler(svc)(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		out := w.Body.String()
+		assert.True(t, strings.HasPrefix(out, "uuid,short_url,original_url,user_id,is_deleted,expires_at\n"))
+		assert.Contains(t, out, ",imported1,https://example.org/1,u1,false,\n")
+		assert.Contains(t, out, ",imported2,https://example.org/2,u2,true,\n")
+		assert.Contains(t, out, ",imported3,https://example.org/3,u3,false,\n")
+		assert.NotContains(t, out, "evil")
+	})
+
+	t.Run("Export spoofed real ip", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/internal/export", nil)
+		r.RemoteAddr = "203.0.113.5:4321"
+		r.Header.Set("X-Real-IP", trustedIP)
+		w := httptest.NewRecorder()
+		ExportHandler(svc)(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "imported1")
+	})
+
+	t.Run("Export unknown format", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/internal/export?format=xml", nil)
+		r.Header.Set("X-Real-IP", trustedIP)
+		w := httptest.NewRecorder()
+		ExportHandler(svc)(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
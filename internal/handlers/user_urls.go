@@ -14,7 +14,7 @@ func GetURLsHandler(svc *service.Service) http.HandlerFunc {
 
 		urls, err := svc.GetUserURLs(ctx)
 		if err != nil {
-			svc.Log.Err("failed get user urls: ", err)
+			svc.Log.ErrContext(r.Context(), "failed get user urls: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -24,7 +24,7 @@ func GetURLsHandler(svc *service.Service) http.HandlerFunc {
 			w.WriteHeader(http.StatusNoContent)
 		}
 		if err = json.NewEncoder(w).Encode(urls); err != nil {
-			svc.Log.Err("failed to encode response: ", err)
+			svc.Log.ErrContext(r.Context(), "failed to encode response: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,151 @@
+// Package health reports the liveness and the readiness of the service and its dependencies.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout bounds a single check.
+const DefaultTimeout = 2 * time.Second
+
+// ErrStale error indicates a background goroutine stopped reporting.
+var ErrStale = errors.New("no heartbeat")
+
+// Check reports a dependency as unavailable by returning an error.
+type Check func(ctx context.Context) error
+
+// MigrationChecker is implemented by the storages with a schema, see storage.DBStore.
+type MigrationChecker interface {
+	CheckMigrations(ctx context.Context) error
+}
+
+// WritableChecker is implemented by the storages persisting to a file.
+type WritableChecker interface {
+	CheckWritable(ctx context.Context) error
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of all checks, failed if any of them failed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the registered checks. A nil Checker has no checks and is always ready.
+type Checker struct {
+	mu      sync.RWMutex
+	checks  map[string]Check
+	timeout time.Duration
+}
+
+// New creates a Checker bounding each check by the timeout, DefaultTimeout if it is not positive.
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{checks: make(map[string]Check), timeout: timeout}
+}
+
+// Add registers the check under the name replacing the previous one.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Ready runs the checks concurrently and reports each of them.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK}
+	if c == nil {
+		return report
+	}
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	report.Checks = make(map[string]Result, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			res := c.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	res := Result{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Heartbeat tracks a background goroutine that beats on every iteration.
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+// Beat records the goroutine is alive. It does nothing for a nil Heartbeat.
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Check returns the check failing once the last beat is older than maxAge.
+func (h *Heartbeat) Check(maxAge time.Duration) Check {
+	return func(_ context.Context) error {
+		last := h.last.Load()
+		if last == 0 {
+			return fmt.Errorf("not started: %w", ErrStale)
+		}
+		if age := time.Since(time.Unix(0, last)); age > maxAge {
+			return fmt.Errorf("last beat %s ago: %w", age.Round(time.Second), ErrStale)
+		}
+		return nil
+	}
+}
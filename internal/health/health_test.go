@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus string
+		wantFailed []string
+	}{
+		{
+			name:       "no checks",
+			wantStatus: StatusOK,
+		},
+		{
+			name: "all pass",
+			checks: map[string]Check{
+				"storage": func(context.Context) error { return nil },
+				"file":    func(context.Context) error { return nil },
+			},
+			wantStatus: StatusOK,
+		},
+		{
+			name: "one fails",
+			checks: map[string]Check{
+				"storage": func(context.Context) error { return errDown },
+				"file":    func(context.Context) error { return nil },
+			},
+			wantStatus: StatusFail,
+			wantFailed: []string{"storage"},
+		},
+		{
+			name: "timeout",
+			checks: map[string]Check{
+				"slow": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantStatus: StatusFail,
+			wantFailed: []string{"slow"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(10 * time.Millisecond)
+			for name, check := range tt.checks {
+				c.Add(name, check)
+			}
+			report := c.Ready(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Len(t, report.Checks, len(tt.checks))
+			for name, res := range report.Checks {
+				if assert.Contains(t, tt.checks, name) && !slices.Contains(tt.wantFailed, name) {
+					assert.Equal(t, StatusOK, res.Status, name)
+					assert.Empty(t, res.Error, name)
+					continue
+				}
+				assert.Equal(t, StatusFail, res.Status, name)
+				assert.NotEmpty(t, res.Error, name)
+			}
+		})
+	}
+}
+
+func TestChecker_ReadyNil(t *testing.T) {
+	var c *Checker
+	assert.True(t, c.Ready(context.Background()).OK())
+}
+
+func TestHeartbeat_Check(t *testing.T) {
+	var h Heartbeat
+	check := h.Check(time.Hour)
+	assert.ErrorIs(t, check(context.Background()), ErrStale)
+
+	h.Beat()
+	assert.NoError(t, check(context.Background()))
+
+	h.last.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	assert.ErrorIs(t, check(context.Background()), ErrStale)
+}
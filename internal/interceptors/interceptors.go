@@ -2,9 +2,12 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
@@ -12,40 +15,144 @@ import (
 	"shortener/internal/service"
 )
 
-// UserIDUnaryInterceptor generates and adds JWT token from metadata to context.
+// tokenKey is the metadata key of the session token.
+const tokenKey = "token"
+
+// UserIDUnaryInterceptor authenticates the call and adds the user to the context.
+//
+// An API key sent as x-api-key or authorization: Bearer sk_... metadata authenticates the call. Otherwise
+// the session token is read from the token or the authorization: Bearer metadata, and a call without one
+// gets a new anonymous user whose token is sent back in the token response header to be reused.
+// The health checks are anonymous.
 func UserIDUnaryInterceptor(svc *service.Service) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
-		_ *grpc.UnaryServerInfo,
+		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			token, err := svc.BuildJWTString()
-			if err != nil {
-				svc.Log.Err("Failed to generate token", err)
-				return nil, status.Error(codes.Unauthenticated, "Access denied")
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		newCtx, token, err := authenticate(ctx, svc)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			if err = grpc.SetHeader(ctx, metadata.Pairs(tokenKey, token)); err != nil {
+				svc.Log.ErrContext(ctx, "failed to set token header: ", err)
+				return nil, status.Error(codes.Internal, "failed to authenticate")
 			}
-			md = metadata.New(map[string]string{"token": token})
-		}
-		token := md.Get("token")
-		if len(token) == 0 {
-			generatedToken, err := svc.BuildJWTString()
-			if err != nil {
-				svc.Log.Err("Failed to generate token", err)
-				return nil, status.Error(codes.Unauthenticated, "Access denied")
+		}
+		return handler(newCtx, req)
+	}
+}
+
+// UserIDStreamInterceptor is UserIDUnaryInterceptor of the streaming calls.
+func UserIDStreamInterceptor(svc *service.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isHealthCheck(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, token, err := authenticate(ss.Context(), svc)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			if err = ss.SetHeader(metadata.Pairs(tokenKey, token)); err != nil {
+				svc.Log.ErrContext(ctx, "failed to set token header: ", err)
+				return status.Error(codes.Internal, "failed to authenticate")
 			}
-			md.Set("token", generatedToken)
-			token = append(token, generatedToken)
 		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
 
-		userID := svc.GetUserID(token[0], svc.SecretKey, svc.Log)
-		if userID == "" {
-			return nil, status.Error(codes.Unauthenticated, "Access denied")
+// authenticate returns the context with the user of the call and the token minted for a new user, if any.
+func authenticate(ctx context.Context, svc *service.Service) (context.Context, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := firstValue(md, "authorization")
+	if secret := service.APIKeyFromHeaders(firstValue(md, "x-api-key"), authorization); secret != "" {
+		key, err := svc.AuthenticateAPIKey(ctx, secret)
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidAPIKey) {
+				svc.Log.ErrContext(ctx, "failed to authenticate api key: ", err)
+				return nil, "", status.Error(codes.Internal, "failed to authenticate")
+			}
+			return nil, "", status.Error(codes.Unauthenticated, "Access denied")
 		}
-		newCtx := context.WithValue(ctx, models.CtxUserIDKey, userID)
+		if err = checkEnabled(ctx, svc, key.UserID); err != nil {
+			return nil, "", err
+		}
+		ctx = context.WithValue(ctx, models.CtxUserIDKey, key.UserID)
+		return context.WithValue(ctx, models.CtxAPIKeyIDKey, key.ID), "", nil
+	}
 
-		return handler(newCtx, req)
+	token := firstValue(md, tokenKey)
+	if token == "" {
+		token = bearerToken(authorization)
+	}
+	var minted string
+	if token == "" {
+		generatedToken, err := svc.BuildJWTString()
+		if err != nil {
+			svc.Log.ErrContext(ctx, "Failed to generate token", err)
+			return nil, "", status.Error(codes.Unauthenticated, "Access denied")
+		}
+		token, minted = generatedToken, generatedToken
+	}
+
+	userID := svc.GetUserID(token, svc.SecretKey, svc.Log)
+	if userID == "" {
+		return nil, "", status.Error(codes.Unauthenticated, "Access denied")
+	}
+	if err := checkEnabled(ctx, svc, userID); err != nil {
+		return nil, "", err
+	}
+	return context.WithValue(ctx, models.CtxUserIDKey, userID), minted, nil
+}
+
+// bearerToken returns the token of the authorization: Bearer metadata.
+func bearerToken(authorization string) string {
+	const bearer = "Bearer "
+	if len(authorization) > len(bearer) && strings.EqualFold(authorization[:len(bearer)], bearer) {
+		return strings.TrimSpace(authorization[len(bearer):])
+	}
+	return ""
+}
+
+// isHealthCheck reports whether the method belongs to the gRPC health service.
+func isHealthCheck(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// serverStream replaces the context of the stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// checkEnabled returns the status error for a user disabled by an operator.
+func checkEnabled(ctx context.Context, svc *service.Service, userID string) error {
+	err := svc.CheckUserEnabled(ctx, userID)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, service.ErrUserDisabled) {
+		return status.Error(codes.PermissionDenied, "User disabled")
+	}
+	svc.Log.ErrContext(ctx, "failed to check user: ", err)
+	return status.Error(codes.Internal, "failed to authenticate")
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
 	}
+	return ""
 }
@@ -0,0 +1,39 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"shortener/internal/metrics"
+)
+
+// MetricsUnaryInterceptor counts the calls and observes their latency per method.
+func MetricsUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	metrics.GRPCRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	metrics.GRPCDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// MetricsStreamInterceptor is MetricsUnaryInterceptor of the streaming calls, a call lasts until its stream ends.
+func MetricsStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	metrics.GRPCRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	metrics.GRPCDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	return err
+}
@@ -0,0 +1,109 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/service"
+	pb "shortener/pkg/service/proto"
+)
+
+// RateLimitUnaryInterceptor throttles the saves and the lookups like the HTTP routes.
+//
+// It has to run after UserIDUnaryInterceptor to see the user of the call. A rejected call gets
+// ResourceExhausted and the retry-after header in seconds.
+func RateLimitUnaryInterceptor(svc *service.Service) grpc.UnaryServerInterceptor {
+	limiters := map[string]*ratelimit.Limiter{
+		pb.URLShortenerService_Save_FullMethodName:    svc.RateLimits.Save,
+		pb.URLShortenerService_Shorten_FullMethodName: svc.RateLimits.Save,
+		pb.URLShortenerService_Batch_FullMethodName:   svc.RateLimits.Batch,
+		pb.URLShortenerService_Get_FullMethodName:     svc.RateLimits.Redirect,
+	}
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		limiter, ok := limiters[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		userID, _ := ctx.Value(models.CtxUserIDKey).(string)
+		ip := peerIP(ctx)
+		if wait, ok := limiter.Allow(ratelimit.Keys(userID, ip)...); !ok {
+			svc.Log.InfoContext(ctx, "rate limit exceeded", "method", info.FullMethod, "user", userID, "ip", ip)
+			retryAfter := strconv.Itoa(ratelimit.RetryAfter(wait))
+			if err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter)); err != nil {
+				svc.Log.ErrContext(ctx, "failed to set retry-after header: ", err)
+			}
+			return nil, status.Error(codes.ResourceExhausted, "Too many requests, retry after "+retryAfter+"s")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor throttles the streamed saves taking a token for every received message.
+//
+// BatchStream messages are taken from the batch limiter and ShortenStream ones from the save limiter.
+// It has to run after UserIDStreamInterceptor. A rejected message ends the stream with ResourceExhausted
+// and the retry-after trailer in seconds.
+func RateLimitStreamInterceptor(svc *service.Service) grpc.StreamServerInterceptor {
+	limiters := map[string]*ratelimit.Limiter{
+		pb.URLShortenerService_BatchStream_FullMethodName:   svc.RateLimits.Batch,
+		pb.URLShortenerService_ShortenStream_FullMethodName: svc.RateLimits.Save,
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		limiter, ok := limiters[info.FullMethod]
+		if !ok || limiter == nil {
+			return handler(srv, ss)
+		}
+		return handler(srv, &limitedStream{ServerStream: ss, svc: svc, limiter: limiter, method: info.FullMethod})
+	}
+}
+
+// limitedStream takes a token for every message received from the client.
+type limitedStream struct {
+	grpc.ServerStream
+	svc     *service.Service
+	limiter *ratelimit.Limiter
+	method  string
+}
+
+// RecvMsg receives the message unless the client ran out of tokens.
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	ctx := s.Context()
+	userID, _ := ctx.Value(models.CtxUserIDKey).(string)
+	ip := peerIP(ctx)
+	if wait, ok := s.limiter.Allow(ratelimit.Keys(userID, ip)...); !ok {
+		s.svc.Log.InfoContext(ctx, "rate limit exceeded", "method", s.method, "user", userID, "ip", ip)
+		retryAfter := strconv.Itoa(ratelimit.RetryAfter(wait))
+		s.SetTrailer(metadata.Pairs("retry-after", retryAfter))
+		return status.Error(codes.ResourceExhausted, "Too many requests, retry after "+retryAfter+"s")
+	}
+	return nil
+}
+
+// peerIP returns the IP address of the calling client.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if tcpAddr, ok := p.Addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+	return p.Addr.String()
+}
@@ -0,0 +1,51 @@
+package interceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"shortener/internal/logger"
+	"shortener/internal/service"
+)
+
+// requestIDKey is the metadata key of the request ID, the gRPC form of X-Request-ID.
+const requestIDKey = "x-request-id"
+
+// RequestIDUnaryInterceptor puts the request ID from the x-request-id metadata, or a new one, into the context
+// of the log lines and sends it back in the response header.
+func RequestIDUnaryInterceptor(svc *service.Service) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx = requestIDContext(ctx)
+		if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, logger.RequestID(ctx))); err != nil {
+			svc.Log.ErrContext(ctx, "failed to set request id header: ", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RequestIDStreamInterceptor is RequestIDUnaryInterceptor of the streaming calls.
+func RequestIDStreamInterceptor(svc *service.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := requestIDContext(ss.Context())
+		if err := ss.SetHeader(metadata.Pairs(requestIDKey, logger.RequestID(ctx))); err != nil {
+			svc.Log.ErrContext(ctx, "failed to set request id header: ", err)
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// requestIDContext returns the context with the request ID of the call or a new one.
+func requestIDContext(ctx context.Context) context.Context {
+	var fromClient string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(values) > 0 {
+		fromClient = values[0]
+	}
+	return logger.WithRequestID(ctx, service.RequestID(fromClient))
+}
@@ -2,62 +2,202 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+
+	"shortener/internal/models"
+)
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
+// Log outputs besides a file path.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// RequestIDKey is the attribute of the request ID in the log lines.
+const RequestIDKey = "request_id"
+
+// ErrInvalidOptions error indicates an unknown log format or level.
+var ErrInvalidOptions = errors.New("invalid logger options")
+
 // Log struct.
 type Log struct {
 	Logger *slog.Logger
 }
 
+// Options configure the logger.
+type Options struct {
+	// Output receives the log lines, stdout by default.
+	Output io.Writer
+	// Format is "text" (default) or "json".
+	Format string
+	// Level is DEBUG, INFO (default), WARN or ERROR, in any case.
+	Level string
+}
+
 // Initialize logger.
 func (l *Log) Initialize(level string) *slog.Logger {
-	const (
-		info    = "INFO"
-		debug   = "DEBUG"
-		warning = "WARNING"
-		errorL  = "ERROR"
-	)
-	var logLevel slog.Level
-	switch level {
-	case info:
+	logLevel, err := parseLevel(level)
+	if err != nil {
 		logLevel = slog.LevelInfo
-	case debug:
-		logLevel = slog.LevelDebug
-	case warning:
-		logLevel = slog.LevelWarn
-	case errorL:
-		logLevel = slog.LevelError
 	}
-	l.Logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	l.Logger = slog.New(contextHandler{slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})})
+
+	return l.Logger
+}
+
+// Setup replaces the logger with the configured one. Everything holding the Log switches to it at once.
+func (l *Log) Setup(opts Options) error {
+	logLevel, err := parseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	handlerOpts := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		return fmt.Errorf("format %q: %w", opts.Format, ErrInvalidOptions)
+	}
+	l.Logger = slog.New(contextHandler{handler})
+	return nil
+}
+
+// OpenOutput returns the writer for the output name: stdout, stderr or a file path to append to.
+//
+// The returned function closes the file and does nothing for the standard streams.
+func OpenOutput(name string) (io.Writer, func() error, error) {
+	switch name {
+	case "", OutputStdout:
+		return os.Stdout, func() error { return nil }, nil
+	case OutputStderr:
+		return os.Stderr, func() error { return nil }, nil
+	}
+	const perm = 0o600
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, perm)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, file.Close, nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return slog.LevelDebug, nil
+	case "", "INFO":
+		return slog.LevelInfo, nil
+	case "WARN", "WARNING":
+		return slog.LevelWarn, nil
+	case "ERROR":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("level %q: %w", level, ErrInvalidOptions)
+	}
+}
+
+// WithRequestID returns the context carrying the request ID for the log lines.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, models.CtxRequestIDKey, id)
+}
+
+// RequestID returns the request ID of the context.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(models.CtxRequestIDKey).(string)
+	return id
+}
+
+// contextHandler adds the request ID of the context to the log lines.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
 
+// WithGroup implements slog.Handler.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// logger returns the configured logger falling back to the default one for a zero Log.
+func (l *Log) logger() *slog.Logger {
+	if l == nil || l.Logger == nil {
+		return slog.Default()
+	}
 	return l.Logger
 }
 
 // Fatal event.
 func (l *Log) Fatal(v ...any) {
-	l.Logger.Error(fmt.Sprint(v...))
+	l.logger().Error(fmt.Sprint(v...))
 	os.Exit(1)
 }
 
 // Err event.
 func (l *Log) Err(message string, value interface{}) {
-	slog.Error(message, slog.String("err", fmt.Sprintf("%v", value)))
+	l.ErrContext(context.Background(), message, value)
+}
+
+// ErrContext event with the request ID of the context.
+func (l *Log) ErrContext(ctx context.Context, message string, value interface{}) {
+	l.logger().ErrorContext(ctx, message, slog.String("err", fmt.Sprintf("%v", value)))
 }
 
 // Info event.
 func (l *Log) Info(msg string, args ...any) {
-	l.Logger.Info(msg, args...)
+	l.logger().Info(msg, args...)
+}
+
+// InfoContext event with the request ID of the context.
+func (l *Log) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.logger().InfoContext(ctx, msg, args...)
 }
 
 // Debug event.
 func (l *Log) Debug(msg string, args ...any) {
-	l.Logger.Debug(msg, args...)
+	l.logger().Debug(msg, args...)
+}
+
+// DebugContext event with the request ID of the context.
+func (l *Log) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.logger().DebugContext(ctx, msg, args...)
 }
 
 // Warn event.
 func (l *Log) Warn(msg string, args ...any) {
-	l.Logger.Debug(msg, args...)
+	l.logger().Warn(msg, args...)
+}
+
+// WarnContext event with the request ID of the context.
+func (l *Log) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.logger().WarnContext(ctx, msg, args...)
 }
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_Setup(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "defaults", opts: Options{}},
+		{name: "json warning", opts: Options{Format: "JSON", Level: "warning"}},
+		{name: "text debug", opts: Options{Format: FormatText, Level: "DEBUG"}},
+		{name: "unknown format", opts: Options{Format: "xml"}, wantErr: true},
+		{name: "unknown level", opts: Options{Level: "TRACE"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = &bytes.Buffer{}
+			err := (&Log{}).Setup(tt.opts)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidOptions)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLog_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := &Log{}
+	require.NoError(t, log.Setup(Options{Output: &buf, Format: FormatJSON, Level: "INFO"}))
+
+	log.InfoContext(WithRequestID(context.Background(), "req-1"), "handled", "path", "/")
+	log.Debug("filtered out")
+	log.Warn("no request")
+
+	dec := json.NewDecoder(&buf)
+	var first, second map[string]any
+	require.NoError(t, dec.Decode(&first))
+	require.NoError(t, dec.Decode(&second))
+	assert.False(t, dec.More())
+
+	assert.Equal(t, "INFO", first["level"])
+	assert.Equal(t, "req-1", first[RequestIDKey])
+	assert.Equal(t, "/", first["path"])
+	assert.Equal(t, "WARN", second["level"])
+	assert.NotContains(t, second, RequestIDKey)
+}
@@ -0,0 +1,170 @@
+// Package metrics collects the service metrics and exposes them in the Prometheus format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortener"
+
+// Redirect results.
+const (
+	RedirectHit     = "hit"
+	RedirectMiss    = "miss"
+	RedirectGone    = "gone"
+	RedirectBlocked = "blocked"
+)
+
+var (
+	// Registry holds every metric of the service along with the Go runtime and process ones.
+	Registry = prometheus.NewRegistry()
+
+	// HTTPRequests counts the HTTP requests per chi route pattern.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	// HTTPDuration observes the HTTP request latency per chi route pattern.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// GRPCRequests counts the gRPC calls per method.
+	GRPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	// GRPCDuration observes the gRPC call latency per method.
+	GRPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// Redirects counts the short link lookups by result: hit, miss, gone or blocked.
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short link lookups by result.",
+	}, []string{"result"})
+
+	// StorageDuration observes the latency of the URLStorage methods per backend.
+	StorageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Storage operation latency by backend, operation and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"backend", "operation", "result"})
+
+	// CleanupRuns counts the background cleanup runs by result.
+	CleanupRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cleanup_runs_total",
+		Help:      "Background cleanup runs by result.",
+	}, []string{"result"})
+	// CleanupDeleted counts the short links purged by the background cleanup.
+	CleanupDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cleanup_deleted_urls_total",
+		Help:      "Short links purged by the background cleanup.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		GRPCRequests,
+		GRPCDuration,
+		Redirects,
+		StorageDuration,
+		CleanupRuns,
+		CleanupDeleted,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Result returns the result label of an operation.
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// PoolStater is implemented by the storages backed by a pgx connection pool.
+type PoolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// RegisterPool exposes the connection pool stats of the storage.
+func RegisterPool(pool PoolStater) error {
+	return Registry.Register(&poolCollector{pool: pool})
+}
+
+var (
+	poolAcquired = prometheus.NewDesc(namespace+"_pgxpool_acquired_conns",
+		"Connections currently in use.", nil, nil)
+	poolIdle = prometheus.NewDesc(namespace+"_pgxpool_idle_conns",
+		"Idle connections.", nil, nil)
+	poolConstructing = prometheus.NewDesc(namespace+"_pgxpool_constructing_conns",
+		"Connections being established.", nil, nil)
+	poolTotal = prometheus.NewDesc(namespace+"_pgxpool_total_conns",
+		"All connections of the pool.", nil, nil)
+	poolMax = prometheus.NewDesc(namespace+"_pgxpool_max_conns",
+		"Maximum size of the pool.", nil, nil)
+	poolAcquires = prometheus.NewDesc(namespace+"_pgxpool_acquires_total",
+		"Successful connection acquires.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc(namespace+"_pgxpool_empty_acquires_total",
+		"Acquires that had to wait for a connection.", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc(namespace+"_pgxpool_canceled_acquires_total",
+		"Acquires canceled by the context.", nil, nil)
+	poolAcquireDuration = prometheus.NewDesc(namespace+"_pgxpool_acquire_duration_seconds_total",
+		"Total time spent acquiring connections.", nil, nil)
+)
+
+// poolCollector reads the pool stats on every scrape.
+type poolCollector struct {
+	pool PoolStater
+}
+
+// Describe implements prometheus.Collector.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		poolAcquired, poolIdle, poolConstructing, poolTotal, poolMax,
+		poolAcquires, poolEmptyAcquires, poolCanceledAcquires, poolAcquireDuration,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolConstructing, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotal, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMax, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
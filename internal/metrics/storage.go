@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// Storage wraps any URLStorage and observes the latency of the methods on the request and the background paths.
+// The rarely called admin, account and transfer methods go to the embedded storage as is.
+type Storage struct {
+	service.URLStorage
+	backend string
+}
+
+// NewStorage creates an instrumented URLStorage, backend labels its metrics.
+func NewStorage(store service.URLStorage, backend string) *Storage {
+	return &Storage{URLStorage: store, backend: backend}
+}
+
+// observe records the operation latency. Missing records are not counted as errors.
+func (s *Storage) observe(operation string, start time.Time, err error) {
+	result := Result(err)
+	if errors.Is(err, service.ErrURLNotFound) || errors.Is(err, service.ErrAPIKeyNotFound) ||
+		errors.Is(err, service.ErrAccountNotFound) {
+		result = "not_found"
+	}
+	StorageDuration.WithLabelValues(s.backend, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// Ping implements service.URLStorage.
+func (s *Storage) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := s.URLStorage.Ping(ctx)
+	s.observe("Ping", start, err)
+	return err
+}
+
+// Get implements service.URLStorage.
+func (s *Storage) Get(ctx context.Context, shortLink string) (string, error) {
+	start := time.Now()
+	res, err := s.URLStorage.Get(ctx, shortLink)
+	s.observe("Get", start, err)
+	return res, err
+}
+
+// Save implements service.URLStorage.
+func (s *Storage) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
+	start := time.Now()
+	err := s.URLStorage.Save(ctx, shortLink, longLink, expiresAt)
+	s.observe("Save", start, err)
+	return err
+}
+
+// BatchSave implements service.URLStorage.
+func (s *Storage) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
+	start := time.Now()
+	res, err := s.URLStorage.BatchSave(ctx, input)
+	s.observe("BatchSave", start, err)
+	return res, err
+}
+
+// GetByUserID implements service.URLStorage.
+func (s *Storage) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
+	start := time.Now()
+	res, err := s.URLStorage.GetByUserID(ctx)
+	s.observe("GetByUserID", start, err)
+	return res, err
+}
+
+// GetByUserIDPage implements service.URLStorage.
+func (s *Storage) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	start := time.Now()
+	res, err := s.URLStorage.GetByUserIDPage(ctx, after, limit)
+	s.observe("GetByUserIDPage", start, err)
+	return res, err
+}
+
+// DeleteURLs implements service.URLStorage.
+func (s *Storage) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
+	start := time.Now()
+	err := s.URLStorage.DeleteURLs(ctx, input)
+	s.observe("DeleteURLs", start, err)
+	return err
+}
+
+// Cleanup implements service.URLStorage.
+func (s *Storage) Cleanup(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	res, err := s.URLStorage.Cleanup(ctx)
+	s.observe("Cleanup", start, err)
+	return res, err
+}
+
+// ServiceStats implements service.URLStorage.
+func (s *Storage) ServiceStats(ctx context.Context) (models.Stats, error) {
+	start := time.Now()
+	res, err := s.URLStorage.ServiceStats(ctx)
+	s.observe("ServiceStats", start, err)
+	return res, err
+}
+
+// SaveClicks implements service.URLStorage.
+func (s *Storage) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	start := time.Now()
+	err := s.URLStorage.SaveClicks(ctx, clicks)
+	s.observe("SaveClicks", start, err)
+	return err
+}
+
+// ClickStats implements service.URLStorage.
+func (s *Storage) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	start := time.Now()
+	res, err := s.URLStorage.ClickStats(ctx, shortLink)
+	s.observe("ClickStats", start, err)
+	return res, err
+}
+
+// NextSequence implements service.URLStorage.
+func (s *Storage) NextSequence(ctx context.Context) (uint64, error) {
+	start := time.Now()
+	res, err := s.URLStorage.NextSequence(ctx)
+	s.observe("NextSequence", start, err)
+	return res, err
+}
+
+// GetAPIKey implements service.URLStorage.
+func (s *Storage) GetAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	start := time.Now()
+	res, err := s.URLStorage.GetAPIKey(ctx, hash)
+	s.observe("GetAPIKey", start, err)
+	return res, err
+}
+
+// GetURLRecord implements service.URLStorage.
+func (s *Storage) GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error) {
+	start := time.Now()
+	res, err := s.URLStorage.GetURLRecord(ctx, shortLink)
+	s.observe("GetURLRecord", start, err)
+	return res, err
+}
+
+// IsUserDisabled implements service.URLStorage.
+func (s *Storage) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	start := time.Now()
+	res, err := s.URLStorage.IsUserDisabled(ctx, userID)
+	s.observe("IsUserDisabled", start, err)
+	return res, err
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"shortener/internal/service"
+	"shortener/internal/service/mocks"
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewStorage(mockStore, "test")
+
+	mockStore.EXPECT().Get(gomock.Any(), "found").Return("https://example.com/", nil)
+	mockStore.EXPECT().Get(gomock.Any(), "missing").Return("", service.ErrURLNotFound)
+	mockStore.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+
+	long, err := store.Get(ctx, "found")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/", long)
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+	assert.Error(t, store.Ping(ctx))
+
+	tests := []struct {
+		name      string
+		operation string
+		result    string
+	}{
+		{name: "found", operation: "Get", result: "ok"},
+		{name: "missing is not an error", operation: "Get", result: "not_found"},
+		{name: "failed", operation: "Ping", result: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m dto.Metric
+			observer := StorageDuration.WithLabelValues("test", tt.operation, tt.result)
+			require.NoError(t, observer.(prometheus.Metric).Write(&m))
+			assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+		})
+	}
+}
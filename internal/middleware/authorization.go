@@ -36,14 +36,37 @@ func Auth(svc *service.Service) *BaseAuth {
 }
 
 // Middleware returns an HTTP handler that checks for the presence of a JWT token in the request.
+//
+// Requests presenting an API key in the X-API-Key or Authorization: Bearer header are authenticated
+// by the key instead and never get a new token.
 func (ba *BaseAuth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := r.Cookie("token")
 		rCtx := r.Context()
+		if secret := service.APIKeyFromHeaders(r.Header.Get("X-API-Key"), r.Header.Get("Authorization")); secret != "" {
+			key, err := ba.Service.AuthenticateAPIKey(rCtx, secret)
+			if err != nil {
+				if errors.Is(err, service.ErrInvalidAPIKey) {
+					http.Error(w, unauthorized, http.StatusUnauthorized)
+					return
+				}
+				ba.Service.Log.ErrContext(r.Context(), "failed to authenticate api key: ", err)
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			if !ba.checkEnabled(w, r, key.UserID) {
+				return
+			}
+			newCtx := context.WithValue(rCtx, models.CtxUserIDKey, key.UserID)
+			newCtx = context.WithValue(newCtx, models.CtxAPIKeyIDKey, key.ID)
+			next.ServeHTTP(w, r.WithContext(newCtx))
+			return
+		}
+
+		token, err := r.Cookie("token")
 		if err != nil && errors.Is(err, http.ErrNoCookie) {
 			newToken, err := ba.Service.BuildJWTString()
 			if err != nil {
-				ba.Service.Log.Err("failed build JWTString: ", err)
+				ba.Service.Log.ErrContext(r.Context(), "failed build JWTString: ", err)
 				http.Error(w, "", http.StatusInternalServerError)
 				return
 			}
@@ -51,23 +74,64 @@ func (ba *BaseAuth) Middleware(next http.Handler) http.Handler {
 			http.SetCookie(w, &http.Cookie{Name: "token", Value: newToken})
 			token = &http.Cookie{Name: "token", Value: newToken}
 		} else if err != nil {
-			ba.Service.Log.Err("failed get cookie: ", err)
+			ba.Service.Log.ErrContext(r.Context(), "failed get cookie: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
 
 		userID := ba.Service.GetUserID(token.Value, ba.Service.SecretKey, ba.Service.Log)
 		if userID == "" {
-			ba.Service.Log.Err(unauthorized, "no userID")
+			ba.Service.Log.ErrContext(r.Context(), unauthorized, "no userID")
 			http.Error(w, unauthorized, http.StatusUnauthorized)
 			return
 		}
+		if !ba.checkEnabled(w, r, userID) {
+			return
+		}
 		newCtx := context.WithValue(rCtx, models.CtxUserIDKey, userID)
 		rWithCtx := r.WithContext(newCtx)
 		next.ServeHTTP(w, rWithCtx)
 	})
 }
 
+// checkEnabled replies 403 to a user disabled by an operator and reports whether the request may proceed.
+func (ba *BaseAuth) checkEnabled(w http.ResponseWriter, r *http.Request, userID string) bool {
+	err := ba.Service.CheckUserEnabled(r.Context(), userID)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrUserDisabled) {
+		http.Error(w, "User disabled", http.StatusForbidden)
+		return false
+	}
+	ba.Service.Log.ErrContext(r.Context(), "failed to check user: ", err)
+	http.Error(w, "", http.StatusInternalServerError)
+	return false
+}
+
+// BaseTrusted represents the trusted subnet middleware.
+type BaseTrusted struct {
+	Service *service.Service
+}
+
+// TrustedSubnet creates a new instance of the BaseTrusted middleware.
+func TrustedSubnet(svc *service.Service) *BaseTrusted {
+	return &BaseTrusted{Service: svc}
+}
+
+// Middleware returns an HTTP handler that only lets through requests whose client address is in the trusted subnet.
+//
+// The address is resolved by ClientIP, so only the trusted proxies can set it with a header.
+func (bt *BaseTrusted) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bt.Service.IsSubnetTrusted(ClientIP(bt.Service, r)) {
+			http.Error(w, "Untrusted subnet", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // BaseCheck represents the base check middleware.
 type BaseCheck struct {
 	Log *logger.Log
@@ -79,8 +143,14 @@ func CheckAuth(log *logger.Log) *BaseCheck {
 }
 
 // Middleware returns an HTTP handler that checks for the presence of an Authorization header and a token cookie.
+//
+// Requests already authenticated by an API key pass through.
 func (bc *BaseCheck) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(models.CtxAPIKeyIDKey).(string); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
 		auth := r.Header.Get("Authorization")
 		if auth == "" {
 			http.Error(w, unauthorized, http.StatusUnauthorized)
@@ -88,7 +158,7 @@ func (bc *BaseCheck) Middleware(next http.Handler) http.Handler {
 		}
 		token, err := r.Cookie("token")
 		if err != nil {
-			bc.Log.Err("failed get token from cookies: ", err)
+			bc.Log.ErrContext(r.Context(), "failed get token from cookies: ", err)
 			http.Error(w, "", http.StatusInternalServerError)
 			return
 		}
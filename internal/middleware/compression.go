@@ -43,7 +43,7 @@ func (ng *BaseMW) Middleware(next http.Handler) http.Handler {
 				defer func() {
 					err := cw.Close()
 					if err != nil {
-						ng.Log.Err("failed to close compress writer: ", err)
+						ng.Log.ErrContext(r.Context(), "failed to close compress writer: ", err)
 						http.Error(w, "", http.StatusInternalServerError)
 						return
 					}
@@ -56,7 +56,7 @@ func (ng *BaseMW) Middleware(next http.Handler) http.Handler {
 		if sendsGzip {
 			cr, err := newCompressReader(r.Body)
 			if err != nil {
-				ng.Log.Err("failed to read compressed body: ", err)
+				ng.Log.ErrContext(r.Context(), "failed to read compressed body: ", err)
 				http.Error(w, "check if gzip data is valid", http.StatusBadRequest)
 				return
 			} else {
@@ -64,7 +64,7 @@ func (ng *BaseMW) Middleware(next http.Handler) http.Handler {
 				defer func() {
 					err = cr.Close()
 					if err != nil {
-						ng.Log.Err("failed to close compress reader: ", err)
+						ng.Log.ErrContext(r.Context(), "failed to close compress reader: ", err)
 						http.Error(w, "", http.StatusInternalServerError)
 						return
 					}
@@ -27,11 +27,11 @@ func (b *BaseLog) Middleware(next http.Handler) http.Handler {
 		start := time.Now()
 		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 		defer func() {
-			b.Log.Info(
+			b.Log.InfoContext(r.Context(),
 				"OK",
-				"path", r.Method,
+				"method", r.Method,
 				"path", r.URL.Path,
-				"status", ww.Status(),
+				"status", statusCode(ww),
 				"size", ww.BytesWritten(),
 				"duration", time.Since(start).String(),
 			)
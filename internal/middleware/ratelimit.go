@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/service"
+)
+
+// BaseRateLimit represents the rate limiting middleware.
+type BaseRateLimit struct {
+	Limiter *ratelimit.Limiter
+	Service *service.Service
+}
+
+// RateLimit creates a new instance of the BaseRateLimit middleware. A nil limiter lets every request through.
+func RateLimit(svc *service.Service, limiter *ratelimit.Limiter) *BaseRateLimit {
+	return &BaseRateLimit{Limiter: limiter, Service: svc}
+}
+
+// Middleware returns an HTTP handler that replies 429 with Retry-After once the user or the IP address
+// runs out of tokens.
+func (rl *BaseRateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(models.CtxUserIDKey).(string)
+		ip := ClientIP(rl.Service, r)
+		if wait, ok := rl.Limiter.Allow(ratelimit.Keys(userID, ip)...); !ok {
+			rl.Service.Log.InfoContext(r.Context(), "rate limit exceeded", "path", r.URL.Path, "user", userID, "ip", ip)
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the client address of the request, see service.Service.ClientIP.
+func ClientIP(svc *service.Service, r *http.Request) string {
+	return svc.ClientIP(r.RemoteAddr, r.Header.Get("X-Real-IP"), r.Header.Get("X-Forwarded-For"))
+}
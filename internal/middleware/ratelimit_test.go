@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/service"
+)
+
+func TestBaseRateLimit_Middleware(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	svc := &service.Service{Log: log, TrustedProxies: []string{"192.0.2.1"}}
+	handler := RateLimit(svc, ratelimit.New(0.5, 2)).Middleware(next)
+
+	const proxy = "192.0.2.1:1234"
+	tests := []struct {
+		name       string
+		userID     string
+		remoteAddr string
+		realIP     string
+		wantStatus int
+		retryAfter string
+	}{
+		{name: "first request", userID: "u1", remoteAddr: proxy, realIP: "10.0.0.1", wantStatus: http.StatusCreated},
+		{name: "burst", userID: "u1", remoteAddr: proxy, realIP: "10.0.0.1", wantStatus: http.StatusCreated},
+		{
+			name:       "user exhausted",
+			userID:     "u1",
+			remoteAddr: proxy,
+			realIP:     "10.0.0.2",
+			wantStatus: http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+		{
+			name:       "ip exhausted for a new user",
+			userID:     "u2",
+			remoteAddr: proxy,
+			realIP:     "10.0.0.1",
+			wantStatus: http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+		{name: "another client", userID: "u3", remoteAddr: proxy, realIP: "10.0.0.3", wantStatus: http.StatusCreated},
+		{name: "direct client", userID: "u4", remoteAddr: "10.0.0.4:1234", wantStatus: http.StatusCreated},
+		{name: "direct client burst", userID: "u5", remoteAddr: "10.0.0.4:1234", wantStatus: http.StatusCreated},
+		{
+			name:       "spoofed real ip is ignored",
+			userID:     "u6",
+			remoteAddr: "10.0.0.4:1234",
+			realIP:     "10.0.0.9",
+			wantStatus: http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), models.CtxUserIDKey, tt.userID))
+			r.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+
+	unlimited := RateLimit(svc, nil).Middleware(next)
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		unlimited.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"shortener/internal/logger"
+	"shortener/internal/service"
+)
+
+// RequestIDHeader carries the request ID from the client and back in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns an HTTP handler that puts the request ID into the context of the log lines and echoes it.
+//
+// The client's X-Request-ID is kept if it is a sane token, otherwise a new one is generated.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := service.RequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"shortener/internal/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		seen = logger.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "from client", header: "abc-123", wantSame: true},
+		{name: "missing", header: ""},
+		{name: "too long", header: strings.Repeat("a", 129)},
+		{name: "control characters", header: "abc\x01def"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, seen)
+			if tt.wantSame {
+				assert.Equal(t, tt.header, got)
+			} else {
+				assert.NotEqual(t, tt.header, got)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 // Package models using for describe request and response models.
 package models
 
+import "time"
+
 // ShortenRequest shorten request model.
 type ShortenRequest struct {
-	URL string `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	URL       string     `json:"url"`
+	Alias     string     `json:"alias,omitempty"`
+	// TTL is the link lifetime in seconds, mutually exclusive with ExpiresAt.
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 // ShortenResponse shorten response model.
@@ -13,8 +19,12 @@ type ShortenResponse struct {
 
 // BatchRequest shorten request model.
 type BatchRequest struct {
-	CorrelationID string `json:"correlation_id"`
-	OriginalURL   string `json:"original_url"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CorrelationID string     `json:"correlation_id"`
+	OriginalURL   string     `json:"original_url"`
+	Alias         string     `json:"alias,omitempty"`
+	// TTL is the link lifetime in seconds, mutually exclusive with ExpiresAt.
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 // BatchResponse shorten response model.
@@ -25,9 +35,10 @@ type BatchResponse struct {
 
 // Batch shorten model.
 type Batch struct {
-	CorrelationID string `json:"correlation_id"`
-	ShortURL      string `json:"short_url"`
-	OriginalURL   string `json:"original_url"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CorrelationID string     `json:"correlation_id"`
+	ShortURL      string     `json:"short_url"`
+	OriginalURL   string     `json:"original_url"`
 }
 
 // BatchArray shorten type.
@@ -61,11 +72,157 @@ type DeleteURLs []string
 
 // Stats model.
 type Stats struct {
-	URLs  int `json:"urls"`
-	Users int `json:"users"`
+	URLs    int `json:"urls"`
+	Users   int `json:"users"`
+	Blocked int `json:"blocked"`
+}
+
+// Click model describes a single redirect event.
+type Click struct {
+	Time      time.Time `json:"time"`
+	Short     string    `json:"short"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+}
+
+// DayClicks model.
+type DayClicks struct {
+	Day    string `json:"day"`
+	Clicks int    `json:"clicks"`
+}
+
+// ClickStats model.
+type ClickStats struct {
+	Short string      `json:"short"`
+	Days  []DayClicks `json:"days"`
+	Total int         `json:"total"`
 }
 
 type key int
 
-// CtxUserIDKey context userID key.
-const CtxUserIDKey key = iota
+const (
+	// CtxUserIDKey context userID key.
+	CtxUserIDKey key = iota
+	// CtxAPIKeyIDKey context key of the API key that authenticated the request.
+	CtxAPIKeyIDKey
+	// CtxRequestIDKey context key of the request ID echoed in X-Request-ID and the log lines.
+	CtxRequestIDKey
+)
+
+// URLRecord represents a single stored URL record.
+type URLRecord struct {
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	UUID        string     `json:"uuid"`
+	OriginalURL string     `json:"original_url"`
+	ShortURL    string     `json:"short_url"`
+	UserID      string     `json:"user_id"`
+	Deleted     bool       `json:"is_deleted"`
+}
+
+// Expired reports whether the record has an expiration deadline that has passed.
+func (r URLRecord) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
+
+// ConflictPolicy tells the import what to do with a record whose short URL is already stored.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the stored record.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the stored record.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail aborts the import.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ImportResult model.
+type ImportResult struct {
+	Imported    int `json:"imported"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// Add sums up the results of two import batches.
+func (r ImportResult) Add(other ImportResult) ImportResult {
+	return ImportResult{
+		Imported:    r.Imported + other.Imported,
+		Overwritten: r.Overwritten + other.Overwritten,
+		Skipped:     r.Skipped + other.Skipped,
+	}
+}
+
+// APIKey model describes a named key of a programmatic client. Only the key hash is stored.
+type APIKey struct {
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	UserID    string     `json:"user_id"`
+	Hash      string     `json:"hash"`
+}
+
+// APIKeyRequest create API key request model.
+type APIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// APIKeyResponse API key response model. Key is only returned when the key is created.
+type APIKeyResponse struct {
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+}
+
+// Account model describes a registered user. Only the bcrypt hash of the password is stored.
+type Account struct {
+	CreatedAt    time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"password_hash"`
+}
+
+// CredentialsRequest register, login and claim request model.
+type CredentialsRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// SessionResponse register and login response model.
+type SessionResponse struct {
+	UserID string `json:"user_id"`
+	Token  string `json:"token"`
+}
+
+// ClaimResponse claim response model with the number of links moved to the account.
+type ClaimResponse struct {
+	UserID  string `json:"user_id"`
+	Token   string `json:"token"`
+	Claimed int    `json:"claimed"`
+}
+
+// AuditEntry model describes one action of a trusted subnet operator.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Action string    `json:"action"`
+	Target string    `json:"target"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// AdminURL admin response model with the owner and the metadata of a short link.
+type AdminURL struct {
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	UUID         string     `json:"uuid"`
+	ShortURL     string     `json:"short_url"`
+	OriginalURL  string     `json:"original_url"`
+	UserID       string     `json:"user_id"`
+	Deleted      bool       `json:"is_deleted"`
+	Expired      bool       `json:"is_expired"`
+	UserDisabled bool       `json:"user_disabled"`
+}
@@ -0,0 +1,125 @@
+// Package ratelimit throttles requests with token buckets kept per client key.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets refilled to the full burst are dropped.
+const sweepInterval = time.Minute
+
+// Limiter keeps a token bucket per key. A nil Limiter allows everything.
+type Limiter struct {
+	now       func() time.Time
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	rate      float64
+	burst     float64
+	mux       sync.Mutex
+}
+
+type bucket struct {
+	last   time.Time
+	tokens float64
+}
+
+// New returns the limiter refilling rate tokens per second up to burst. Non-positive rate disables limiting.
+func New(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+		rate:    rate,
+		burst:   math.Max(float64(burst), 1),
+	}
+}
+
+// Allow takes a token from the bucket of every key.
+//
+// If any bucket is empty nothing is taken and the time until all of them have a token is returned.
+func (l *Limiter) Allow(keys ...string) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	var wait float64
+	for _, key := range keys {
+		if tokens := l.refill(key, now).tokens; tokens < 1 {
+			wait = math.Max(wait, (1-tokens)/l.rate)
+		}
+	}
+	if wait > 0 {
+		return time.Duration(wait * float64(time.Second)), false
+	}
+	for _, key := range keys {
+		l.buckets[key].tokens--
+	}
+	return 0, true
+}
+
+// refill returns the bucket of the key topped up for the time passed. The caller must hold the lock.
+func (l *Limiter) refill(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+		return b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+	return b
+}
+
+// sweep drops the buckets that are full again, as a new bucket behaves the same. The caller must hold the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Limits groups the limiters of the throttled request kinds.
+type Limits struct {
+	// Save limits the single link saves.
+	Save *Limiter
+	// Batch limits the batch saves.
+	Batch *Limiter
+	// Redirect limits the short link lookups.
+	Redirect *Limiter
+	// Login limits the sign-up and login attempts.
+	Login *Limiter
+}
+
+// RetryAfter returns the Retry-After value in whole seconds, at least one.
+func RetryAfter(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
+
+// Keys returns the bucket keys of the client, one per user and one per IP address, skipping the unknown ones.
+//
+// Both are limited as a client without cookies gets a new user on every request.
+func Keys(userID, ip string) []string {
+	keys := make([]string, 0, 2)
+	if userID != "" {
+		keys = append(keys, "user:"+userID)
+	}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestLimiterAllow(t *testing.T) {
+	c := &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(2, 3)
+	l.now = c.Now
+
+	for i := 0; i < 3; i++ {
+		_, ok := l.Allow("a")
+		assert.True(t, ok, "burst request %d", i)
+	}
+	wait, ok := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// the other key has its own bucket
+	_, ok = l.Allow("b")
+	assert.True(t, ok)
+
+	c.now = c.now.Add(500 * time.Millisecond)
+	_, ok = l.Allow("a")
+	assert.True(t, ok)
+	_, ok = l.Allow("a")
+	assert.False(t, ok)
+
+	// refill never exceeds the burst
+	c.now = c.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		_, ok = l.Allow("a")
+		assert.True(t, ok)
+	}
+	_, ok = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiterAllowKeys(t *testing.T) {
+	c := &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(1, 2)
+	l.now = c.Now
+
+	// new users from one address share its bucket
+	_, ok := l.Allow(Keys("u1", "10.0.0.1")...)
+	assert.True(t, ok)
+	_, ok = l.Allow(Keys("u2", "10.0.0.1")...)
+	assert.True(t, ok)
+	wait, ok := l.Allow(Keys("u3", "10.0.0.1")...)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// a rejected call takes no tokens from the buckets with some left
+	_, ok = l.Allow(Keys("u3", "10.0.0.2")...)
+	assert.True(t, ok)
+	_, ok = l.Allow(Keys("u3", "10.0.0.2")...)
+	assert.True(t, ok)
+	_, ok = l.Allow(Keys("u3", "10.0.0.3")...)
+	assert.False(t, ok)
+}
+
+func TestLimiterSweep(t *testing.T) {
+	c := &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(1, 1)
+	l.now = c.Now
+	l.Allow("a")
+	l.Allow("b")
+	assert.Len(t, l.buckets, 2)
+
+	c.now = c.now.Add(sweepInterval)
+	l.Allow("c")
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestDisabled(t *testing.T) {
+	l := New(0, 10)
+	assert.Nil(t, l)
+	for i := 0; i < 100; i++ {
+		_, ok := l.Allow("a")
+		assert.True(t, ok)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		wait time.Duration
+		want int
+	}{
+		{name: "less than a second", wait: 10 * time.Millisecond, want: 1},
+		{name: "whole seconds", wait: 2 * time.Second, want: 2},
+		{name: "rounded up", wait: 2100 * time.Millisecond, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RetryAfter(tt.wait))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"shortener/internal/models"
+)
+
+const (
+	minLoginLen    = 3
+	maxLoginLen    = 64
+	loginChars     = "abcdefghijklmnopqrstuvwxyz0123456789-_.@"
+	minPasswordLen = 8
+	// maxPasswordLen is the bcrypt input limit, longer passwords are rejected rather than truncated.
+	maxPasswordLen = 72
+)
+
+var (
+	// ErrAccountNotFound error indicates there is no account with the login.
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrLoginTaken error indicates an account with the login already exists.
+	ErrLoginTaken = errors.New("login already taken")
+	// ErrInvalidLogin error indicates the login is too short, too long or has unsupported characters.
+	ErrInvalidLogin = errors.New("invalid login")
+	// ErrInvalidPassword error indicates the password is too short or too long.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrInvalidCredentials error indicates the login or the password does not match.
+	ErrInvalidCredentials = errors.New("invalid login or password")
+)
+
+// dummyHash is compared against when the login is unknown, so unknown logins take as long as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Register creates an account and returns a session token of it.
+func (s *Service) Register(ctx context.Context, creds models.CredentialsRequest) (models.SessionResponse, error) {
+	login := normalizeLogin(creds.Login)
+	if len(login) < minLoginLen || len(login) > maxLoginLen || strings.Trim(login, loginChars) != "" {
+		return models.SessionResponse{}, fmt.Errorf("login %q: %w", login, ErrInvalidLogin)
+	}
+	if len(creds.Password) < minPasswordLen || len(creds.Password) > maxPasswordLen {
+		return models.SessionResponse{}, ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.SessionResponse{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+	account := models.Account{
+		ID:           uuid.NewString(),
+		Login:        login,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err = s.Storage.CreateAccount(ctx, account); err != nil {
+		return models.SessionResponse{}, fmt.Errorf("failed to create account: %w", err)
+	}
+	return s.session(account.ID)
+}
+
+// Login checks the credentials and returns a new session token of the account.
+func (s *Service) Login(ctx context.Context, creds models.CredentialsRequest) (models.SessionResponse, error) {
+	account, err := s.authenticate(ctx, creds)
+	if err != nil {
+		return models.SessionResponse{}, err
+	}
+	return s.session(account.ID)
+}
+
+// Claim moves the links of the current user onto the account and returns a session token of the account.
+//
+// The current user is usually the anonymous one from the cookie, whose links would be lost with the cookie.
+func (s *Service) Claim(ctx context.Context, creds models.CredentialsRequest) (models.ClaimResponse, error) {
+	account, err := s.authenticate(ctx, creds)
+	if err != nil {
+		return models.ClaimResponse{}, err
+	}
+	claimed, err := s.Storage.ClaimURLs(ctx, account.ID)
+	if err != nil {
+		return models.ClaimResponse{}, fmt.Errorf("failed to claim urls: %w", err)
+	}
+	session, err := s.session(account.ID)
+	if err != nil {
+		return models.ClaimResponse{}, err
+	}
+	return models.ClaimResponse{UserID: session.UserID, Token: session.Token, Claimed: claimed}, nil
+}
+
+func (s *Service) authenticate(ctx context.Context, creds models.CredentialsRequest) (models.Account, error) {
+	account, err := s.Storage.GetAccount(ctx, normalizeLogin(creds.Login))
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(creds.Password))
+			return models.Account{}, ErrInvalidCredentials
+		}
+		return models.Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(creds.Password)); err != nil {
+		return models.Account{}, ErrInvalidCredentials
+	}
+	return account, nil
+}
+
+func (s *Service) session(userID string) (models.SessionResponse, error) {
+	token, err := s.buildToken(userID)
+	if err != nil {
+		return models.SessionResponse{}, err
+	}
+	return models.SessionResponse{UserID: userID, Token: token}, nil
+}
+
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
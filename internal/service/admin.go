@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shortener/internal/models"
+)
+
+// Audit log actions of the admin API.
+const (
+	AuditGetURL      = "get_url"
+	AuditDeleteURL   = "delete_url"
+	AuditRestoreURL  = "restore_url"
+	AuditUserURLs    = "list_user_urls"
+	AuditDisableUser = "disable_user"
+	AuditEnableUser  = "enable_user"
+)
+
+var (
+	// ErrUserDisabled error indicates the user was disabled by an operator.
+	ErrUserDisabled = errors.New("user disabled")
+	// ErrRestoreConflict error indicates a live link already uses the short or the long URL of the restored one.
+	ErrRestoreConflict = errors.New("restore conflicts with a live url")
+)
+
+// CheckUserEnabled returns ErrUserDisabled when the user was disabled by an operator.
+func (s *Service) CheckUserEnabled(ctx context.Context, userID string) error {
+	disabled, err := s.Storage.IsUserDisabled(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if disabled {
+		return ErrUserDisabled
+	}
+	return nil
+}
+
+// AdminGetURL returns the owner and the metadata of any short link, deleted ones included.
+func (s *Service) AdminGetURL(ctx context.Context, actor, short string) (models.AdminURL, error) {
+	res, err := s.adminGetURL(ctx, short)
+	s.audit(actor, AuditGetURL, short, err)
+	return res, err
+}
+
+// AdminDeleteURL marks the link deleted regardless of its owner.
+func (s *Service) AdminDeleteURL(ctx context.Context, actor, short string) error {
+	err := s.Storage.SetURLDeleted(ctx, short, true)
+	if err != nil && !errors.Is(err, ErrURLNotFound) {
+		err = fmt.Errorf("failed to delete url: %w", err)
+	}
+	s.audit(actor, AuditDeleteURL, short, err)
+	return err
+}
+
+// AdminRestoreURL brings back a deleted link that has not been cleaned up yet.
+func (s *Service) AdminRestoreURL(ctx context.Context, actor, short string) error {
+	err := s.Storage.SetURLDeleted(ctx, short, false)
+	if err != nil && !errors.Is(err, ErrURLNotFound) && !errors.Is(err, ErrRestoreConflict) {
+		err = fmt.Errorf("failed to restore url: %w", err)
+	}
+	s.audit(actor, AuditRestoreURL, short, err)
+	return err
+}
+
+// AdminUserURLs returns every link of the user, deleted ones included.
+func (s *Service) AdminUserURLs(ctx context.Context, actor, userID string) ([]models.AdminURL, error) {
+	res, err := s.adminUserURLs(ctx, userID)
+	s.audit(actor, AuditUserURLs, userID, err)
+	return res, err
+}
+
+// AdminSetUserDisabled disables or enables the user. Requests of a disabled user are rejected.
+func (s *Service) AdminSetUserDisabled(ctx context.Context, actor, userID string, disabled bool) error {
+	action := AuditEnableUser
+	if disabled {
+		action = AuditDisableUser
+	}
+	err := s.Storage.SetUserDisabled(ctx, userID, disabled)
+	if err != nil {
+		err = fmt.Errorf("failed to update user: %w", err)
+	}
+	s.audit(actor, action, userID, err)
+	return err
+}
+
+func (s *Service) adminGetURL(ctx context.Context, short string) (models.AdminURL, error) {
+	record, err := s.Storage.GetURLRecord(ctx, short)
+	if err != nil {
+		if errors.Is(err, ErrURLNotFound) {
+			return models.AdminURL{}, err
+		}
+		return models.AdminURL{}, fmt.Errorf("failed to get url: %w", err)
+	}
+	disabled, err := s.Storage.IsUserDisabled(ctx, record.UserID)
+	if err != nil {
+		return models.AdminURL{}, fmt.Errorf("failed to check user: %w", err)
+	}
+	return adminURL(record, time.Now(), disabled), nil
+}
+
+func (s *Service) adminUserURLs(ctx context.Context, userID string) ([]models.AdminURL, error) {
+	records, err := s.Storage.ListURLsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user urls: %w", err)
+	}
+	disabled, err := s.Storage.IsUserDisabled(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	now := time.Now()
+	res := make([]models.AdminURL, 0, len(records))
+	for _, record := range records {
+		res = append(res, adminURL(record, now, disabled))
+	}
+	return res, nil
+}
+
+// audit writes the action to the audit log or to the service log when no audit log is configured.
+func (s *Service) audit(actor, action, target string, err error) {
+	entry := models.AuditEntry{Time: time.Now().UTC(), Actor: actor, Action: action, Target: target}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if s.Audit == nil {
+		s.Log.Info("audit", "actor", entry.Actor, "action", entry.Action, "target", entry.Target, "error", entry.Error)
+		return
+	}
+	s.Audit.Record(entry)
+}
+
+func adminURL(record models.URLRecord, now time.Time, userDisabled bool) models.AdminURL {
+	return models.AdminURL{
+		UUID:         record.UUID,
+		ShortURL:     record.ShortURL,
+		OriginalURL:  record.OriginalURL,
+		UserID:       record.UserID,
+		ExpiresAt:    record.ExpiresAt,
+		Deleted:      record.Deleted,
+		Expired:      record.Expired(now),
+		UserDisabled: userDisabled,
+	}
+}
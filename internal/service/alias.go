@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	aliasMinLength = 3
+	aliasMaxLength = 32
+)
+
+// reservedAliases contains path prefixes already used by the router.
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"ping":    {},
+	"debug":   {},
+	"healthz": {},
+	"readyz":  {},
+}
+
+// validateAlias checks that the custom alias matches the charset and length policy
+// and does not shadow any of the reserved routes.
+func validateAlias(alias string) error {
+	if len(alias) < aliasMinLength || len(alias) > aliasMaxLength {
+		return ErrInvalidAlias
+	}
+	for _, r := range alias {
+		if !isAliasChar(r) {
+			return ErrInvalidAlias
+		}
+	}
+	if _, ok := reservedAliases[strings.ToLower(alias)]; ok {
+		return ErrInvalidAlias
+	}
+	return nil
+}
+
+func isAliasChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '-' || r == '_':
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrInvalidAlias error indicates the custom alias violates the alias policy.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrShortURLExists error indicates the short URL is already taken.
+	ErrShortURLExists = errors.New("short url already exists")
+)
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shortener/internal/models"
+)
+
+const (
+	// APIKeyPrefix starts every API key, so keys are told apart from JWT tokens.
+	APIKeyPrefix = "sk_"
+
+	apiKeyBytes     = 32
+	apiKeyShownLen  = 11
+	maxAPIKeyName   = 64
+	apiKeyNameChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_. "
+)
+
+var (
+	// ErrAPIKeyNotFound error indicates the API key does not exist or belongs to another user.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey error indicates the presented API key is unknown or revoked.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidAPIKeyName error indicates the API key name is empty, too long or has unsupported characters.
+	ErrInvalidAPIKeyName = errors.New("invalid api key name")
+)
+
+// IsAPIKey reports whether the token looks like an API key rather than a JWT token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// APIKeyFromHeaders returns the API key sent as X-API-Key or as an Authorization bearer token.
+//
+// A bearer token without the API key prefix is not an API key and is ignored.
+func APIKeyFromHeaders(apiKey, authorization string) string {
+	if apiKey != "" {
+		return apiKey
+	}
+	const bearer = "Bearer "
+	if len(authorization) > len(bearer) && strings.EqualFold(authorization[:len(bearer)], bearer) {
+		if token := strings.TrimSpace(authorization[len(bearer):]); IsAPIKey(token) {
+			return token
+		}
+	}
+	return ""
+}
+
+// HashAPIKey returns the hex SHA-256 of the key. Keys are random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey creates a named API key for the current user.
+//
+// The key is returned once, only its hash and a short prefix are stored.
+func (s *Service) CreateAPIKey(ctx context.Context, name string) (models.APIKeyResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyName || strings.Trim(name, apiKeyNameChars) != "" {
+		return models.APIKeyResponse{}, fmt.Errorf("name %q: %w", name, ErrInvalidAPIKeyName)
+	}
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return models.APIKeyResponse{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	key := models.APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Prefix:    secret[:apiKeyShownLen],
+		Hash:      HashAPIKey(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Storage.SaveAPIKey(ctx, key); err != nil {
+		return models.APIKeyResponse{}, fmt.Errorf("failed to save api key: %w", err)
+	}
+	resp := apiKeyResponse(key)
+	resp.Key = secret
+	return resp, nil
+}
+
+// ListAPIKeys returns the API keys of the current user including revoked ones.
+func (s *Service) ListAPIKeys(ctx context.Context) ([]models.APIKeyResponse, error) {
+	keys, err := s.Storage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	resp := make([]models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, apiKeyResponse(key))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey revokes an API key of the current user.
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) error {
+	if err := s.Storage.RevokeAPIKey(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// AuthenticateAPIKey returns the live API key matching the presented one.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, secret string) (models.APIKey, error) {
+	key, err := s.Storage.GetAPIKey(ctx, HashAPIKey(secret))
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return models.APIKey{}, ErrInvalidAPIKey
+		}
+		return models.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func apiKeyResponse(key models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
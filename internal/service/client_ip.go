@@ -0,0 +1,58 @@
+package service
+
+import (
+	"net"
+	"strings"
+)
+
+// ClientIP returns the address of the client behind the connection from remoteAddr.
+//
+// The realIP and forwardedFor header values are only taken when the peer is one of TrustedProxies
+// or is in the trusted subnet, as any other client can set them to look like someone else.
+// In X-Forwarded-For the nearest address that is not a trusted proxy is the client.
+func (s *Service) ClientIP(remoteAddr, realIP, forwardedFor string) string {
+	peer, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		peer = remoteAddr
+	}
+	if !s.isProxyTrusted(peer) {
+		return peer
+	}
+	if realIP = strings.TrimSpace(realIP); realIP != "" {
+		return realIP
+	}
+	if forwardedFor == "" {
+		return peer
+	}
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if i == 0 || !s.isProxyTrusted(hop) {
+			return hop
+		}
+	}
+	return peer
+}
+
+// isProxyTrusted reports whether the address is one of TrustedProxies or is in the trusted subnet.
+func (s *Service) isProxyTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if s.IsSubnetTrusted(addr) {
+		return true
+	}
+	for _, proxy := range s.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if ipNet, err := parseCIDR(proxy); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
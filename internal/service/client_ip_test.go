@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_ClientIP(t *testing.T) {
+	svc := &Service{TrustedSubnet: "10.0.0.0/8", TrustedProxies: []string{"192.168.1.1", "172.16.0.0/12"}}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		realIP       string
+		forwardedFor string
+		want         string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.5:4321", want: "203.0.113.5"},
+		{name: "spoofed real ip", remoteAddr: "203.0.113.5:4321", realIP: "198.51.100.1", want: "203.0.113.5"},
+		{
+			name:         "spoofed forwarded for",
+			remoteAddr:   "203.0.113.5:4321",
+			forwardedFor: "198.51.100.1",
+			want:         "203.0.113.5",
+		},
+		{name: "real ip from proxy", remoteAddr: "192.168.1.1:80", realIP: "198.51.100.1", want: "198.51.100.1"},
+		{name: "real ip from subnet", remoteAddr: "10.1.2.3:80", realIP: "198.51.100.1", want: "198.51.100.1"},
+		{
+			name:         "forwarded through proxies",
+			remoteAddr:   "172.16.0.2:80",
+			forwardedFor: "1.1.1.1, 198.51.100.1, 192.168.1.1",
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "forwarded by proxies only",
+			remoteAddr:   "172.16.0.2:80",
+			forwardedFor: "10.0.0.7, 192.168.1.1",
+			want:         "10.0.0.7",
+		},
+		{name: "proxy without headers", remoteAddr: "192.168.1.1:80", want: "192.168.1.1"},
+		{name: "address without port", remoteAddr: "203.0.113.5", realIP: "198.51.100.1", want: "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, svc.ClientIP(tt.remoteAddr, tt.realIP, tt.forwardedFor))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// maxTTL is the longest TTL in seconds, a longer one overflows time.Duration.
+const maxTTL = math.MaxInt64 / int64(time.Second)
+
+// resolveExpiry converts the optional absolute expiry or TTL (in seconds) into the link deadline.
+//
+// It returns nil when the link never expires.
+func resolveExpiry(expiresAt *time.Time, ttl int64) (*time.Time, error) {
+	switch {
+	case expiresAt != nil && ttl != 0:
+		return nil, ErrInvalidExpiry
+	case ttl < 0, ttl > maxTTL:
+		return nil, ErrInvalidExpiry
+	case ttl > 0:
+		deadline := time.Now().Add(time.Duration(ttl) * time.Second).UTC()
+		return &deadline, nil
+	case expiresAt != nil:
+		if !expiresAt.After(time.Now()) {
+			return nil, ErrInvalidExpiry
+		}
+		deadline := expiresAt.UTC()
+		return &deadline, nil
+	}
+	return nil, nil
+}
+
+// ErrInvalidExpiry error indicates the requested expiration is malformed or already in the past.
+var ErrInvalidExpiry = errors.New("invalid expiration")
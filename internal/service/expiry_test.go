@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveExpiry(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		ttl       int64
+		wantAfter time.Duration
+		wantNil   bool
+		wantErr   error
+	}{
+		{name: "never expires", wantNil: true},
+		{name: "ttl", ttl: 3600, wantAfter: 59 * time.Minute},
+		{name: "longest ttl", ttl: maxTTL, wantAfter: 290 * 365 * 24 * time.Hour},
+		{name: "expires at", expiresAt: &future, wantAfter: 59 * time.Minute},
+		{name: "negative ttl", ttl: -1, wantErr: ErrInvalidExpiry},
+		{name: "overflowing ttl", ttl: maxTTL + 1, wantErr: ErrInvalidExpiry},
+		{name: "huge ttl", ttl: 10_000_000_000, wantErr: ErrInvalidExpiry},
+		{name: "both set", expiresAt: &future, ttl: 60, wantErr: ErrInvalidExpiry},
+		{name: "in the past", expiresAt: &past, wantErr: ErrInvalidExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveExpiry(tt.expiresAt, tt.ttl)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Greater(t, time.Until(*got), tt.wantAfter)
+		})
+	}
+}
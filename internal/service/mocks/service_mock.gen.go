@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 	models "shortener/internal/models"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -55,6 +56,21 @@ func (mr *MockURLStorageMockRecorder) BatchSave(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchSave", reflect.TypeOf((*MockURLStorage)(nil).BatchSave), ctx, input)
 }
 
+// ClaimURLs mocks base method.
+func (m *MockURLStorage) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimURLs", ctx, accountID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimURLs indicates an expected call of ClaimURLs.
+func (mr *MockURLStorageMockRecorder) ClaimURLs(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimURLs", reflect.TypeOf((*MockURLStorage)(nil).ClaimURLs), ctx, accountID)
+}
+
 // Cleanup mocks base method.
 func (m *MockURLStorage) Cleanup(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +86,21 @@ func (mr *MockURLStorageMockRecorder) Cleanup(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cleanup", reflect.TypeOf((*MockURLStorage)(nil).Cleanup), ctx)
 }
 
+// ClickStats mocks base method.
+func (m *MockURLStorage) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClickStats", ctx, shortLink)
+	ret0, _ := ret[0].(models.ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClickStats indicates an expected call of ClickStats.
+func (mr *MockURLStorageMockRecorder) ClickStats(ctx, shortLink any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClickStats", reflect.TypeOf((*MockURLStorage)(nil).ClickStats), ctx, shortLink)
+}
+
 // Close mocks base method.
 func (m *MockURLStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -84,6 +115,20 @@ func (mr *MockURLStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockURLStorage)(nil).Close))
 }
 
+// CreateAccount mocks base method.
+func (m *MockURLStorage) CreateAccount(ctx context.Context, user models.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockURLStorageMockRecorder) CreateAccount(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockURLStorage)(nil).CreateAccount), ctx, user)
+}
+
 // DeleteURLs mocks base method.
 func (m *MockURLStorage) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
 	m.ctrl.T.Helper()
@@ -98,6 +143,20 @@ func (mr *MockURLStorageMockRecorder) DeleteURLs(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURLs", reflect.TypeOf((*MockURLStorage)(nil).DeleteURLs), ctx, input)
 }
 
+// ExportURLs mocks base method.
+func (m *MockURLStorage) ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportURLs", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportURLs indicates an expected call of ExportURLs.
+func (mr *MockURLStorageMockRecorder) ExportURLs(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportURLs", reflect.TypeOf((*MockURLStorage)(nil).ExportURLs), ctx, fn)
+}
+
 // Get mocks base method.
 func (m *MockURLStorage) Get(ctx context.Context, shortLink string) (string, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +172,36 @@ func (mr *MockURLStorageMockRecorder) Get(ctx, shortLink any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockURLStorage)(nil).Get), ctx, shortLink)
 }
 
+// GetAPIKey mocks base method.
+func (m *MockURLStorage) GetAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, hash)
+	ret0, _ := ret[0].(models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockURLStorageMockRecorder) GetAPIKey(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockURLStorage)(nil).GetAPIKey), ctx, hash)
+}
+
+// GetAccount mocks base method.
+func (m *MockURLStorage) GetAccount(ctx context.Context, login string) (models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, login)
+	ret0, _ := ret[0].(models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockURLStorageMockRecorder) GetAccount(ctx, login any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockURLStorage)(nil).GetAccount), ctx, login)
+}
+
 // GetByUserID mocks base method.
 func (m *MockURLStorage) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
 	m.ctrl.T.Helper()
@@ -128,6 +217,111 @@ func (mr *MockURLStorageMockRecorder) GetByUserID(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockURLStorage)(nil).GetByUserID), ctx)
 }
 
+// GetByUserIDPage mocks base method.
+func (m *MockURLStorage) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserIDPage", ctx, after, limit)
+	ret0, _ := ret[0].([]models.BaseRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserIDPage indicates an expected call of GetByUserIDPage.
+func (mr *MockURLStorageMockRecorder) GetByUserIDPage(ctx, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDPage", reflect.TypeOf((*MockURLStorage)(nil).GetByUserIDPage), ctx, after, limit)
+}
+
+// GetURLRecord mocks base method.
+func (m *MockURLStorage) GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLRecord", ctx, shortLink)
+	ret0, _ := ret[0].(models.URLRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLRecord indicates an expected call of GetURLRecord.
+func (mr *MockURLStorageMockRecorder) GetURLRecord(ctx, shortLink any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLRecord", reflect.TypeOf((*MockURLStorage)(nil).GetURLRecord), ctx, shortLink)
+}
+
+// ImportURLs mocks base method.
+func (m *MockURLStorage) ImportURLs(ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy) (models.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURLs", ctx, records, policy)
+	ret0, _ := ret[0].(models.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURLs indicates an expected call of ImportURLs.
+func (mr *MockURLStorageMockRecorder) ImportURLs(ctx, records, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURLs", reflect.TypeOf((*MockURLStorage)(nil).ImportURLs), ctx, records, policy)
+}
+
+// IsUserDisabled mocks base method.
+func (m *MockURLStorage) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserDisabled", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserDisabled indicates an expected call of IsUserDisabled.
+func (mr *MockURLStorageMockRecorder) IsUserDisabled(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserDisabled", reflect.TypeOf((*MockURLStorage)(nil).IsUserDisabled), ctx, userID)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockURLStorage) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockURLStorageMockRecorder) ListAPIKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockURLStorage)(nil).ListAPIKeys), ctx)
+}
+
+// ListURLsByUser mocks base method.
+func (m *MockURLStorage) ListURLsByUser(ctx context.Context, userID string) ([]models.URLRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLsByUser", ctx, userID)
+	ret0, _ := ret[0].([]models.URLRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLsByUser indicates an expected call of ListURLsByUser.
+func (mr *MockURLStorageMockRecorder) ListURLsByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLsByUser", reflect.TypeOf((*MockURLStorage)(nil).ListURLsByUser), ctx, userID)
+}
+
+// NextSequence mocks base method.
+func (m *MockURLStorage) NextSequence(ctx context.Context) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextSequence", ctx)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextSequence indicates an expected call of NextSequence.
+func (mr *MockURLStorageMockRecorder) NextSequence(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSequence", reflect.TypeOf((*MockURLStorage)(nil).NextSequence), ctx)
+}
+
 // Ping mocks base method.
 func (m *MockURLStorage) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -142,18 +336,60 @@ func (mr *MockURLStorageMockRecorder) Ping(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockURLStorage)(nil).Ping), ctx)
 }
 
+// RevokeAPIKey mocks base method.
+func (m *MockURLStorage) RevokeAPIKey(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockURLStorageMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockURLStorage)(nil).RevokeAPIKey), ctx, id)
+}
+
 // Save mocks base method.
-func (m *MockURLStorage) Save(ctx context.Context, shortLink, longLink string) error {
+func (m *MockURLStorage) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Save", ctx, shortLink, longLink)
+	ret := m.ctrl.Call(m, "Save", ctx, shortLink, longLink, expiresAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Save indicates an expected call of Save.
-func (mr *MockURLStorageMockRecorder) Save(ctx, shortLink, longLink any) *gomock.Call {
+func (mr *MockURLStorageMockRecorder) Save(ctx, shortLink, longLink, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockURLStorage)(nil).Save), ctx, shortLink, longLink, expiresAt)
+}
+
+// SaveAPIKey mocks base method.
+func (m *MockURLStorage) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAPIKey indicates an expected call of SaveAPIKey.
+func (mr *MockURLStorageMockRecorder) SaveAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIKey", reflect.TypeOf((*MockURLStorage)(nil).SaveAPIKey), ctx, key)
+}
+
+// SaveClicks mocks base method.
+func (m *MockURLStorage) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveClicks", ctx, clicks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveClicks indicates an expected call of SaveClicks.
+func (mr *MockURLStorageMockRecorder) SaveClicks(ctx, clicks any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockURLStorage)(nil).Save), ctx, shortLink, longLink)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveClicks", reflect.TypeOf((*MockURLStorage)(nil).SaveClicks), ctx, clicks)
 }
 
 // ServiceStats mocks base method.
@@ -170,3 +406,139 @@ func (mr *MockURLStorageMockRecorder) ServiceStats(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServiceStats", reflect.TypeOf((*MockURLStorage)(nil).ServiceStats), ctx)
 }
+
+// SetURLDeleted mocks base method.
+func (m *MockURLStorage) SetURLDeleted(ctx context.Context, shortLink string, deleted bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLDeleted", ctx, shortLink, deleted)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetURLDeleted indicates an expected call of SetURLDeleted.
+func (mr *MockURLStorageMockRecorder) SetURLDeleted(ctx, shortLink, deleted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLDeleted", reflect.TypeOf((*MockURLStorage)(nil).SetURLDeleted), ctx, shortLink, deleted)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockURLStorage) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockURLStorageMockRecorder) SetUserDisabled(ctx, userID, disabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockURLStorage)(nil).SetUserDisabled), ctx, userID, disabled)
+}
+
+// MockAuditRecorder is a mock of AuditRecorder interface.
+type MockAuditRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRecorderMockRecorder
+}
+
+// MockAuditRecorderMockRecorder is the mock recorder for MockAuditRecorder.
+type MockAuditRecorderMockRecorder struct {
+	mock *MockAuditRecorder
+}
+
+// NewMockAuditRecorder creates a new mock instance.
+func NewMockAuditRecorder(ctrl *gomock.Controller) *MockAuditRecorder {
+	mock := &MockAuditRecorder{ctrl: ctrl}
+	mock.recorder = &MockAuditRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRecorder) EXPECT() *MockAuditRecorderMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockAuditRecorder) Record(entry models.AuditEntry) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", entry)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditRecorderMockRecorder) Record(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditRecorder)(nil).Record), entry)
+}
+
+// MockBlocklist is a mock of Blocklist interface.
+type MockBlocklist struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlocklistMockRecorder
+}
+
+// MockBlocklistMockRecorder is the mock recorder for MockBlocklist.
+type MockBlocklistMockRecorder struct {
+	mock *MockBlocklist
+}
+
+// NewMockBlocklist creates a new mock instance.
+func NewMockBlocklist(ctrl *gomock.Controller) *MockBlocklist {
+	mock := &MockBlocklist{ctrl: ctrl}
+	mock.recorder = &MockBlocklistMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlocklist) EXPECT() *MockBlocklistMockRecorder {
+	return m.recorder
+}
+
+// Blocked mocks base method.
+func (m *MockBlocklist) Blocked(url string) (string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Blocked", url)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Blocked indicates an expected call of Blocked.
+func (mr *MockBlocklistMockRecorder) Blocked(url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blocked", reflect.TypeOf((*MockBlocklist)(nil).Blocked), url)
+}
+
+// MockClickRecorder is a mock of ClickRecorder interface.
+type MockClickRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockClickRecorderMockRecorder
+}
+
+// MockClickRecorderMockRecorder is the mock recorder for MockClickRecorder.
+type MockClickRecorderMockRecorder struct {
+	mock *MockClickRecorder
+}
+
+// NewMockClickRecorder creates a new mock instance.
+func NewMockClickRecorder(ctrl *gomock.Controller) *MockClickRecorder {
+	mock := &MockClickRecorder{ctrl: ctrl}
+	mock.recorder = &MockClickRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickRecorder) EXPECT() *MockClickRecorderMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *MockClickRecorder) Record(click models.Click) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", click)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockClickRecorderMockRecorder) Record(click any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockClickRecorder)(nil).Record), click)
+}
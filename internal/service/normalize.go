@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultPorts are dropped from the canonical form as they do not change the destination.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// normalizeURL validates the destination URL and returns its canonical form.
+//
+// Only absolute http and https URLs with a host are accepted. The canonical form has
+// the scheme and the host in lower case, no default port, no fragment, the query
+// parameters sorted by name as sent and "/" instead of an empty path, so the variants
+// of the same URL share one short link.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("empty url: %w", ErrInvalidURL)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return "", fmt.Errorf("scheme %q: %w", u.Scheme, ErrInvalidURL)
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return "", fmt.Errorf("url %q has no host: %w", raw, ErrInvalidURL)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", fmt.Errorf("url %q has no host: %w", raw, ErrInvalidURL)
+	}
+	if strings.Contains(host, ":") {
+		if net.ParseIP(host) == nil {
+			return "", fmt.Errorf("host %q: %w", host, ErrInvalidURL)
+		}
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("port %q: %w", port, ErrInvalidURL)
+		}
+		if port = strconv.Itoa(n); port != defaultPorts[u.Scheme] {
+			host += ":" + port
+		}
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	u.RawQuery = sortQuery(u.RawQuery)
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+// sortQuery sorts the "&" separated parameters of the raw query by name keeping the order of the values
+// of one name. The parameters are not decoded, so ";", bare names and the escaping of signed values stay as sent.
+func sortQuery(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	params = slices.DeleteFunc(params, func(param string) bool { return param == "" })
+	name := func(param string) string {
+		name, _, _ := strings.Cut(param, "=")
+		return name
+	}
+	slices.SortStableFunc(params, func(a, b string) int {
+		return strings.Compare(name(a), name(b))
+	})
+	return strings.Join(params, "&")
+}
+
+// ErrInvalidURL error indicates the destination is not an absolute http or https URL.
+var ErrInvalidURL = errors.New("invalid url")
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "canonical", raw: "https://example.com/path?a=1", want: "https://example.com/path?a=1"},
+		{name: "empty path", raw: "https://example.com", want: "https://example.com/"},
+		{name: "surrounding spaces", raw: "  https://example.com/a \n", want: "https://example.com/a"},
+		{name: "scheme and host case", raw: "HTTP://WWW.Example.COM/Path", want: "http://www.example.com/Path"},
+		{name: "default http port", raw: "http://example.com:80/", want: "http://example.com/"},
+		{name: "default https port", raw: "https://example.com:443", want: "https://example.com/"},
+		{name: "custom port", raw: "https://example.com:8443/", want: "https://example.com:8443/"},
+		{name: "port with leading zero", raw: "http://example.com:080/", want: "http://example.com/"},
+		{name: "fragment", raw: "https://example.com/a#section", want: "https://example.com/a"},
+		{name: "query order", raw: "https://example.com/?b=2&a=1&b=1", want: "https://example.com/?a=1&b=2&b=1"},
+		{name: "empty query", raw: "https://example.com/a?", want: "https://example.com/a"},
+		{name: "empty parameters", raw: "https://example.com/?b=2&&a=1&", want: "https://example.com/?a=1&b=2"},
+		{name: "semicolon", raw: "https://example.com/?b=2;c=3&a=1", want: "https://example.com/?a=1&b=2;c=3"},
+		{name: "bare name", raw: "https://example.com/?flag&a=1", want: "https://example.com/?a=1&flag"},
+		{
+			name: "signed query",
+			raw:  "https://cdn.example.com/f?X-Sig=ab%2Bc%2F%3D&X-Expires=1700000000&name=a+b%20c",
+			want: "https://cdn.example.com/f?X-Expires=1700000000&X-Sig=ab%2Bc%2F%3D&name=a+b%20c",
+		},
+		{name: "invalid escape kept", raw: "https://example.com/?a=%zz", want: "https://example.com/?a=%zz"},
+		{name: "trailing dot", raw: "https://example.com./a", want: "https://example.com/a"},
+		{name: "ipv6 host", raw: "http://[2001:DB8::1]:80/", want: "http://[2001:db8::1]/"},
+		{name: "trailing slash kept", raw: "https://example.com/a/", want: "https://example.com/a/"},
+		{name: "empty", raw: "", wantErr: true},
+		{name: "relative path", raw: "/relative/path", wantErr: true},
+		{name: "no scheme", raw: "example.com", wantErr: true},
+		{name: "scheme relative", raw: "//example.com/a", wantErr: true},
+		{name: "ftp", raw: "ftp://example.com/file", wantErr: true},
+		{name: "javascript", raw: "javascript:alert(1)", wantErr: true},
+		{name: "no host", raw: "https:///path", wantErr: true},
+		{name: "opaque", raw: "https:example.com", wantErr: true},
+		{name: "space in host", raw: "https://exa mple.com/", wantErr: true},
+		{name: "bad port", raw: "https://example.com:99999/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeURL(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidURL)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import "github.com/google/uuid"
+
+// maxRequestIDLength bounds the request ID taken from the client.
+const maxRequestIDLength = 128
+
+// RequestID returns the client's request ID if it is safe to log and echo, otherwise a new random one.
+func RequestID(fromClient string) string {
+	if validRequestID(fromClient) {
+		return fromClient
+	}
+	return uuid.NewString()
+}
+
+// validRequestID reports whether the ID is non-empty, bounded and made of visible ASCII only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
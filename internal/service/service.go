@@ -5,40 +5,89 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"net"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 
+	"shortener/internal/health"
 	"shortener/internal/logger"
 	"shortener/internal/models"
+	"shortener/internal/ratelimit"
+	"shortener/internal/shortcode"
 )
 
+// maxGenerateAttempts limits retries when a generated short link is already taken.
+const maxGenerateAttempts = 5
+
 // URLStorage contains contracts for communicate with storage.
 type URLStorage interface {
 	Close() error
 	Ping(ctx context.Context) error
 	Get(ctx context.Context, shortLink string) (string, error)
-	Save(ctx context.Context, shortLink, longLink string) error
+	Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error
 	BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error)
 	GetByUserID(ctx context.Context) ([]models.BaseRow, error)
+	GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error)
 	DeleteURLs(ctx context.Context, input models.DeleteURLs) error
 	Cleanup(ctx context.Context) ([]string, error)
 	ServiceStats(ctx context.Context) (models.Stats, error)
+	SaveClicks(ctx context.Context, clicks []models.Click) error
+	ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error)
+	NextSequence(ctx context.Context) (uint64, error)
+	ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error
+	ImportURLs(ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy) (models.ImportResult, error)
+	SaveAPIKey(ctx context.Context, key models.APIKey) error
+	GetAPIKey(ctx context.Context, hash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	CreateAccount(ctx context.Context, user models.Account) error
+	GetAccount(ctx context.Context, login string) (models.Account, error)
+	ClaimURLs(ctx context.Context, accountID string) (int, error)
+	GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error)
+	SetURLDeleted(ctx context.Context, shortLink string, deleted bool) error
+	ListURLsByUser(ctx context.Context, userID string) ([]models.URLRecord, error)
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) error
+	IsUserDisabled(ctx context.Context, userID string) (bool, error)
+}
+
+// AuditRecorder contains contract for writing operator actions to the audit log.
+type AuditRecorder interface {
+	Record(entry models.AuditEntry)
+}
+
+// Blocklist contains contract for checking destinations against the blocked ones.
+type Blocklist interface {
+	Blocked(url string) (rule string, ok bool)
+}
+
+// ClickRecorder contains contract for collecting redirect events.
+type ClickRecorder interface {
+	Record(click models.Click)
 }
 
 // Service represents the main service structure for the URL shortener.
 type Service struct {
 	Log             *logger.Log
 	Storage         URLStorage
+	Clicks          ClickRecorder
+	Audit           AuditRecorder
+	Blocklist       Blocklist
+	Health          *health.Checker
+	RateLimits      ratelimit.Limits
+	Codes           shortcode.Generator
 	FileStoragePath string
 	BaseURL         string
 	DatabaseDSN     string
 	SecretKey       string
 	TrustedSubnet   string
+	// TrustedProxies are the addresses and subnets of the proxies whose client address headers are honoured.
+	TrustedProxies []string
+
+	blocked atomic.Int64
 }
 
 // Claims represents the claims for a JWT token.
@@ -48,29 +97,77 @@ type Claims struct {
 }
 
 // SaveURL saves a long URL and returns a shortened URL.
-func (s *Service) SaveURL(ctx context.Context, long string) (string, error) {
-	short := s.generateUniqueShortLink(ctx)
-	if err := s.Storage.Save(ctx, short, long); err != nil {
-		return short, fmt.Errorf("failed save URL: %w", err)
+//
+// The long URL is validated and stored in its canonical form, see normalizeURL.
+// If the request carries a custom alias it is validated and used as the short URL
+// instead of a generated one. An optional expires_at or ttl limits the link lifetime.
+// A generated short URL that turns out to be taken is replaced and saved again.
+func (s *Service) SaveURL(ctx context.Context, req models.ShortenRequest) (string, error) {
+	long, err := normalizeURL(req.URL)
+	if err != nil {
+		return "", err
+	}
+	if err = s.checkBlocked(long); err != nil {
+		return "", err
+	}
+	expiresAt, err := resolveExpiry(req.ExpiresAt, req.TTL)
+	if err != nil {
+		return "", err
+	}
+	for attempt := 1; ; attempt++ {
+		short, err := s.shortFor(ctx, req.Alias)
+		if err != nil {
+			return "", err
+		}
+		err = s.Storage.Save(ctx, short, long, expiresAt)
+		if errors.Is(err, ErrShortURLExists) && req.Alias == "" && attempt < maxGenerateAttempts {
+			continue
+		}
+		if err != nil {
+			return short, fmt.Errorf("failed save URL: %w", err)
+		}
+		return short, nil
 	}
-	return short, nil
 }
 
 // GetURL retrieves a long URL by its short URL.
+//
+// Links saved before their destination got blocked are refused with ErrURLBlocked.
 func (s *Service) GetURL(ctx context.Context, short string) (string, error) {
 	long, err := s.Storage.Get(ctx, short)
 	if err != nil {
 		return "", fmt.Errorf("not found long URL by passed short URL: %w", err)
 	}
+	if err = s.checkBlocked(long); err != nil {
+		return "", fmt.Errorf("short URL %q: %w", short, err)
+	}
 	return long, nil
 }
 
 // SaveURLs saves multiple URLs in batch and returns the corresponding short URLs.
+//
+// When a short URL of the batch is taken the generated ones are replaced and the batch
+// is saved again, so a taken alias fails the batch after the last attempt.
+// Items repeating the canonical URL of an earlier item get its short URL.
 func (s *Service) SaveURLs(ctx context.Context, input []models.BatchRequest) (models.BatchResponseArray, error) {
-	processed := s.convertData(ctx, input)
-	saved, err := s.Storage.BatchSave(ctx, processed)
-	if err != nil {
-		return nil, fmt.Errorf("failed to batch save urls: %w", err)
+	var (
+		saved   models.BatchArray
+		repeats map[string][]string
+	)
+	for attempt := 1; ; attempt++ {
+		processed, batchRepeats, err := s.convertData(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		repeats = batchRepeats
+		saved, err = s.Storage.BatchSave(ctx, processed)
+		if errors.Is(err, ErrShortURLExists) && hasGenerated(input) && attempt < maxGenerateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch save urls: %w", err)
+		}
+		break
 	}
 	resp := make(models.BatchResponseArray, 0)
 	for _, svd := range saved {
@@ -78,6 +175,9 @@ func (s *Service) SaveURLs(ctx context.Context, input []models.BatchRequest) (mo
 			CorrelationID: svd.CorrelationID,
 			ShortURL:      svd.ShortURL,
 		})
+		for _, id := range repeats[svd.CorrelationID] {
+			resp = append(resp, models.BatchResponse{CorrelationID: id, ShortURL: svd.ShortURL})
+		}
 	}
 
 	return resp, nil
@@ -93,45 +193,128 @@ func (s *Service) DeleteURLs(ctx context.Context, input models.DeleteURLs) error
 	return nil
 }
 
+// RecordClick passes the redirect event to the click recorder if analytics is enabled.
+func (s *Service) RecordClick(click models.Click) {
+	if s.Clicks == nil {
+		return
+	}
+	s.Clicks.Record(click)
+}
+
+// GetClickStats returns click totals and the per-day histogram for a link owned by the current user.
+func (s *Service) GetClickStats(ctx context.Context, short string) (models.ClickStats, error) {
+	stats, err := s.Storage.ClickStats(ctx, short)
+	if err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to get click stats: %w", err)
+	}
+	return stats, nil
+}
+
 // GetStats gets statistics of saved urls and users.
 func (s *Service) GetStats(ctx context.Context) (models.Stats, error) {
 	res, err := s.Storage.ServiceStats(ctx)
 	if err != nil {
 		return models.Stats{}, fmt.Errorf("failed to get stats: %w", err)
 	}
+	res.Blocked = int(s.blocked.Load())
 
 	return res, nil
 }
 
-func (s *Service) generateUniqueShortLink(ctx context.Context) string {
-	const length = 8
-	var uniqString string
+// codes returns the configured short link generator falling back to crypto-random codes.
+func (s *Service) codes() shortcode.Generator {
+	if s.Codes == nil {
+		return shortcode.NewRandom(shortcode.DefaultLength)
+	}
+	return s.Codes
+}
 
-	// check if the string is unique
-	for {
-		uniqStringCandidate := generateRandomString(length)
-		_, err := s.Storage.Get(ctx, uniqStringCandidate)
+// shortFor returns the validated alias or a generated short link when the alias is empty.
+func (s *Service) shortFor(ctx context.Context, alias string) (string, error) {
+	if alias == "" {
+		short, err := s.codes().Generate(ctx)
 		if err != nil {
-			if errors.Is(err, ErrURLNotFound) {
-				uniqString = uniqStringCandidate
-				break
-			}
+			return "", fmt.Errorf("failed to generate short link: %w", err)
 		}
+		return short, nil
 	}
-	return uniqString
+	if err := validateAlias(alias); err != nil {
+		return "", fmt.Errorf("alias %q: %w", alias, err)
+	}
+	return alias, nil
 }
 
-func (s *Service) convertData(ctx context.Context, input []models.BatchRequest) models.BatchArray {
+func (s *Service) convertData(
+	ctx context.Context, input []models.BatchRequest,
+) (models.BatchArray, map[string][]string, error) {
 	res := make(models.BatchArray, 0)
+	aliases := make(map[string]struct{})
+	// firsts maps the canonical URLs to the correlation ids of the items saving them,
+	// repeats maps those ids to the ones of the items repeating the URL
+	firsts := make(map[string]string)
+	repeats := make(map[string][]string)
 	for _, item := range input {
-		short := s.generateUniqueShortLink(ctx)
+		if item.Alias != "" {
+			if _, ok := aliases[item.Alias]; ok {
+				return nil, nil, fmt.Errorf("alias %q used twice in batch: %w", item.Alias, ErrShortURLExists)
+			}
+			aliases[item.Alias] = struct{}{}
+		}
+		long, err := normalizeURL(item.OriginalURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("correlation id %q: %w", item.CorrelationID, err)
+		}
+		if first, ok := firsts[long]; ok {
+			if item.Alias != "" {
+				return nil, nil, fmt.Errorf("correlation id %q: %w", item.CorrelationID, ErrDuplicateURL)
+			}
+			repeats[first] = append(repeats[first], item.CorrelationID)
+			continue
+		}
+		if err = s.checkBlocked(long); err != nil {
+			return nil, nil, fmt.Errorf("correlation id %q: %w", item.CorrelationID, err)
+		}
+		expiresAt, err := resolveExpiry(item.ExpiresAt, item.TTL)
+		if err != nil {
+			return nil, nil, err
+		}
+		short, err := s.shortFor(ctx, item.Alias)
+		if err != nil {
+			return nil, nil, err
+		}
+		firsts[long] = item.CorrelationID
 		res = append(res, models.Batch{
 			CorrelationID: item.CorrelationID,
-			OriginalURL:   item.OriginalURL,
+			OriginalURL:   long,
 			ShortURL:      short,
+			ExpiresAt:     expiresAt,
 		})
 	}
-	return res
+	return res, repeats, nil
+}
+
+// checkBlocked returns ErrURLBlocked and counts the attempt if the destination is on the blocklist.
+func (s *Service) checkBlocked(long string) error {
+	if s.Blocklist == nil {
+		return nil
+	}
+	rule, ok := s.Blocklist.Blocked(long)
+	if !ok {
+		return nil
+	}
+	s.blocked.Add(1)
+	s.Log.Info("blocked destination", "url", long, "rule", rule)
+	return fmt.Errorf("%q matches %q: %w", long, rule, ErrURLBlocked)
+}
+
+// hasGenerated reports whether any item of the batch gets a generated short link.
+func hasGenerated(input []models.BatchRequest) bool {
+	for _, item := range input {
+		if item.Alias == "" {
+			return true
+		}
+	}
+	return false
 }
 
 // GetUserURLs retrieves all URLs associated with a user.
@@ -151,6 +334,37 @@ func (s *Service) GetUserURLs(ctx context.Context) (models.UserURLs, error) {
 	return userURLs, nil
 }
 
+// UserURLPages passes the URLs of the user to fn by pages of up to pageSize, ordered by the short link.
+//
+// Each page is a separate storage query, so a long listing does not hold the whole set in memory.
+func (s *Service) UserURLPages(ctx context.Context, pageSize int, fn func(models.UserURLs) error) error {
+	var after string
+	for {
+		data, err := s.Storage.GetByUserIDPage(ctx, after, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed get urls page by userID: %w", err)
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		page := make(models.UserURLs, 0, len(data))
+		for _, item := range data {
+			short, err := url.JoinPath(s.BaseURL, "/", item.Short)
+			if err != nil {
+				return fmt.Errorf("failed join url for short: %w", err)
+			}
+			page = append(page, models.URL{ShortURL: short, OriginalURL: item.Long})
+		}
+		if err = fn(page); err != nil {
+			return err
+		}
+		if len(data) < pageSize {
+			return nil
+		}
+		after = data[len(data)-1].Short
+	}
+}
+
 // IsSubnetTrusted method checks if the IP allowed.
 func (s *Service) IsSubnetTrusted(realIP string) bool {
 	ipNet, err := parseCIDR(s.TrustedSubnet)
@@ -169,12 +383,17 @@ func (s *Service) IsSubnetTrusted(realIP string) bool {
 }
 
 func (s *Service) BuildJWTString() (string, error) {
+	return s.buildToken(uuid.NewString())
+}
+
+// buildToken signs a token of the user. Anonymous users and accounts share the same token format.
+func (s *Service) buildToken(userID string) (string, error) {
 	const tokenExp = time.Hour * 720
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExp)),
 		},
-		UserID: uuid.NewString(),
+		UserID: userID,
 	})
 	tokenString, err := token.SignedString([]byte(s.SecretKey))
 	if err != nil {
@@ -203,16 +422,6 @@ func (s *Service) GetUserID(tokenString, secretKey string, log *logger.Log) stri
 	return claims.UserID
 }
 
-func generateRandomString(length int) string {
-	charset := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	randomString := make([]byte, length)
-	// generate a random string
-	for i := range randomString {
-		randomString[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(randomString)
-}
-
 func parseCIDR(cidr string) (*net.IPNet, error) {
 	_, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
@@ -223,3 +432,9 @@ func parseCIDR(cidr string) (*net.IPNet, error) {
 
 // ErrURLNotFound error indicates item was not found.
 var ErrURLNotFound = errors.New("url not found")
+
+// ErrDuplicateURL error indicates a batch item with an alias repeats the canonical URL of an earlier item.
+var ErrDuplicateURL = errors.New("url repeated in batch")
+
+// ErrURLBlocked error indicates the destination matches the blocklist.
+var ErrURLBlocked = errors.New("url is blocked")
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"shortener/internal/models"
+	"shortener/internal/transfer"
+)
+
+// ErrImportConflict error indicates an imported record is already stored and the conflict policy is fail.
+var ErrImportConflict = errors.New("record already exists")
+
+// ExportURLs streams every stored record to w in the given format.
+func (s *Service) ExportURLs(ctx context.Context, w io.Writer, format string, progress transfer.Progress) (int, error) {
+	n, err := transfer.Export(ctx, s.Storage, w, format, progress)
+	if err != nil {
+		return n, fmt.Errorf("failed to export urls: %w", err)
+	}
+	return n, nil
+}
+
+// ImportURLs reads records in the given format from r and stores them resolving conflicts by the policy.
+//
+// The long URLs are validated and canonicalized as on save, live records may not point to blocked destinations.
+func (s *Service) ImportURLs(
+	ctx context.Context, r io.Reader, format string, policy models.ConflictPolicy, progress transfer.Progress,
+) (models.ImportResult, error) {
+	res, err := transfer.Import(ctx, importSink{s}, r, format, policy, progress)
+	if err != nil {
+		return res, fmt.Errorf("failed to import urls: %w", err)
+	}
+	return res, nil
+}
+
+// importSink validates the imported records before passing them to the storage.
+type importSink struct {
+	s *Service
+}
+
+// ImportURLs stores the records, none of them when any record fails the validation.
+func (sink importSink) ImportURLs(
+	ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	valid := make([]models.URLRecord, 0, len(records))
+	for _, r := range records {
+		long, err := normalizeURL(r.OriginalURL)
+		if err != nil {
+			return models.ImportResult{}, fmt.Errorf("short %s: %w: %w", r.ShortURL, transfer.ErrInvalidRecord, err)
+		}
+		if !r.Deleted {
+			if err = sink.s.checkBlocked(long); err != nil {
+				return models.ImportResult{}, fmt.Errorf("short %s: %w: %w", r.ShortURL, transfer.ErrInvalidRecord, err)
+			}
+		}
+		r.OriginalURL = long
+		valid = append(valid, r)
+	}
+	return sink.s.Storage.ImportURLs(ctx, valid, policy)
+}
@@ -0,0 +1,104 @@
+package shortcode
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+)
+
+// maxHashIDLength keeps 62^length within uint64.
+const maxHashIDLength = 10
+
+// HashID obfuscates the next storage sequence value into a non-sequential code.
+//
+// The value is mapped by a salt-keyed bijection over the 62^length code space and encoded
+// with an alphabet shuffled by the salt, so distinct values never share a code. Values
+// outgrowing the code space get a longer code.
+type HashID struct {
+	seq      Sequencer
+	alphabet string
+	salt     string
+	length   int
+}
+
+// NewHashID creates an obfuscating sequence generator.
+func NewHashID(seq Sequencer, length int, salt string) *HashID {
+	return &HashID{seq: seq, length: length, salt: salt, alphabet: shuffle(base62, salt)}
+}
+
+// Generate returns the obfuscated code for the next sequence value.
+func (h *HashID) Generate(ctx context.Context) (string, error) {
+	n, err := h.seq.NextSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next sequence: %w", err)
+	}
+	return h.Encode(n), nil
+}
+
+// Encode returns the obfuscated code for n.
+func (h *HashID) Encode(n uint64) string {
+	length := min(h.length, maxHashIDLength)
+	space := pow62(length)
+	for n >= space && length < maxHashIDLength {
+		length++
+		space = pow62(length)
+	}
+	if n >= space {
+		return encode(n, h.alphabet, length)
+	}
+	multiplier, offset := h.keys(space)
+	hi, lo := bits.Mul64(n, multiplier)
+	_, mixed := bits.Div64(hi, lo, space)
+	return encode((mixed+offset)%space, h.alphabet, length)
+}
+
+// keys derives from the salt a multiplier coprime with space and an offset below it.
+func (h *HashID) keys(space uint64) (uint64, uint64) {
+	multiplier := hashOf(h.salt, "multiplier")%space | 1
+	for gcd(multiplier, space) != 1 {
+		multiplier += 2
+	}
+	return multiplier, hashOf(h.salt, "offset") % space
+}
+
+// shuffle deterministically permutes the alphabet with the salt.
+func shuffle(alphabet, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+	chars := []byte(alphabet)
+	state := hashOf(salt, "alphabet")
+	for i := len(chars) - 1; i > 0; i-- {
+		// xorshift keeps the permutation stable across Go releases
+		state ^= state << 13
+		state ^= state >> 7
+		state ^= state << 17
+		j := state % uint64(i+1)
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
+
+func hashOf(salt, purpose string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(purpose))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(salt))
+	return h.Sum64() | 1
+}
+
+func pow62(n int) uint64 {
+	res := uint64(1)
+	for i := 0; i < n; i++ {
+		res *= uint64(len(base62))
+	}
+	return res
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,128 @@
+// Package shortcode contains strategies for generating short URL codes.
+package shortcode
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const (
+	// StrategyRandom generates crypto-random codes.
+	StrategyRandom = "random"
+	// StrategySequence encodes the storage sequence in base62.
+	StrategySequence = "sequence"
+	// StrategyHashID obfuscates the storage sequence with a salt.
+	StrategyHashID = "hashid"
+
+	// DefaultLength is the code length used when none is configured.
+	DefaultLength = 8
+
+	base62 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// Generator contains contract for producing short codes.
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// Sequencer contains contract for issuing unique increasing numbers.
+type Sequencer interface {
+	NextSequence(ctx context.Context) (uint64, error)
+}
+
+// New creates the generator for the given strategy.
+func New(strategy string, seq Sequencer, length int, salt string) (Generator, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("invalid code length %d", length)
+	}
+	switch strategy {
+	case StrategyRandom, "":
+		return NewRandom(length), nil
+	case StrategySequence, StrategyHashID:
+		if seq == nil {
+			return nil, fmt.Errorf("strategy %q: %w", strategy, ErrNoSequencer)
+		}
+		if strategy == StrategySequence {
+			return NewSequence(seq, length), nil
+		}
+		return NewHashID(seq, length, salt), nil
+	}
+	return nil, fmt.Errorf("unknown short code strategy %q", strategy)
+}
+
+// Random generates codes from a cryptographically secure source.
+type Random struct {
+	length int
+}
+
+// NewRandom creates a crypto-random generator of codes with the given length.
+func NewRandom(length int) *Random {
+	return &Random{length: length}
+}
+
+// Generate returns a new random code.
+func (r *Random) Generate(_ context.Context) (string, error) {
+	// bytes above maxByte are rejected to keep the distribution uniform
+	const maxByte = byte(255 - (256 % len(base62)))
+	code := make([]byte, 0, r.length)
+	buf := make([]byte, r.length)
+	for len(code) < r.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if b > maxByte {
+				continue
+			}
+			code = append(code, base62[int(b)%len(base62)])
+			if len(code) == r.length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// Sequence encodes the next storage sequence value in base62.
+//
+// Codes are left-padded up to the configured length and grow once the sequence outgrows it.
+type Sequence struct {
+	seq    Sequencer
+	length int
+}
+
+// NewSequence creates a base62 sequence generator.
+func NewSequence(seq Sequencer, length int) *Sequence {
+	return &Sequence{seq: seq, length: length}
+}
+
+// Generate returns the code for the next sequence value.
+func (s *Sequence) Generate(ctx context.Context) (string, error) {
+	n, err := s.seq.NextSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next sequence: %w", err)
+	}
+	return encode(n, base62, s.length), nil
+}
+
+// encode converts n to the alphabet base left-padded with the zero digit up to length.
+func encode(n uint64, alphabet string, length int) string {
+	base := uint64(len(alphabet))
+	digits := make([]byte, 0, length)
+	for n > 0 {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+	}
+	for len(digits) < length {
+		digits = append(digits, alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// ErrNoSequencer error indicates the sequence strategies were selected without a sequence source.
+var ErrNoSequencer = errors.New("no sequence source")
@@ -0,0 +1,98 @@
+package shortcode
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	n uint64
+}
+
+func (c *counter) NextSequence(_ context.Context) (uint64, error) {
+	c.n++
+	return c.n, nil
+}
+
+var codeRe = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+func TestRandom(t *testing.T) {
+	g := NewRandom(12)
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		code, err := g.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, code, 12)
+		assert.Regexp(t, codeRe, code)
+		seen[code] = struct{}{}
+	}
+	assert.Len(t, seen, 1000)
+}
+
+func TestSequence(t *testing.T) {
+	g := NewSequence(&counter{n: 60}, 3)
+	codes := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		code, err := g.Generate(context.Background())
+		require.NoError(t, err)
+		codes = append(codes, code)
+	}
+	assert.Equal(t, []string{"aa9", "aba", "abb"}, codes)
+	assert.Equal(t, "baaa", encode(62*62*62, base62, 3))
+}
+
+func TestHashID(t *testing.T) {
+	const length = 3
+	g := NewHashID(&counter{}, length, "pepper")
+	seen := make(map[string]struct{})
+	prev := ""
+	for i := 0; i < 5000; i++ {
+		code, err := g.Generate(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, code, length)
+		assert.Regexp(t, codeRe, code)
+		assert.NotEqual(t, prev, code)
+		seen[code] = struct{}{}
+		prev = code
+	}
+	assert.Len(t, seen, 5000)
+
+	assert.Equal(t, g.Encode(42), NewHashID(nil, length, "pepper").Encode(42))
+	assert.NotEqual(t, g.Encode(42), NewHashID(nil, length, "salt").Encode(42))
+	assert.Len(t, g.Encode(pow62(length)), length+1)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		seq      Sequencer
+		length   int
+		wantErr  bool
+	}{
+		{name: "default is random", strategy: "", length: 8},
+		{name: "random", strategy: StrategyRandom, length: 8},
+		{name: "sequence", strategy: StrategySequence, seq: &counter{}, length: 6},
+		{name: "hashid", strategy: StrategyHashID, seq: &counter{}, length: 6},
+		{name: "sequence without source", strategy: StrategySequence, length: 6, wantErr: true},
+		{name: "unknown strategy", strategy: "uuid", length: 8, wantErr: true},
+		{name: "invalid length", strategy: StrategyRandom, length: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.strategy, tt.seq, tt.length, "")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			code, err := g.Generate(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, code, tt.length)
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// accountsFileSuffix is appended to the file storage path to get the accounts file.
+const accountsFileSuffix = ".accounts"
+
+// CreateAccount saves a new account to the database.
+func (d *inDatabase) CreateAccount(ctx context.Context, account models.Account) error {
+	const stmt = `INSERT INTO accounts (id, login, password_hash, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := d.pool.Exec(ctx, stmt, account.ID, account.Login, account.PasswordHash, account.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("login %s: %w", account.Login, service.ErrLoginTaken)
+		}
+		return fmt.Errorf("failed to insert account: %w", err)
+	}
+	return nil
+}
+
+// GetAccount retrieves an account by its login from the database.
+func (d *inDatabase) GetAccount(ctx context.Context, login string) (models.Account, error) {
+	const stmt = `SELECT id, login, password_hash, created_at FROM accounts WHERE login = $1`
+	var account models.Account
+	err := d.pool.QueryRow(ctx, stmt, login).Scan(&account.ID, &account.Login, &account.PasswordHash, &account.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Account{}, service.ErrAccountNotFound
+		}
+		return models.Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+	return account, nil
+}
+
+// ClaimURLs moves live URLs of the user from the context to the account and returns their count.
+func (d *inDatabase) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	const stmt = `UPDATE urls SET user_id = $2 WHERE user_id = $1 AND is_deleted = FALSE`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return 0, errGetUserFromContext
+	}
+	if userID == accountID {
+		return 0, nil
+	}
+	tag, err := d.pool.Exec(ctx, stmt, userID, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim urls: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// CreateAccount keeps a new account in memory.
+func (m *inMemory) CreateAccount(_ context.Context, account models.Account) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.createAccount(account)
+}
+
+// GetAccount retrieves an account by its login from memory.
+func (m *inMemory) GetAccount(_ context.Context, login string) (models.Account, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	account, ok := m.accounts[login]
+	if !ok {
+		return models.Account{}, service.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// ClaimURLs moves live URLs of the user from the context to the account and returns their count.
+func (m *inMemory) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	return m.claimURLs(ctx, accountID, noJournal)
+}
+
+// createAccount adds the account unless the login is taken. The caller must hold the lock.
+func (m *inMemory) createAccount(account models.Account) error {
+	if _, ok := m.accounts[account.Login]; ok {
+		return fmt.Errorf("login %s: %w", account.Login, service.ErrLoginTaken)
+	}
+	m.putAccounts([]models.Account{account})
+	return nil
+}
+
+// putAccounts adds or replaces the accounts in the in-memory index. The caller must hold the lock.
+func (m *inMemory) putAccounts(accounts []models.Account) {
+	if m.accounts == nil {
+		m.accounts = make(map[string]models.Account)
+	}
+	for _, account := range accounts {
+		m.accounts[account.Login] = account
+	}
+}
+
+// claimURLs journals and changes the owner of the live URLs of the user from the context.
+func (m *inMemory) claimURLs(ctx context.Context, accountID string, journal journalFunc) (int, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return 0, errGetUserFromContext
+	}
+	if userID == accountID {
+		return 0, nil
+	}
+	shorts := make([]string, 0)
+	for _, u := range m.urls.userRecords(userID) {
+		if !u.Deleted {
+			shorts = append(shorts, u.ShortURL)
+		}
+	}
+	claimed := make([]URLRecord, 0, len(shorts))
+	err := m.urls.update(shorts, nil, func(tx *urlTx) error {
+		for _, short := range shorts {
+			if u, ok := tx.get(short); ok && u.UserID == userID && !u.Deleted {
+				u.UserID = accountID
+				claimed = append(claimed, u)
+			}
+		}
+		if err := journal(walEntry{Op: opSave, Records: claimed}); err != nil {
+			return err
+		}
+		for _, u := range claimed {
+			tx.put(u)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(claimed), nil
+}
+
+// CreateAccount keeps a new account in memory and appends it to the accounts file.
+func (f *inFile) CreateAccount(_ context.Context, account models.Account) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if _, ok := f.accounts[account.Login]; ok {
+		return fmt.Errorf("login %s: %w", account.Login, service.ErrLoginTaken)
+	}
+	if err := appendJSONLines(f.filePath+accountsFileSuffix, []models.Account{account}); err != nil {
+		return fmt.Errorf("failed to append account: %w", err)
+	}
+	return f.createAccount(account)
+}
+
+// ClaimURLs moves live URLs of the user from the context to the account and logs the claimed records.
+func (f *inFile) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	claimed, err := f.claimURLs(ctx, accountID, f.appendLog)
+	if err != nil {
+		return 0, err
+	}
+	f.compactLog()
+	return claimed, nil
+}
+
+// restoreAccounts loads accounts saved next to the file storage.
+func (f *inFile) restoreAccounts() error {
+	accounts, err := readJSONLines[models.Account](f.filePath + accountsFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to restore accounts: %w", err)
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.putAccounts(accounts)
+	return nil
+}
+
+// CreateAccount saves a new account to the key-value storage.
+func (k *inKV) CreateAccount(_ context.Context, account models.Account) error {
+	return k.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAccounts)
+		if b.Get([]byte(account.Login)) != nil {
+			return fmt.Errorf("login %s: %w", account.Login, service.ErrLoginTaken)
+		}
+		data, err := json.Marshal(account)
+		if err != nil {
+			return fmt.Errorf("failed to marshal account: %w", err)
+		}
+		if err = b.Put([]byte(account.Login), data); err != nil {
+			return fmt.Errorf("failed to put account: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAccount retrieves an account by its login from the key-value storage.
+func (k *inKV) GetAccount(_ context.Context, login string) (models.Account, error) {
+	var account models.Account
+	err := k.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketAccounts).Get([]byte(login))
+		if data == nil {
+			return service.ErrAccountNotFound
+		}
+		if err := json.Unmarshal(data, &account); err != nil {
+			return fmt.Errorf("failed to unmarshal account: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Account{}, err
+	}
+	return account, nil
+}
+
+// ClaimURLs moves live URLs of the user from the context to the account and returns their count.
+func (k *inKV) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return 0, errGetUserFromContext
+	}
+	if userID == accountID {
+		return 0, nil
+	}
+	var claimed []URLRecord
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		err := userRecords(tx, userID, "", func(r URLRecord) bool {
+			if !r.Deleted {
+				claimed = append(claimed, r)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range claimed {
+			r.UserID = accountID
+			if err = putRecord(tx, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim urls: %w", err)
+	}
+	return len(claimed), nil
+}
+
+// CreateAccount saves a new account to the SQLite database.
+func (s *inSQLite) CreateAccount(ctx context.Context, account models.Account) error {
+	const stmt = `INSERT INTO accounts (id, login, password_hash, created_at) VALUES (?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, stmt, account.ID, account.Login, account.PasswordHash, account.CreatedAt.UnixMicro())
+	if err != nil {
+		if isUniqueViolation(err, "accounts.login") {
+			return fmt.Errorf("login %s: %w", account.Login, service.ErrLoginTaken)
+		}
+		return fmt.Errorf("failed to insert account: %w", err)
+	}
+	return nil
+}
+
+// GetAccount retrieves an account by its login from the SQLite database.
+func (s *inSQLite) GetAccount(ctx context.Context, login string) (models.Account, error) {
+	const stmt = `SELECT id, login, password_hash, created_at FROM accounts WHERE login = ?`
+	var (
+		account   models.Account
+		createdAt int64
+	)
+	err := s.db.QueryRowContext(ctx, stmt, login).Scan(&account.ID, &account.Login, &account.PasswordHash, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Account{}, service.ErrAccountNotFound
+		}
+		return models.Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+	account.CreatedAt = time.UnixMicro(createdAt).UTC()
+	return account, nil
+}
+
+// ClaimURLs moves live URLs of the user from the context to the account and returns their count.
+func (s *inSQLite) ClaimURLs(ctx context.Context, accountID string) (int, error) {
+	const stmt = `UPDATE urls SET user_id = ? WHERE user_id = ? AND is_deleted = FALSE`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return 0, errGetUserFromContext
+	}
+	if userID == accountID {
+		return 0, nil
+	}
+	res, err := s.db.ExecContext(ctx, stmt, accountID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim urls: %w", err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count claimed urls: %w", err)
+	}
+	return int(claimed), nil
+}
@@ -0,0 +1,436 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// disabledUsersFileSuffix is appended to the file storage path to get the disabled users file.
+const disabledUsersFileSuffix = ".disabled"
+
+// disabledUser is a line of the disabled users file. The last line of a user wins.
+type disabledUser struct {
+	UserID   string `json:"user_id"`
+	Disabled bool   `json:"disabled"`
+}
+
+// GetURLRecord retrieves the record of the short link from the database, preferring the live one.
+func (d *inDatabase) GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error) {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls WHERE short = $1
+		ORDER BY is_deleted, id DESC LIMIT 1`
+	rows, err := d.pool.Query(ctx, stmt, shortLink)
+	if err != nil {
+		return models.URLRecord{}, fmt.Errorf("failed query db: %w", err)
+	}
+	record, err := pgx.CollectExactlyOneRow(rows, scanURLRecord)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.URLRecord{}, service.ErrURLNotFound
+		}
+		return models.URLRecord{}, fmt.Errorf("failed to get url record: %w", err)
+	}
+	return record, nil
+}
+
+// SetURLDeleted marks the short link deleted or live regardless of its owner.
+//
+// A deleted link is restored from its latest row, a live one stays as it is.
+func (d *inDatabase) SetURLDeleted(ctx context.Context, shortLink string, deleted bool) error {
+	const stmt = `UPDATE urls SET is_deleted = $2 WHERE id =
+		(SELECT id FROM urls WHERE short = $1 ORDER BY is_deleted, id DESC LIMIT 1)`
+	tag, err := d.pool.Exec(ctx, stmt, shortLink, deleted)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return fmt.Errorf("short %s: %w", shortLink, service.ErrRestoreConflict)
+		}
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return service.ErrURLNotFound
+	}
+	return nil
+}
+
+// ListURLsByUser returns every record of the user ordered by id, deleted ones included.
+func (d *inDatabase) ListURLsByUser(ctx context.Context, userID string) ([]models.URLRecord, error) {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls WHERE user_id = $1 ORDER BY id`
+	rows, err := d.pool.Query(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed query db: %w", err)
+	}
+	records, err := pgx.CollectRows(rows, scanURLRecord)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect url records: %w", err)
+	}
+	return records, nil
+}
+
+// SetUserDisabled disables or enables the user in the database.
+func (d *inDatabase) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	const (
+		disableStmt = `INSERT INTO disabled_users (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+		enableStmt  = `DELETE FROM disabled_users WHERE user_id = $1`
+	)
+	stmt := enableStmt
+	if disabled {
+		stmt = disableStmt
+	}
+	if _, err := d.pool.Exec(ctx, stmt, userID); err != nil {
+		return fmt.Errorf("failed to update disabled users: %w", err)
+	}
+	return nil
+}
+
+// IsUserDisabled reports whether the user is disabled in the database.
+func (d *inDatabase) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	const stmt = `SELECT EXISTS (SELECT 1 FROM disabled_users WHERE user_id = $1)`
+	var disabled bool
+	if err := d.pool.QueryRow(ctx, stmt, userID).Scan(&disabled); err != nil {
+		return false, fmt.Errorf("failed to check disabled users: %w", err)
+	}
+	return disabled, nil
+}
+
+func scanURLRecord(row pgx.CollectableRow) (URLRecord, error) {
+	var (
+		id        int64
+		record    URLRecord
+		isDeleted *bool
+	)
+	if err := row.Scan(&id, &record.ShortURL, &record.OriginalURL, &record.UserID, &isDeleted,
+		&record.ExpiresAt); err != nil {
+		return URLRecord{}, fmt.Errorf("failed scan row into URLRecord: %w", err)
+	}
+	record.UUID = strconv.FormatInt(id, 10)
+	record.Deleted = isDeleted != nil && *isDeleted
+	return record, nil
+}
+
+// GetURLRecord retrieves the record of the short link from memory.
+func (m *inMemory) GetURLRecord(_ context.Context, shortLink string) (models.URLRecord, error) {
+	u, ok := m.urls.get(shortLink)
+	if !ok {
+		return models.URLRecord{}, service.ErrURLNotFound
+	}
+	return u, nil
+}
+
+// SetURLDeleted marks the short link deleted or live regardless of its owner.
+func (m *inMemory) SetURLDeleted(_ context.Context, shortLink string, deleted bool) error {
+	return m.setURLDeleted(shortLink, deleted, noJournal)
+}
+
+// ListURLsByUser returns every record of the user ordered by short URL, deleted ones included.
+func (m *inMemory) ListURLsByUser(_ context.Context, userID string) ([]models.URLRecord, error) {
+	records := m.urls.userRecords(userID)
+	sort.Slice(records, func(i, j int) bool { return records[i].ShortURL < records[j].ShortURL })
+	return records, nil
+}
+
+// SetUserDisabled disables or enables the user in memory.
+func (m *inMemory) SetUserDisabled(_ context.Context, userID string, disabled bool) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.putDisabledUsers([]disabledUser{{UserID: userID, Disabled: disabled}})
+	return nil
+}
+
+// IsUserDisabled reports whether the user is disabled in memory.
+func (m *inMemory) IsUserDisabled(_ context.Context, userID string) (bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	_, ok := m.disabled[userID]
+	return ok, nil
+}
+
+// setURLDeleted journals and flips the deleted flag of the record. A link is not restored while another live one
+// owns its long URL.
+func (m *inMemory) setURLDeleted(shortLink string, deleted bool, journal journalFunc) error {
+	u, ok := m.urls.get(shortLink)
+	if !ok {
+		return service.ErrURLNotFound
+	}
+	return m.urls.update([]string{shortLink}, []string{u.OriginalURL}, func(tx *urlTx) error {
+		u, ok := tx.get(shortLink)
+		if !ok {
+			return service.ErrURLNotFound
+		}
+		if u.Deleted && !deleted {
+			if owner, ok := tx.owner(u.OriginalURL); ok && owner.ShortURL != shortLink {
+				return fmt.Errorf("short %s: %w", shortLink, service.ErrRestoreConflict)
+			}
+		}
+		u.Deleted = deleted
+		if err := journal(walEntry{Op: opSave, Records: []URLRecord{u}}); err != nil {
+			return err
+		}
+		tx.put(u)
+		return nil
+	})
+}
+
+// putDisabledUsers applies the lines to the disabled users set. The caller must hold the lock.
+func (m *inMemory) putDisabledUsers(users []disabledUser) {
+	if m.disabled == nil {
+		m.disabled = make(map[string]struct{})
+	}
+	for _, u := range users {
+		if u.Disabled {
+			m.disabled[u.UserID] = struct{}{}
+		} else {
+			delete(m.disabled, u.UserID)
+		}
+	}
+}
+
+// SetURLDeleted marks the short link deleted or live and logs the record.
+func (f *inFile) SetURLDeleted(_ context.Context, shortLink string, deleted bool) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if err := f.setURLDeleted(shortLink, deleted, f.appendLog); err != nil {
+		return err
+	}
+	f.compactLog()
+	return nil
+}
+
+// SetUserDisabled disables or enables the user and appends the change to the disabled users file.
+func (f *inFile) SetUserDisabled(_ context.Context, userID string, disabled bool) error {
+	line := disabledUser{UserID: userID, Disabled: disabled}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if err := appendJSONLines(f.filePath+disabledUsersFileSuffix, []disabledUser{line}); err != nil {
+		return fmt.Errorf("failed to append disabled user: %w", err)
+	}
+	f.putDisabledUsers([]disabledUser{line})
+	return nil
+}
+
+// restoreDisabledUsers loads disabled users saved next to the file storage.
+func (f *inFile) restoreDisabledUsers() error {
+	users, err := readJSONLines[disabledUser](f.filePath + disabledUsersFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to restore disabled users: %w", err)
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.putDisabledUsers(users)
+	return nil
+}
+
+// GetURLRecord retrieves the record of the short link from the key-value storage.
+func (k *inKV) GetURLRecord(_ context.Context, shortLink string) (models.URLRecord, error) {
+	var record URLRecord
+	err := k.db.View(func(tx *bolt.Tx) error {
+		r, ok, err := getRecord(tx, shortLink)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return service.ErrURLNotFound
+		}
+		record = r
+		return nil
+	})
+	if err != nil {
+		return models.URLRecord{}, err
+	}
+	return record, nil
+}
+
+// SetURLDeleted marks the short link deleted or live regardless of its owner.
+//
+// Restoring a link whose long URL has another live record fails like the unique index of the database does.
+func (k *inKV) SetURLDeleted(_ context.Context, shortLink string, deleted bool) error {
+	return k.db.Update(func(tx *bolt.Tx) error {
+		r, ok, err := getRecord(tx, shortLink)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return service.ErrURLNotFound
+		}
+		if r.Deleted == deleted {
+			return nil
+		}
+		if !deleted {
+			if existing := tx.Bucket(bucketLongs).Get([]byte(r.OriginalURL)); existing != nil {
+				return fmt.Errorf("short %s: %w", shortLink, service.ErrRestoreConflict)
+			}
+		}
+		r.Deleted = deleted
+		return putRecord(tx, r)
+	})
+}
+
+// ListURLsByUser returns every record of the user ordered by short URL, deleted ones included.
+func (k *inKV) ListURLsByUser(_ context.Context, userID string) ([]models.URLRecord, error) {
+	records := make([]URLRecord, 0)
+	err := k.db.View(func(tx *bolt.Tx) error {
+		return userRecords(tx, userID, "", func(r URLRecord) bool {
+			records = append(records, r)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list url records: %w", err)
+	}
+	return records, nil
+}
+
+// SetUserDisabled disables or enables the user in the key-value storage.
+func (k *inKV) SetUserDisabled(_ context.Context, userID string, disabled bool) error {
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDisabledUsers)
+		if disabled {
+			return b.Put([]byte(userID), nil)
+		}
+		return b.Delete([]byte(userID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update disabled users: %w", err)
+	}
+	return nil
+}
+
+// IsUserDisabled reports whether the user is disabled in the key-value storage.
+func (k *inKV) IsUserDisabled(_ context.Context, userID string) (bool, error) {
+	var disabled bool
+	err := k.db.View(func(tx *bolt.Tx) error {
+		disabled = tx.Bucket(bucketDisabledUsers).Get([]byte(userID)) != nil
+		return nil
+	})
+	return disabled, err
+}
+
+// GetURLRecord retrieves the record of the short link from the SQLite database, preferring the live one.
+func (s *inSQLite) GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error) {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls WHERE short = ?
+		ORDER BY is_deleted, id DESC LIMIT 1`
+	record, err := scanSQLiteURLRecord(s.db.QueryRowContext(ctx, stmt, shortLink))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.URLRecord{}, service.ErrURLNotFound
+		}
+		return models.URLRecord{}, fmt.Errorf("failed to get url record: %w", err)
+	}
+	return record, nil
+}
+
+// SetURLDeleted marks the short link deleted or live regardless of its owner.
+//
+// A deleted link is restored from its latest row, a live one stays as it is.
+func (s *inSQLite) SetURLDeleted(ctx context.Context, shortLink string, deleted bool) error {
+	const stmt = `UPDATE urls SET is_deleted = ? WHERE id =
+		(SELECT id FROM urls WHERE short = ? ORDER BY is_deleted, id DESC LIMIT 1)`
+	res, err := s.db.ExecContext(ctx, stmt, deleted, shortLink)
+	if err != nil {
+		if isUniqueViolation(err, "urls.") {
+			return fmt.Errorf("short %s: %w", shortLink, service.ErrRestoreConflict)
+		}
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count updated urls: %w", err)
+	}
+	if updated == 0 {
+		return service.ErrURLNotFound
+	}
+	return nil
+}
+
+// ListURLsByUser returns every record of the user ordered by id, deleted ones included.
+func (s *inSQLite) ListURLsByUser(ctx context.Context, userID string) ([]models.URLRecord, error) {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls WHERE user_id = ? ORDER BY id`
+	records := make([]models.URLRecord, 0)
+	err := s.queryURLRecords(ctx, func(r models.URLRecord) error {
+		records = append(records, r)
+		return nil
+	}, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SetUserDisabled disables or enables the user in the SQLite database.
+func (s *inSQLite) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	const (
+		disableStmt = `INSERT INTO disabled_users (user_id, disabled_at) VALUES (?, ?) ON CONFLICT (user_id) DO NOTHING`
+		enableStmt  = `DELETE FROM disabled_users WHERE user_id = ?`
+	)
+	var err error
+	if disabled {
+		_, err = s.db.ExecContext(ctx, disableStmt, userID, time.Now().UnixMicro())
+	} else {
+		_, err = s.db.ExecContext(ctx, enableStmt, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update disabled users: %w", err)
+	}
+	return nil
+}
+
+// IsUserDisabled reports whether the user is disabled in the SQLite database.
+func (s *inSQLite) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	const stmt = `SELECT EXISTS (SELECT 1 FROM disabled_users WHERE user_id = ?)`
+	var disabled bool
+	if err := s.db.QueryRowContext(ctx, stmt, userID).Scan(&disabled); err != nil {
+		return false, fmt.Errorf("failed to check disabled users: %w", err)
+	}
+	return disabled, nil
+}
+
+// queryURLRecords passes the records selected by the statement to fn.
+func (s *inSQLite) queryURLRecords(
+	ctx context.Context, fn func(models.URLRecord) error, stmt string, args ...any,
+) error {
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed query db: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // rows.Err is checked
+	for rows.Next() {
+		record, err := scanSQLiteURLRecord(rows)
+		if err != nil {
+			return fmt.Errorf("failed scan row into URLRecord: %w", err)
+		}
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+	return nil
+}
+
+func scanSQLiteURLRecord(row interface{ Scan(dest ...any) error }) (URLRecord, error) {
+	var (
+		id        int64
+		record    URLRecord
+		expiresAt sql.NullInt64
+	)
+	if err := row.Scan(&id, &record.ShortURL, &record.OriginalURL, &record.UserID, &record.Deleted,
+		&expiresAt); err != nil {
+		return URLRecord{}, err
+	}
+	record.UUID = strconv.FormatInt(id, 10)
+	record.ExpiresAt = fromMicros(expiresAt)
+	return record, nil
+}
@@ -0,0 +1,416 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// apiKeysFileSuffix is appended to the file storage path to get the API keys file.
+const apiKeysFileSuffix = ".apikeys"
+
+// SaveAPIKey saves a new API key of the user from the context to the database.
+func (d *inDatabase) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	const stmt = `INSERT INTO api_keys (id, user_id, name, prefix, hash, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	if _, err := d.pool.Exec(ctx, stmt, key.ID, userID, key.Name, key.Prefix, key.Hash, key.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key by its hash from the database.
+func (d *inDatabase) GetAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	const stmt = `SELECT id, user_id, name, prefix, hash, created_at, revoked_at FROM api_keys WHERE hash = $1`
+	rows, err := d.pool.Query(ctx, stmt, hash)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("failed to query api key: %w", err)
+	}
+	key, err := pgx.CollectExactlyOneRow(rows, scanAPIKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, service.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys of the user from the context ordered by creation time.
+func (d *inDatabase) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const stmt = `SELECT id, user_id, name, prefix, hash, created_at, revoked_at FROM api_keys
+		WHERE user_id = $1 ORDER BY created_at, id`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	rows, err := d.pool.Query(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, scanAPIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key of the user from the context.
+func (d *inDatabase) RevokeAPIKey(ctx context.Context, id string) error {
+	const stmt = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 AND user_id = $2`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	tag, err := d.pool.Exec(ctx, stmt, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.CollectableRow) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+// SaveAPIKey keeps a new API key of the user from the context in memory.
+func (m *inMemory) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	key.UserID = userID
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.putAPIKeys([]models.APIKey{key})
+	return nil
+}
+
+// GetAPIKey retrieves an API key by its hash from memory.
+func (m *inMemory) GetAPIKey(_ context.Context, hash string) (models.APIKey, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	key, ok := m.apiKeys[hash]
+	if !ok {
+		return models.APIKey{}, service.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys of the user from the context ordered by creation time.
+func (m *inMemory) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	keys := make([]models.APIKey, 0)
+	for _, key := range m.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key of the user from the context.
+func (m *inMemory) RevokeAPIKey(ctx context.Context, id string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	_, err := m.revokeAPIKey(ctx, id)
+	return err
+}
+
+// revokeAPIKey marks the key revoked and returns it. The caller must hold the lock.
+func (m *inMemory) revokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return models.APIKey{}, errGetUserFromContext
+	}
+	for hash, key := range m.apiKeys {
+		if key.ID != id || key.UserID != userID {
+			continue
+		}
+		if key.RevokedAt == nil {
+			now := time.Now().UTC()
+			key.RevokedAt = &now
+			m.apiKeys[hash] = key
+		}
+		return key, nil
+	}
+	return models.APIKey{}, service.ErrAPIKeyNotFound
+}
+
+// putAPIKeys adds or replaces the keys in the in-memory index. The caller must hold the lock.
+func (m *inMemory) putAPIKeys(keys []models.APIKey) {
+	if m.apiKeys == nil {
+		m.apiKeys = make(map[string]models.APIKey)
+	}
+	for _, key := range keys {
+		m.apiKeys[key.Hash] = key
+	}
+}
+
+// SaveAPIKey keeps a new API key in memory and appends it to the API keys file.
+func (f *inFile) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	key.UserID = userID
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if err := appendJSONLines(f.filePath+apiKeysFileSuffix, []models.APIKey{key}); err != nil {
+		return fmt.Errorf("failed to append api key: %w", err)
+	}
+	f.putAPIKeys([]models.APIKey{key})
+	return nil
+}
+
+// RevokeAPIKey revokes an API key and appends its revoked state to the API keys file.
+func (f *inFile) RevokeAPIKey(ctx context.Context, id string) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	key, err := f.revokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = appendJSONLines(f.filePath+apiKeysFileSuffix, []models.APIKey{key}); err != nil {
+		return fmt.Errorf("failed to append api key: %w", err)
+	}
+	return nil
+}
+
+// restoreAPIKeys loads API keys saved next to the file storage. The last line of a key wins.
+func (f *inFile) restoreAPIKeys() error {
+	keys, err := readJSONLines[models.APIKey](f.filePath + apiKeysFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to restore api keys: %w", err)
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.putAPIKeys(keys)
+	return nil
+}
+
+// SaveAPIKey saves a new API key of the user from the context to the key-value storage.
+func (k *inKV) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	key.UserID = userID
+	return k.db.Update(func(tx *bolt.Tx) error {
+		return putAPIKey(tx, key)
+	})
+}
+
+// GetAPIKey retrieves an API key by its hash from the key-value storage.
+func (k *inKV) GetAPIKey(_ context.Context, hash string) (models.APIKey, error) {
+	var key models.APIKey
+	err := k.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketAPIKeys).Get([]byte(hash))
+		if data == nil {
+			return service.ErrAPIKeyNotFound
+		}
+		if err := json.Unmarshal(data, &key); err != nil {
+			return fmt.Errorf("failed to unmarshal api key: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys of the user from the context ordered by creation time.
+func (k *inKV) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	keys := make([]models.APIKey, 0)
+	err := k.db.View(func(tx *bolt.Tx) error {
+		return forEachAPIKey(tx, func(key models.APIKey) error {
+			if key.UserID == userID {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key of the user from the context.
+func (k *inKV) RevokeAPIKey(ctx context.Context, id string) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	return k.db.Update(func(tx *bolt.Tx) error {
+		var found *models.APIKey
+		err := forEachAPIKey(tx, func(key models.APIKey) error {
+			if key.ID == id && key.UserID == userID {
+				found = &key
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if found == nil {
+			return service.ErrAPIKeyNotFound
+		}
+		if found.RevokedAt != nil {
+			return nil
+		}
+		now := time.Now().UTC()
+		found.RevokedAt = &now
+		return putAPIKey(tx, *found)
+	})
+}
+
+func putAPIKey(tx *bolt.Tx, key models.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal api key: %w", err)
+	}
+	if err = tx.Bucket(bucketAPIKeys).Put([]byte(key.Hash), data); err != nil {
+		return fmt.Errorf("failed to put api key: %w", err)
+	}
+	return nil
+}
+
+func forEachAPIKey(tx *bolt.Tx, fn func(models.APIKey) error) error {
+	return tx.Bucket(bucketAPIKeys).ForEach(func(_, data []byte) error {
+		var key models.APIKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			return fmt.Errorf("failed to unmarshal api key: %w", err)
+		}
+		return fn(key)
+	})
+}
+
+// SaveAPIKey saves a new API key of the user from the context to the SQLite database.
+func (s *inSQLite) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	const stmt = `INSERT INTO api_keys (id, user_id, name, prefix, hash, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	_, err := s.db.ExecContext(ctx, stmt, key.ID, userID, key.Name, key.Prefix, key.Hash, key.CreatedAt.UnixMicro())
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key by its hash from the SQLite database.
+func (s *inSQLite) GetAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	const stmt = `SELECT id, user_id, name, prefix, hash, created_at, revoked_at FROM api_keys WHERE hash = ?`
+	key, err := scanSQLiteAPIKey(s.db.QueryRowContext(ctx, stmt, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, service.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys of the user from the context ordered by creation time.
+func (s *inSQLite) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const stmt = `SELECT id, user_id, name, prefix, hash, created_at, revoked_at FROM api_keys
+		WHERE user_id = ? ORDER BY created_at, id`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	rows, err := s.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // rows.Err is checked
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanSQLiteAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to collect api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key of the user from the context.
+func (s *inSQLite) RevokeAPIKey(ctx context.Context, id string) error {
+	const stmt = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND user_id = ?`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	res, err := s.db.ExecContext(ctx, stmt, time.Now().UnixMicro(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count revoked api keys: %w", err)
+	}
+	if revoked == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func scanSQLiteAPIKey(row interface{ Scan(dest ...any) error }) (models.APIKey, error) {
+	var (
+		key       models.APIKey
+		createdAt int64
+		revokedAt sql.NullInt64
+	)
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &createdAt, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+	key.CreatedAt = time.UnixMicro(createdAt).UTC()
+	key.RevokedAt = fromMicros(revokedAt)
+	return key, nil
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// clicksFileSuffix is appended to the file storage path to get the click events file.
+const clicksFileSuffix = ".clicks"
+
+// SaveClicks writes click events to the database using the COPY protocol.
+func (d *inDatabase) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	rows := make([][]any, 0, len(clicks))
+	for _, c := range clicks {
+		rows = append(rows, []any{c.Short, c.Time, c.Referrer, c.UserAgent, c.ClientIP})
+	}
+	_, err := d.pool.CopyFrom(
+		ctx,
+		pgx.Identifier{"clicks"},
+		[]string{"short", "clicked_at", "referrer", "user_agent", "client_ip"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy clicks: %w", err)
+	}
+	return nil
+}
+
+// ClickStats returns the click histogram for a link owned by the user from the context.
+func (d *inDatabase) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	const (
+		ownerStmt = `SELECT EXISTS(SELECT 1 FROM urls WHERE short = $1 AND user_id = $2 AND is_deleted = FALSE)`
+		statsStmt = `SELECT to_char(clicked_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*)
+		FROM clicks WHERE short = $1 GROUP BY day ORDER BY day`
+	)
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return models.ClickStats{}, errGetUserFromContext
+	}
+	var owned bool
+	if err := d.pool.QueryRow(ctx, ownerStmt, shortLink, userID).Scan(&owned); err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to check url owner: %w", err)
+	}
+	if !owned {
+		return models.ClickStats{}, service.ErrURLNotFound
+	}
+
+	rows, err := d.pool.Query(ctx, statsStmt, shortLink)
+	if err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to query clicks: %w", err)
+	}
+	defer rows.Close()
+	result := models.ClickStats{Short: shortLink, Days: make([]models.DayClicks, 0)}
+	for rows.Next() {
+		var day models.DayClicks
+		if err = rows.Scan(&day.Day, &day.Clicks); err != nil {
+			return models.ClickStats{}, fmt.Errorf("failed to scan clicks row: %w", err)
+		}
+		result.Total += day.Clicks
+		result.Days = append(result.Days, day)
+	}
+	if err = rows.Err(); err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to read clicks rows: %w", err)
+	}
+	return result, nil
+}
+
+// SaveClicks keeps click events in memory.
+func (m *inMemory) SaveClicks(_ context.Context, clicks []models.Click) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.appendClicks(clicks)
+	return nil
+}
+
+// appendClicks adds click events to the in-memory index. The caller must hold the lock.
+func (m *inMemory) appendClicks(clicks []models.Click) {
+	if m.clicks == nil {
+		m.clicks = make(map[string][]models.Click)
+	}
+	for _, c := range clicks {
+		m.clicks[c.Short] = append(m.clicks[c.Short], c)
+	}
+}
+
+// ClickStats returns the click histogram for a link owned by the user from the context.
+func (m *inMemory) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return models.ClickStats{}, errGetUserFromContext
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	u, ok := m.urls.get(shortLink)
+	if !ok || u.Deleted || u.UserID != userID {
+		return models.ClickStats{}, service.ErrURLNotFound
+	}
+	return clickHistogram(shortLink, m.clicks[shortLink]), nil
+}
+
+// purgeOrphanClicks drops click events of links that no longer exist and reports whether any were dropped.
+// The caller must hold the lock.
+func (m *inMemory) purgeOrphanClicks() bool {
+	purged := false
+	for short := range m.clicks {
+		if _, ok := m.urls.get(short); !ok {
+			delete(m.clicks, short)
+			purged = true
+		}
+	}
+	return purged
+}
+
+// SaveClicks keeps click events in memory and appends them to the clicks file.
+func (f *inFile) SaveClicks(_ context.Context, clicks []models.Click) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if err := appendJSONLines(f.filePath+clicksFileSuffix, clicks); err != nil {
+		return fmt.Errorf("failed to append clicks: %w", err)
+	}
+	f.appendClicks(clicks)
+	return nil
+}
+
+// restoreClicks loads click events saved next to the file storage.
+func (f *inFile) restoreClicks() error {
+	clicks, err := readJSONLines[models.Click](f.filePath + clicksFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to restore clicks: %w", err)
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.appendClicks(clicks)
+	return nil
+}
+
+// rewriteClicks replaces the clicks file with the click events kept in memory,
+// so the ones of purged links do not come back on restart. The caller must hold the lock.
+func (f *inFile) rewriteClicks() error {
+	shorts := make([]string, 0, len(f.clicks))
+	for short := range f.clicks {
+		shorts = append(shorts, short)
+	}
+	sort.Strings(shorts)
+	data := make([]byte, 0)
+	for _, short := range shorts {
+		for _, c := range f.clicks[short] {
+			line, err := encodeLine(c)
+			if err != nil {
+				return fmt.Errorf("failed to encode click: %w", err)
+			}
+			data = append(data, line...)
+		}
+	}
+	if err := replaceFile(f.filePath+clicksFileSuffix, data); err != nil {
+		return fmt.Errorf("failed to rewrite clicks: %w", err)
+	}
+	return nil
+}
+
+// clickHistogram groups click events by UTC day.
+func clickHistogram(shortLink string, clicks []models.Click) models.ClickStats {
+	perDay := make(map[string]int)
+	for _, c := range clicks {
+		perDay[c.Time.UTC().Format(time.DateOnly)]++
+	}
+	result := models.ClickStats{Short: shortLink, Total: len(clicks), Days: make([]models.DayClicks, 0, len(perDay))}
+	for day, cnt := range perDay {
+		result.Days = append(result.Days, models.DayClicks{Day: day, Clicks: cnt})
+	}
+	sort.Slice(result.Days, func(i, j int) bool {
+		return result.Days[i].Day < result.Days[j].Day
+	})
+	return result
+}
+
+// SaveClicks writes click events to the key-value storage.
+func (k *inKV) SaveClicks(_ context.Context, clicks []models.Click) error {
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketClicks)
+		for _, c := range clicks {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return fmt.Errorf("failed to get click id: %w", err)
+			}
+			data, err := json.Marshal(&c)
+			if err != nil {
+				return fmt.Errorf("failed to marshal click: %w", err)
+			}
+			if err = b.Put(compositeKey(c.Short, sequenceKey(seq)), data); err != nil {
+				return fmt.Errorf("failed to put click: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save clicks: %w", err)
+	}
+	return nil
+}
+
+// ClickStats returns the click histogram for a link owned by the user from the context.
+func (k *inKV) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return models.ClickStats{}, errGetUserFromContext
+	}
+	var clicks []models.Click
+	err := k.db.View(func(tx *bolt.Tx) error {
+		r, ok, err := getRecord(tx, shortLink)
+		if err != nil {
+			return err
+		}
+		if !ok || r.Deleted || r.UserID != userID {
+			return service.ErrURLNotFound
+		}
+		prefix := compositeKey(shortLink)
+		c := tx.Bucket(bucketClicks).Cursor()
+		for key, data := c.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, data = c.Next() {
+			var click models.Click
+			if err = json.Unmarshal(data, &click); err != nil {
+				return fmt.Errorf("failed to unmarshal click: %w", err)
+			}
+			clicks = append(clicks, click)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ClickStats{}, err
+	}
+	return clickHistogram(shortLink, clicks), nil
+}
+
+// SaveClicks writes click events to the SQLite database in a single transaction.
+func (s *inSQLite) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	const stmt = `INSERT INTO clicks (short, clicked_at, referrer, user_agent, client_ip) VALUES (?, ?, ?, ?, ?)`
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer s.rollback(tx)
+	insert, err := tx.PrepareContext(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close() //nolint:errcheck // closed with the transaction
+	for _, c := range clicks {
+		if _, err = insert.ExecContext(ctx, c.Short, c.Time.UnixMicro(), c.Referrer, c.UserAgent, c.ClientIP); err != nil {
+			return fmt.Errorf("failed to insert click: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ClickStats returns the click histogram for a link owned by the user from the context.
+func (s *inSQLite) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	const (
+		ownerStmt = `SELECT EXISTS(SELECT 1 FROM urls WHERE short = ? AND user_id = ? AND is_deleted = FALSE)`
+		statsStmt = `SELECT strftime('%Y-%m-%d', clicked_at / 1000000, 'unixepoch') AS day, COUNT(*)
+		FROM clicks WHERE short = ? GROUP BY day ORDER BY day`
+	)
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return models.ClickStats{}, errGetUserFromContext
+	}
+	var owned bool
+	if err := s.db.QueryRowContext(ctx, ownerStmt, shortLink, userID).Scan(&owned); err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to check url owner: %w", err)
+	}
+	if !owned {
+		return models.ClickStats{}, service.ErrURLNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, statsStmt, shortLink)
+	if err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to query clicks: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // rows.Err is checked
+	result := models.ClickStats{Short: shortLink, Days: make([]models.DayClicks, 0)}
+	for rows.Next() {
+		var day models.DayClicks
+		if err = rows.Scan(&day.Day, &day.Clicks); err != nil {
+			return models.ClickStats{}, fmt.Errorf("failed to scan clicks row: %w", err)
+		}
+		result.Total += day.Clicks
+		result.Days = append(result.Days, day)
+	}
+	if err = rows.Err(); err != nil {
+		return models.ClickStats{}, fmt.Errorf("failed to read clicks rows: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+	"shortener/internal/storage/storagetest"
+)
+
+// testDatabaseDSN names the variable with the DSN of a Postgres the suite may wipe.
+const testDatabaseDSN = "TEST_DATABASE_DSN"
+
+func loadTestStorage(t *testing.T, app config.AppConfig) service.URLStorage {
+	t.Helper()
+	log := &logger.Log{}
+	log.Initialize("ERROR")
+	app.BaseURL = storagetest.BaseURL
+	s, err := storage.LoadStorage(context.Background(), &config.Config{App: app}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, s.Close())
+	})
+	return s
+}
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		name       string
+		newStorage storagetest.Factory
+	}{
+		{
+			name: storage.BackendMemory,
+			newStorage: func(t *testing.T) service.URLStorage {
+				return loadTestStorage(t, config.AppConfig{})
+			},
+		},
+		{
+			name: storage.BackendFile,
+			newStorage: func(t *testing.T) service.URLStorage {
+				return loadTestStorage(t, config.AppConfig{FileStoragePath: path.Join(t.TempDir(), "storage.json")})
+			},
+		},
+		{
+			name: storage.BackendKV,
+			newStorage: func(t *testing.T) service.URLStorage {
+				return loadTestStorage(t, config.AppConfig{KVStoragePath: path.Join(t.TempDir(), "kv.db")})
+			},
+		},
+		{
+			name: storage.BackendSQLite,
+			newStorage: func(t *testing.T) service.URLStorage {
+				dsn := storage.SQLiteScheme + path.Join(t.TempDir(), "test.db")
+				return loadTestStorage(t, config.AppConfig{DatabaseDSN: dsn})
+			},
+		},
+		{
+			name: storage.BackendPostgres,
+			newStorage: func(t *testing.T) service.URLStorage {
+				dsn := os.Getenv(testDatabaseDSN)
+				if dsn == "" {
+					t.Skipf("%s is not set", testDatabaseDSN)
+				}
+				s := loadTestStorage(t, config.AppConfig{DatabaseDSN: dsn})
+				truncatePostgres(t, dsn)
+				return s
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storagetest.Run(t, tt.newStorage)
+		})
+	}
+}
+
+// truncatePostgres empties the tables left by the previous test, the migrations are already applied.
+func truncatePostgres(t *testing.T, dsn string) {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, conn.Close(ctx))
+	}()
+	_, err = conn.Exec(ctx, `TRUNCATE urls, clicks, api_keys, accounts, disabled_users RESTART IDENTITY`)
+	require.NoError(t, err)
+}
@@ -2,30 +2,22 @@ package storage
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 
-	"shortener/internal/logger"
 	"shortener/internal/models"
 )
 
 // URLRecord represents a single URL record.
-type URLRecord struct {
-	UUID        string `json:"uuid"`
-	OriginalURL string `json:"original_url"`
-	ShortURL    string `json:"short_url"`
-	UserID      string `json:"user_id"`
-	Deleted     bool   `json:"is_deleted"`
-}
+type URLRecord = models.URLRecord
 
 // Consumer represents a file consumer.
 type Consumer struct {
 	file   *os.File
-	reader *bufio.Scanner
+	reader *bufio.Reader
 }
 
 func prepareDir(filename string) error {
@@ -46,148 +38,100 @@ func NewConsumer(filename string) (*Consumer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare direcory: %w", err)
 	}
-	file, err := os.OpenFile(filename, os.O_RDONLY|os.O_CREATE, 0666)
+	file, err := os.OpenFile(filename, os.O_RDONLY|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	return &Consumer{
 		file:   file,
-		reader: bufio.NewScanner(file),
+		reader: bufio.NewReader(file),
 	}, nil
 }
 
-// ReadFileStorage reads URL records from the given filename and returns them as a map.
+// ReadFileStorage replays the storage log of the given filename and returns the records as a map.
+//
+// The file is not modified, a torn last record is skipped.
 func ReadFileStorage(filename string) (map[string]URLRecord, error) {
 	c, err := NewConsumer(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new consumer: %w", err)
 	}
-	var urlRecord URLRecord
-	var URLs = map[string]URLRecord{}
-
-	for c.reader.Scan() {
-		row := c.reader.Text()
-		err = json.Unmarshal([]byte(row), &urlRecord)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal row: %w", err)
-		}
-		URLs[urlRecord.ShortURL] = URLRecord{
-			UUID:        urlRecord.UUID,
-			OriginalURL: urlRecord.OriginalURL,
-			ShortURL:    urlRecord.ShortURL,
-			UserID:      urlRecord.UserID,
-			Deleted:     urlRecord.Deleted,
-		}
+	URLs := map[string]URLRecord{}
+	if _, _, err = replayLog(c.reader, URLs, &walCounters{}); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to replay log: %w", err), c.file.Close())
+	}
+	if err = c.file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close file: %w", err)
 	}
-
 	return URLs, nil
 }
 
-// AppendToFile appends a single URL record to the given filename.
-func AppendToFile(log *logger.Log, filename string, urlRecord URLRecord) error {
-	urlRow := URLRecord{
-		UUID:        urlRecord.UUID,
-		OriginalURL: urlRecord.OriginalURL,
-		ShortURL:    urlRecord.ShortURL,
-		UserID:      urlRecord.UserID,
-		Deleted:     false,
-	}
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return fmt.Errorf("failed to open file %w", err)
-	}
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			log.Err("failed to close file", err)
-		}
-	}()
-	data, err := json.Marshal(&urlRow)
+// CheckWritable reports an error unless the storage file can be appended to.
+// A missing file is created readable by the owner only, as the log holds the user IDs.
+func (f *inFile) CheckWritable(_ context.Context) error {
+	file, err := os.OpenFile(f.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
-		return fmt.Errorf("failed to marshal url record %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
-
-	data = append(data, '\n')
-	_, err = file.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed write to file %w", err)
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
 	}
-
 	return nil
 }
 
-// BatchAppend appends multiple URL records to the given filename and returns the updated batch array.
-func BatchAppend(
-	log *logger.Log, filename, baseURL, userID string, input models.BatchArray, counter uint64,
-) (models.BatchArray, error) {
-	var saved models.BatchArray
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file %w", err)
+// appendLog writes the operation to the storage log opening it on first use, an empty one is skipped.
+// The caller must hold the lock.
+func (f *inFile) appendLog(e walEntry) error {
+	if e.empty() {
+		return nil
 	}
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			log.Err("failed to close file: ", err)
-		}
-	}()
-	for _, item := range input {
-		var row = URLRecord{
-			UUID:        strconv.FormatUint(counter+1, 10),
-			OriginalURL: item.OriginalURL,
-			ShortURL:    item.CorrelationID,
-			UserID:      userID,
-			Deleted:     false,
-		}
-		data, err := json.Marshal(&row)
+	if f.journal == nil {
+		journal, _, err := openWAL(f.filePath, f.fsync, f.fsyncInterval, make(map[string]URLRecord), &walCounters{})
 		if err != nil {
-			return nil, fmt.Errorf("failed marshal row: %w", err)
+			return fmt.Errorf("failed to open log: %w", err)
 		}
-		data = append(data, '\n')
-		_, err = file.Write(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed write batch into file: %w", err)
-		}
-		counter++
-		shortURL, err := url.JoinPath(baseURL, "/", item.CorrelationID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to build short url: %w", err)
-		}
-		saved = append(saved, models.Batch{
-			CorrelationID: item.CorrelationID,
-			ShortURL:      shortURL,
-			OriginalURL:   item.OriginalURL,
-		})
+		f.journal = journal
+	}
+	if err := f.journal.append(e); err != nil {
+		return fmt.Errorf("failed to append to log: %w", err)
 	}
+	return nil
+}
 
-	return saved, nil
+// compactLog rewrites the storage log once it has grown well past the live records.
+// The caller must hold the lock.
+//
+// The operation is already durable, so a failed compaction is only logged.
+func (f *inFile) compactLog() {
+	if f.journal == nil || !f.journal.needsCompaction(f.urls.len()) {
+		return
+	}
+	counters := walCounters{counter: f.counter.Load(), sequence: f.sequenceLimit}
+	if err := f.journal.compact(f.urls.records(), counters); err != nil {
+		f.Err("failed to compact file storage log", err)
+	}
 }
 
-// BatchUpdate updates multiple URL records in the given filename.
-func BatchUpdate(filename string, input []URLRecord) error {
-	file, err := os.OpenFile(filename, os.O_TRUNC|os.O_RDWR, 0666)
+// replaceFile atomically replaces the file with the data created with owner only permissions.
+//
+// The data is written to a temporary file next to it, synced and renamed over it,
+// so a crash leaves either the old or the new file.
+func replaceFile(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("failed to open file %w", err)
-	}
-	result := make([]byte, 0)
-	for _, in := range input {
-		record := URLRecord{
-			UUID:        in.UUID,
-			OriginalURL: in.OriginalURL,
-			ShortURL:    in.ShortURL,
-			UserID:      in.UserID,
-			Deleted:     in.Deleted,
-		}
-		data, err := json.Marshal(&record)
-		if err != nil {
-			return fmt.Errorf("failed marshal data: %w", err)
-		}
-		data = append(data, '\n')
-		result = append(result, data...)
+		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
-	if _, err = file.Write(result); err != nil {
-		return fmt.Errorf("failed write line into file: %w", err)
+	if _, err = tmp.Write(data); err != nil {
+		return errors.Join(fmt.Errorf("failed to write temporary file: %w", err), tmp.Close(), os.Remove(tmp.Name()))
 	}
-
-	return nil
+	if err = tmp.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync temporary file: %w", err), tmp.Close(), os.Remove(tmp.Name()))
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to close temporary file: %w", err), os.Remove(tmp.Name()))
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return errors.Join(fmt.Errorf("failed to replace file: %w", err), os.Remove(tmp.Name()))
+	}
+	return syncDir(filepath.Dir(filename))
 }
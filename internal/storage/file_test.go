@@ -1,15 +1,19 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"shortener/internal/config"
 	"shortener/internal/logger"
 	"shortener/internal/models"
 )
@@ -85,99 +89,48 @@ func TestReadFileStorage(t *testing.T) {
 	assert.Equal(t, len(urls), len(records))
 }
 
-func TestAppendToFile(t *testing.T) {
-	tmpDir := path.Join(os.TempDir(), strconv.FormatInt(time.Now().Unix(), 10))
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		assert.NoError(t, err)
-	}
-	filePath := path.Join(tmpDir, filename)
-	defer func() {
-		err := os.RemoveAll(tmpDir)
-		assert.NoError(t, err)
-	}()
-
-	log := &logger.Log{}
-	log.Initialize("INFO")
-	record := URLRecord{
-		UUID:        "1",
-		OriginalURL: "https://example.com/1",
-		ShortURL:    "short1",
-		UserID:      "user1",
-	}
-
-	if err := AppendToFile(log, filePath, record); err != nil {
-		assert.NoError(t, err)
-	}
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		assert.NoError(t, err)
-	}
-
-	var readRecord URLRecord
-	if err := json.Unmarshal(content[:len(content)-1], &readRecord); err != nil {
-		assert.NoError(t, err)
-	}
-
-	assert.Equal(t, readRecord, record)
-}
-
-func TestBatchAppend(t *testing.T) {
-	tmpDir := path.Join(os.TempDir(), strconv.FormatInt(time.Now().Unix(), 10))
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		assert.NoError(t, err)
-	}
-	filePath := path.Join(tmpDir, filename)
-	defer func() {
-		err := os.RemoveAll(tmpDir)
-		assert.NoError(t, err)
-	}()
-
+func TestReadFileStorage_ExpiresAt(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
 	log := &logger.Log{}
 	log.Initialize("INFO")
-	baseURL := "http://short.url"
+	expiresAt := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
 
-	input := models.BatchArray{
-		{OriginalURL: "http://example.com/1", CorrelationID: "short1"},
-		{OriginalURL: "http://example.com/2", CorrelationID: "short2"},
+	fileStorage := &inFile{
+		inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: newURLTable(memoryShards)},
+		filePath: filePath,
 	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user1")
+	require.NoError(t, fileStorage.Save(ctx, "short1", "https://example.com/1", &expiresAt))
+	require.NoError(t, fileStorage.Save(ctx, "short2", "https://example.com/2", nil))
+	require.NoError(t, fileStorage.Close())
 
-	saved, err := BatchAppend(log, filePath, baseURL, "user1", input, 0)
-	if err != nil {
-		assert.NoError(t, err)
+	urls, err := ReadFileStorage(filePath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, urls["short1"].ExpiresAt) {
+		assert.True(t, expiresAt.Equal(*urls["short1"].ExpiresAt))
 	}
-
-	assert.Equal(t, 2, len(saved))
+	assert.Nil(t, urls["short2"].ExpiresAt)
 }
 
-func TestBatchUpdate(t *testing.T) {
-	tmpDir := path.Join(os.TempDir(), strconv.FormatInt(time.Now().Unix(), 10))
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		assert.NoError(t, err)
-	}
-
-	records := []URLRecord{
-		{UUID: "1", OriginalURL: "https://example.com/3", ShortURL: "short3", UserID: "user3"},
-		{UUID: "2", OriginalURL: "https://example.com/4", ShortURL: "short4", UserID: "user4"},
+func TestOrderFileRecords(t *testing.T) {
+	mapping := map[string]URLRecord{
+		"ccc": {UUID: "10", OriginalURL: "https://example.com/a"},
+		"aaa": {UUID: "2", OriginalURL: "https://example.com/a"},
+		"bbb": {UUID: "3", OriginalURL: "https://example.com/b", Deleted: true},
+		"ddd": {UUID: "corr-1", OriginalURL: "https://example.com/b"},
 	}
 
-	file, err := os.CreateTemp(tmpDir, filename)
-	assert.NoError(t, err)
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			assert.NoError(t, err)
-		}
-	}()
+	_, err := OrderFileRecords(mapping, false)
+	assert.ErrorIs(t, err, ErrDuplicateLongURL)
 
-	if err = BatchUpdate(file.Name(), records); err != nil {
-		assert.NoError(t, err)
+	records, err := OrderFileRecords(mapping, true)
+	require.NoError(t, err)
+	shorts := make([]string, 0, len(records))
+	for _, r := range records {
+		shorts = append(shorts, r.ShortURL)
 	}
-
-	urls, err := ReadFileStorage(file.Name())
-	if err != nil {
-		assert.NoError(t, err)
-	}
-
-	assert.Equal(t, len(urls), len(records))
+	assert.Equal(t, []string{"aaa", "bbb", "ccc", "ddd"}, shorts)
+	assert.False(t, records[0].Deleted)
+	assert.True(t, records[2].Deleted, "repeated live long URL is retired")
+	assert.False(t, records[3].Deleted, "long URL of a deleted record stays live")
 }
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrDuplicateLongURL error indicates the file has several live records for one long URL,
+	// which the database does not allow.
+	ErrDuplicateLongURL = errors.New("several live records share a long URL")
+	// ErrCountMismatch error indicates some migrated records are missing from the database after migration.
+	ErrCountMismatch = errors.New("row count mismatch")
+)
+
+// FileMigrationResult describes a file to database migration.
+type FileMigrationResult struct {
+	Total   int
+	Resumed int
+	Copied  int
+}
+
+// OrderFileRecords returns the restored file records in the order they were written.
+//
+// Live records repeating a long URL make it fail with ErrDuplicateLongURL unless retireDuplicates
+// is set, in which case all but the first one are marked as deleted.
+func OrderFileRecords(mapping map[string]URLRecord, retireDuplicates bool) ([]URLRecord, error) {
+	records := make([]URLRecord, 0, len(mapping))
+	for short, r := range mapping {
+		r.ShortURL = short
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, errA := strconv.ParseUint(records[i].UUID, 10, 64)
+		b, errB := strconv.ParseUint(records[j].UUID, 10, 64)
+		switch {
+		case errA == nil && errB == nil && a != b:
+			return a < b
+		case (errA == nil) != (errB == nil):
+			// numeric ids go first
+			return errA == nil
+		}
+		return records[i].ShortURL < records[j].ShortURL
+	})
+
+	live := make(map[string]string, len(records))
+	for i, r := range records {
+		if r.Deleted {
+			continue
+		}
+		first, ok := live[r.OriginalURL]
+		if !ok {
+			live[r.OriginalURL] = r.ShortURL
+			continue
+		}
+		if !retireDuplicates {
+			return nil, fmt.Errorf("%s used by %s and %s: %w", r.OriginalURL, first, r.ShortURL, ErrDuplicateLongURL)
+		}
+		records[i].Deleted = true
+	}
+	return records, nil
+}
+
+// migrationKey identifies a file record among the migrated ones.
+type migrationKey struct {
+	uuid  string
+	short string
+}
+
+func keyOf(r URLRecord) migrationKey {
+	return migrationKey{uuid: r.UUID, short: r.ShortURL}
+}
+
+// migrationTarget is the database side of a file migration.
+type migrationTarget interface {
+	// startMigration registers the migration of the source or updates its total.
+	startMigration(ctx context.Context, source string, total int) error
+	// migratedKeys returns the keys of the records of the source copied so far.
+	migratedKeys(ctx context.Context, source string) (map[migrationKey]struct{}, error)
+	// copyRecords copies the records and tracks their keys in the same transaction.
+	copyRecords(ctx context.Context, source string, records []URLRecord) error
+	// countMigrated returns how many of the records are tracked and still stored as they were copied.
+	countMigrated(ctx context.Context, source string, records []URLRecord) (int, error)
+	// finishMigration marks the migration of the source finished.
+	finishMigration(ctx context.Context, source string) error
+}
+
+// MigrateFileRecords copies the ordered records into the urls table in batches.
+//
+// Every batch is copied in its own transaction together with the keys of its records,
+// so running it again after an interruption copies only the records not copied yet,
+// whatever order the file gives them. Afterwards every record of the file is looked up
+// among the migrated ones, so rows written to urls by others do not affect the check.
+func (s *DBStore) MigrateFileRecords(
+	ctx context.Context, source string, records []URLRecord, batchSize int, progress func(copied, total int),
+) (FileMigrationResult, error) {
+	return migrateFileRecords(ctx, s, source, records, batchSize, progress)
+}
+
+func migrateFileRecords(
+	ctx context.Context, target migrationTarget, source string, records []URLRecord, batchSize int,
+	progress func(copied, total int),
+) (FileMigrationResult, error) {
+	result := FileMigrationResult{Total: len(records)}
+	if batchSize <= 0 {
+		return result, fmt.Errorf("invalid batch size %d", batchSize)
+	}
+	// records appended to the file after an interruption are copied too
+	if err := target.startMigration(ctx, source, len(records)); err != nil {
+		return result, fmt.Errorf("failed to start migration: %w", err)
+	}
+	migrated, err := target.migratedKeys(ctx, source)
+	if err != nil {
+		return result, fmt.Errorf("failed to get migrated records: %w", err)
+	}
+	pending := make([]URLRecord, 0, len(records))
+	for _, r := range records {
+		if _, ok := migrated[keyOf(r)]; !ok {
+			pending = append(pending, r)
+		}
+	}
+	result.Resumed = len(records) - len(pending)
+
+	for start := 0; start < len(pending); start += batchSize {
+		end := min(start+batchSize, len(pending))
+		if err = target.copyRecords(ctx, source, pending[start:end]); err != nil {
+			return result, fmt.Errorf("failed to copy records %d-%d: %w", start, end, err)
+		}
+		result.Copied += end - start
+		if progress != nil {
+			progress(result.Resumed+result.Copied, len(records))
+		}
+	}
+
+	found, err := target.countMigrated(ctx, source, records)
+	if err != nil {
+		return result, fmt.Errorf("failed to count migrated rows: %w", err)
+	}
+	if found != len(records) {
+		return result, fmt.Errorf("found %d of %d records: %w", found, len(records), ErrCountMismatch)
+	}
+	if err = target.finishMigration(ctx, source); err != nil {
+		return result, fmt.Errorf("failed to finish migration: %w", err)
+	}
+	return result, nil
+}
+
+func (s *DBStore) startMigration(ctx context.Context, source string, total int) error {
+	const stmt = `INSERT INTO file_migrations (source, total) VALUES ($1, $2)
+		ON CONFLICT (source) DO UPDATE SET total = EXCLUDED.total`
+	_, err := s.pool.Exec(ctx, stmt, source, total)
+	return err
+}
+
+func (s *DBStore) migratedKeys(ctx context.Context, source string) (map[migrationKey]struct{}, error) {
+	const stmt = `SELECT uuid, short FROM file_migration_records WHERE source = $1`
+	rows, err := s.pool.Query(ctx, stmt, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed query db: %w", err)
+	}
+	defer rows.Close()
+	keys := make(map[migrationKey]struct{})
+	for rows.Next() {
+		var key migrationKey
+		if err = rows.Scan(&key.uuid, &key.short); err != nil {
+			return nil, fmt.Errorf("failed to scan migrated record: %w", err)
+		}
+		keys[key] = struct{}{}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read migrated records: %w", err)
+	}
+	return keys, nil
+}
+
+// copyRecords copies the records and tracks them as migrated in the same transaction.
+func (s *DBStore) copyRecords(ctx context.Context, source string, records []URLRecord) error {
+	const progressStmt = `UPDATE file_migrations SET copied = copied + $2 WHERE source = $1`
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	columns := []string{"short", "long", "user_id", "is_deleted", "expires_at"}
+	rows := pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+		r := records[i]
+		return []any{r.ShortURL, r.OriginalURL, r.UserID, r.Deleted, r.ExpiresAt}, nil
+	})
+	if _, err = tx.CopyFrom(ctx, pgx.Identifier{"urls"}, columns, rows); err != nil {
+		return fmt.Errorf("failed to copy rows: %w", err)
+	}
+	tracked := pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+		r := records[i]
+		return []any{source, r.UUID, r.ShortURL, r.OriginalURL, r.UserID, r.Deleted}, nil
+	})
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"file_migration_records"},
+		[]string{"source", "uuid", "short", "long", "user_id", "is_deleted"}, tracked)
+	if err != nil {
+		return fmt.Errorf("failed to track migrated records: %w", err)
+	}
+	if _, err = tx.Exec(ctx, progressStmt, source, len(records)); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// countMigrated counts the records tracked as migrated whose rows are still in urls as copied.
+func (s *DBStore) countMigrated(ctx context.Context, source string, records []URLRecord) (int, error) {
+	const stmt = `SELECT COUNT(*) FROM unnest($2::text[], $3::text[]) AS f(uuid, short)
+		JOIN file_migration_records m ON m.source = $1 AND m.uuid = f.uuid AND m.short = f.short
+		WHERE EXISTS (SELECT 1 FROM urls u WHERE u.short = m.short AND u.long = m.long
+			AND u.user_id = m.user_id AND u.is_deleted = m.is_deleted)`
+	uuids := make([]string, 0, len(records))
+	shorts := make([]string, 0, len(records))
+	for _, r := range records {
+		uuids = append(uuids, r.UUID)
+		shorts = append(shorts, r.ShortURL)
+	}
+	var n int
+	if err := s.pool.QueryRow(ctx, stmt, source, uuids, shorts).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *DBStore) finishMigration(ctx context.Context, source string) error {
+	const stmt = `UPDATE file_migrations SET finished_at = NOW() WHERE source = $1`
+	_, err := s.pool.Exec(ctx, stmt, source)
+	return err
+}
+
+// Close closes the connection pool.
+func (s *DBStore) Close() {
+	s.pool.Close()
+}
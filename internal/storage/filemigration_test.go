@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrationTarget keeps the copied rows and the migrated keys in memory.
+type fakeMigrationTarget struct {
+	rows     []URLRecord
+	migrated map[migrationKey]URLRecord
+	// failAt makes the copy with this number fail, counting from one.
+	failAt   int
+	copies   int
+	finished bool
+}
+
+func (f *fakeMigrationTarget) startMigration(context.Context, string, int) error {
+	if f.migrated == nil {
+		f.migrated = make(map[migrationKey]URLRecord)
+	}
+	return nil
+}
+
+func (f *fakeMigrationTarget) migratedKeys(context.Context, string) (map[migrationKey]struct{}, error) {
+	keys := make(map[migrationKey]struct{}, len(f.migrated))
+	for key := range f.migrated {
+		keys[key] = struct{}{}
+	}
+	return keys, nil
+}
+
+func (f *fakeMigrationTarget) copyRecords(_ context.Context, _ string, records []URLRecord) error {
+	f.copies++
+	if f.copies == f.failAt {
+		return errors.New("connection lost")
+	}
+	for _, r := range records {
+		f.rows = append(f.rows, r)
+		f.migrated[keyOf(r)] = r
+	}
+	return nil
+}
+
+func (f *fakeMigrationTarget) countMigrated(_ context.Context, _ string, records []URLRecord) (int, error) {
+	n := 0
+	for _, r := range records {
+		m, ok := f.migrated[keyOf(r)]
+		if !ok {
+			continue
+		}
+		for _, row := range f.rows {
+			if row.ShortURL == m.ShortURL && row.OriginalURL == m.OriginalURL && row.Deleted == m.Deleted {
+				n++
+				break
+			}
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeMigrationTarget) finishMigration(context.Context, string) error {
+	f.finished = true
+	return nil
+}
+
+func migrationRecords(n int) []URLRecord {
+	records := make([]URLRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		records = append(records, URLRecord{
+			UUID:        fmt.Sprint(i),
+			ShortURL:    fmt.Sprintf("short%d", i),
+			OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+		})
+	}
+	return records
+}
+
+func TestMigrateFileRecords_Resume(t *testing.T) {
+	ctx := context.Background()
+	records := migrationRecords(5)
+	target := &fakeMigrationTarget{failAt: 2}
+
+	res, err := migrateFileRecords(ctx, target, "source", records, 2, nil)
+	require.Error(t, err)
+	assert.Equal(t, FileMigrationResult{Total: 5, Copied: 2}, res)
+	assert.False(t, target.finished)
+
+	// the file gives the records in another order on the next run
+	reordered := []URLRecord{records[4], records[1], records[3], records[0], records[2]}
+	var reported []int
+	res, err = migrateFileRecords(ctx, target, "source", reordered, 2, func(copied, total int) {
+		assert.Equal(t, 5, total)
+		reported = append(reported, copied)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FileMigrationResult{Total: 5, Resumed: 2, Copied: 3}, res)
+	assert.Equal(t, []int{4, 5}, reported)
+	assert.Len(t, target.rows, 5, "no record is copied twice")
+	assert.True(t, target.finished)
+
+	res, err = migrateFileRecords(ctx, target, "source", reordered, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, FileMigrationResult{Total: 5, Resumed: 5}, res)
+}
+
+func TestMigrateFileRecords_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(target *fakeMigrationTarget)
+		wantErr error
+	}{
+		{
+			name: "rows written by others",
+			prepare: func(target *fakeMigrationTarget) {
+				target.rows = append(target.rows, URLRecord{ShortURL: "other", OriginalURL: "https://example.com/other"})
+			},
+		},
+		{
+			name: "migrated row is gone",
+			prepare: func(target *fakeMigrationTarget) {
+				target.rows = target.rows[1:]
+			},
+			wantErr: ErrCountMismatch,
+		},
+		{
+			name: "migrated row is changed",
+			prepare: func(target *fakeMigrationTarget) {
+				target.rows[0].Deleted = true
+			},
+			wantErr: ErrCountMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			records := migrationRecords(3)
+			// the first run is interrupted before the check, the second one only verifies
+			target := &fakeMigrationTarget{failAt: 2}
+			_, err := migrateFileRecords(ctx, target, "source", records, 2, nil)
+			require.Error(t, err)
+			_, err = migrateFileRecords(ctx, target, "source", records, 2, nil)
+			require.NoError(t, err)
+
+			tt.prepare(target)
+			_, err = migrateFileRecords(ctx, target, "source", records, 2, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMigrateFileRecords_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+	db, err := New(ctx, dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.pool.Exec(ctx, `TRUNCATE urls, file_migrations, file_migration_records RESTART IDENTITY`)
+	require.NoError(t, err)
+
+	records := migrationRecords(5)
+	res, err := db.MigrateFileRecords(ctx, "source", records[:3], 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, FileMigrationResult{Total: 3, Copied: 3}, res)
+
+	_, err = db.pool.Exec(ctx, `INSERT INTO urls (short, long) VALUES ('other', 'https://example.com/other')`)
+	require.NoError(t, err)
+	reordered := []URLRecord{records[4], records[2], records[3], records[0], records[1]}
+	res, err = db.MigrateFileRecords(ctx, "source", reordered, 2, nil)
+	require.NoError(t, err, "rows written by others do not fail the check")
+	assert.Equal(t, FileMigrationResult{Total: 5, Resumed: 3, Copied: 2}, res)
+
+	_, err = db.pool.Exec(ctx, `DELETE FROM urls WHERE short = 'short1'`)
+	require.NoError(t, err)
+	_, err = db.MigrateFileRecords(ctx, "source", records, 2, nil)
+	assert.ErrorIs(t, err, ErrCountMismatch)
+}
@@ -0,0 +1,424 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// Buckets of the key-value storage.
+var (
+	// bucketURLs maps the short URL to its record, deleted ones included.
+	bucketURLs = []byte("urls")
+	// bucketLongs maps the long URL to the short URL of its live record.
+	bucketLongs = []byte("longs")
+	// bucketUserURLs holds the user ID and the short URL of every record joined by kvSep.
+	bucketUserURLs = []byte("user_urls")
+	// bucketClicks maps the short URL and a big-endian sequence joined by kvSep to the click event.
+	bucketClicks = []byte("clicks")
+	// bucketAPIKeys maps the key hash to the API key.
+	bucketAPIKeys = []byte("api_keys")
+	// bucketAccounts maps the login to the account.
+	bucketAccounts = []byte("accounts")
+	// bucketDisabledUsers holds the IDs of the disabled users.
+	bucketDisabledUsers = []byte("disabled_users")
+	// bucketSequence backs the short code sequence with its own bucket sequence.
+	bucketSequence = []byte("sequence")
+)
+
+// kvSep separates the parts of composite keys, it can not occur in user IDs and short URLs.
+const kvSep = 0
+
+// kvOpenTimeout limits the wait for the file lock held by another process.
+const kvOpenTimeout = time.Second
+
+// errDuplicateLongURL error indicates a live record already shortens the long URL.
+var errDuplicateLongURL = errors.New("long url already shortened")
+
+// inKV represents an embedded key-value URL storage.
+type inKV struct {
+	db  *bolt.DB
+	cfg *config.Config
+	log *logger.Log
+}
+
+// newKV opens the key-value storage file creating it and its buckets when missing.
+func newKV(cfg *config.Config, log *logger.Log) (*inKV, error) {
+	if err := prepareDir(cfg.App.KVStoragePath); err != nil {
+		return nil, fmt.Errorf("failed to prepare directory: %w", err)
+	}
+	db, err := bolt.Open(cfg.App.KVStoragePath, 0600, &bolt.Options{Timeout: kvOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kv storage: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{
+			bucketURLs, bucketLongs, bucketUserURLs, bucketClicks,
+			bucketAPIKeys, bucketAccounts, bucketDisabledUsers, bucketSequence,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return &inKV{db: db, cfg: cfg, log: log}, nil
+}
+
+// compositeKey joins the parts of a key by kvSep.
+func compositeKey(first string, rest ...[]byte) []byte {
+	key := append([]byte(first), kvSep)
+	for i, part := range rest {
+		if i > 0 {
+			key = append(key, kvSep)
+		}
+		key = append(key, part...)
+	}
+	return key
+}
+
+// getRecord reads the record of the short URL.
+func getRecord(tx *bolt.Tx, shortLink string) (URLRecord, bool, error) {
+	data := tx.Bucket(bucketURLs).Get([]byte(shortLink))
+	if data == nil {
+		return URLRecord{}, false, nil
+	}
+	var r URLRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return URLRecord{}, false, fmt.Errorf("failed to unmarshal record %s: %w", shortLink, err)
+	}
+	return r, true, nil
+}
+
+// putRecord stores the record and moves its index entries from the replaced one.
+// A record without UUID gets the next value of the records bucket sequence.
+func putRecord(tx *bolt.Tx, r URLRecord) error {
+	urls := tx.Bucket(bucketURLs)
+	prev, ok, err := getRecord(tx, r.ShortURL)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err = unindexRecord(tx, prev); err != nil {
+			return err
+		}
+	}
+	if r.UUID == "" {
+		id, err := urls.NextSequence()
+		if err != nil {
+			return fmt.Errorf("failed to get record id: %w", err)
+		}
+		r.UUID = strconv.FormatUint(id, 10)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	if err = urls.Put([]byte(r.ShortURL), data); err != nil {
+		return fmt.Errorf("failed to put record: %w", err)
+	}
+	if err = tx.Bucket(bucketUserURLs).Put(compositeKey(r.UserID, []byte(r.ShortURL)), nil); err != nil {
+		return fmt.Errorf("failed to index user url: %w", err)
+	}
+	if !r.Deleted {
+		if err = tx.Bucket(bucketLongs).Put([]byte(r.OriginalURL), []byte(r.ShortURL)); err != nil {
+			return fmt.Errorf("failed to index long url: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteRecord removes the record, its index entries and its click events.
+func deleteRecord(tx *bolt.Tx, r URLRecord) error {
+	if err := unindexRecord(tx, r); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketURLs).Delete([]byte(r.ShortURL)); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	clicks := tx.Bucket(bucketClicks)
+	prefix := compositeKey(r.ShortURL)
+	c := clicks.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := c.Delete(); err != nil {
+			return fmt.Errorf("failed to delete clicks: %w", err)
+		}
+	}
+	return nil
+}
+
+func unindexRecord(tx *bolt.Tx, r URLRecord) error {
+	if err := tx.Bucket(bucketUserURLs).Delete(compositeKey(r.UserID, []byte(r.ShortURL))); err != nil {
+		return fmt.Errorf("failed to unindex user url: %w", err)
+	}
+	longs := tx.Bucket(bucketLongs)
+	if !r.Deleted && string(longs.Get([]byte(r.OriginalURL))) == r.ShortURL {
+		if err := longs.Delete([]byte(r.OriginalURL)); err != nil {
+			return fmt.Errorf("failed to unindex long url: %w", err)
+		}
+	}
+	return nil
+}
+
+// userRecords passes the records of the user with the short URL after the given one to fn in its order
+// until fn returns false.
+func userRecords(tx *bolt.Tx, userID, after string, fn func(URLRecord) bool) error {
+	prefix := compositeKey(userID)
+	c := tx.Bucket(bucketUserURLs).Cursor()
+	k, _ := c.Seek(compositeKey(userID, []byte(after)))
+	for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		short := string(k[len(prefix):])
+		if short == after {
+			continue
+		}
+		r, ok, err := getRecord(tx, short)
+		if err != nil {
+			return err
+		}
+		if ok && !fn(r) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// insertRecord stores a new live record failing like the unique indexes of the database do.
+//
+// A live record for the same long URL that has already expired is retired so the URL can be shortened again.
+func insertRecord(tx *bolt.Tx, r URLRecord, now time.Time) error {
+	prev, ok, err := getRecord(tx, r.ShortURL)
+	if err != nil {
+		return err
+	}
+	if ok && !prev.Deleted {
+		return fmt.Errorf("short %s: %w", r.ShortURL, service.ErrShortURLExists)
+	}
+	if existing := tx.Bucket(bucketLongs).Get([]byte(r.OriginalURL)); existing != nil {
+		live, ok, err := getRecord(tx, string(existing))
+		if err != nil {
+			return err
+		}
+		if ok && !live.Expired(now) {
+			return &DuplicateRecordError{Message: live.ShortURL, Err: errDuplicateLongURL}
+		}
+		if ok {
+			live.Deleted = true
+			if err = putRecord(tx, live); err != nil {
+				return err
+			}
+		}
+	}
+	return putRecord(tx, r)
+}
+
+// Get retrieves a URL by its short link from the key-value storage.
+func (k *inKV) Get(_ context.Context, shortLink string) (string, error) {
+	var long string
+	err := k.db.View(func(tx *bolt.Tx) error {
+		r, ok, err := getRecord(tx, shortLink)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			return service.ErrURLNotFound
+		case r.Deleted:
+			return ErrURLDeleted
+		case r.Expired(time.Now()):
+			return ErrURLExpired
+		}
+		long = r.OriginalURL
+		return nil
+	})
+	return long, err
+}
+
+// Save saves a new URL record to the key-value storage.
+func (k *inKV) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	r := URLRecord{ShortURL: shortLink, OriginalURL: longLink, UserID: userID, ExpiresAt: expiresAt}
+	return k.db.Update(func(tx *bolt.Tx) error {
+		return insertRecord(tx, r, time.Now())
+	})
+}
+
+// BatchSave saves multiple URL records to the key-value storage in a single transaction.
+func (k *inKV) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	resp := make(models.BatchArray, 0, len(input))
+	for _, in := range input {
+		shortURL, err := url.JoinPath(k.cfg.App.BaseURL, "/", in.ShortURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join url: %w", err)
+		}
+		resp = append(resp, models.Batch{
+			CorrelationID: in.CorrelationID,
+			ShortURL:      shortURL,
+			OriginalURL:   in.OriginalURL,
+			ExpiresAt:     in.ExpiresAt,
+		})
+	}
+	now := time.Now()
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		for _, in := range input {
+			r := URLRecord{ShortURL: in.ShortURL, OriginalURL: in.OriginalURL, UserID: userID, ExpiresAt: in.ExpiresAt}
+			if err := insertRecord(tx, r, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save batch: %w", err)
+	}
+	return resp, nil
+}
+
+// GetByUserID retrieves live URLs of the user from the context ordered by the short link.
+func (k *inKV) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
+	return k.GetByUserIDPage(ctx, "", 0)
+}
+
+// GetByUserIDPage retrieves up to limit URLs of the user with the short link after the given one, ordered by it.
+// Zero limit returns all of them.
+func (k *inKV) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	var data []models.BaseRow
+	err := k.db.View(func(tx *bolt.Tx) error {
+		return userRecords(tx, userID, after, func(r URLRecord) bool {
+			if !r.Deleted {
+				data = append(data, models.BaseRow{Short: r.ShortURL, Long: r.OriginalURL})
+			}
+			return limit == 0 || len(data) < limit
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed get urls for user_id = %s: %w", userID, err)
+	}
+	return data, nil
+}
+
+// DeleteURLs marks URLs of the user from the context as deleted in the key-value storage.
+func (k *inKV) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	return k.db.Update(func(tx *bolt.Tx) error {
+		for _, short := range input {
+			r, ok, err := getRecord(tx, short)
+			if err != nil {
+				return err
+			}
+			if !ok || r.UserID != userID || r.Deleted {
+				continue
+			}
+			r.Deleted = true
+			if err = putRecord(tx, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Cleanup removes deleted and expired URLs with their click events from the key-value storage.
+func (k *inKV) Cleanup(_ context.Context) ([]string, error) {
+	result := make([]string, 0)
+	now := time.Now()
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		var stale []URLRecord
+		err := tx.Bucket(bucketURLs).ForEach(func(_, data []byte) error {
+			var r URLRecord
+			if err := json.Unmarshal(data, &r); err != nil {
+				return fmt.Errorf("failed to unmarshal record: %w", err)
+			}
+			if r.Deleted || r.Expired(now) {
+				stale = append(stale, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range stale {
+			if err = deleteRecord(tx, r); err != nil {
+				return err
+			}
+			result = append(result, r.ShortURL)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cleanup urls: %w", err)
+	}
+	return result, nil
+}
+
+// ServiceStats returns the number of distinct long URLs and users.
+func (k *inKV) ServiceStats(_ context.Context) (models.Stats, error) {
+	longs := make(map[string]struct{})
+	users := make(map[string]struct{})
+	err := k.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketURLs).ForEach(func(_, data []byte) error {
+			var r URLRecord
+			if err := json.Unmarshal(data, &r); err != nil {
+				return fmt.Errorf("failed to unmarshal record: %w", err)
+			}
+			longs[r.OriginalURL] = struct{}{}
+			users[r.UserID] = struct{}{}
+			return nil
+		})
+	})
+	if err != nil {
+		return models.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return models.Stats{URLs: len(longs), Users: len(users)}, nil
+}
+
+// Ping checks the key-value storage can start a transaction.
+func (k *inKV) Ping(_ context.Context) error {
+	return k.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketURLs) == nil {
+			return errors.New("urls bucket is missing")
+		}
+		return nil
+	})
+}
+
+// Close closes the key-value storage file.
+func (k *inKV) Close() error {
+	if err := k.db.Close(); err != nil {
+		return fmt.Errorf("failed to close kv storage: %w", err)
+	}
+	return nil
+}
+
+// sequenceKey encodes the sequence value so the keys sort by it.
+func sequenceKey(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+func newTestKV(t *testing.T, filePath string) *inKV {
+	t.Helper()
+	log := &logger.Log{}
+	log.Initialize("ERROR")
+	cfg := &config.Config{App: config.AppConfig{KVStoragePath: filePath, BaseURL: "http://localhost:8080"}}
+	kv, err := newKV(cfg, log)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = kv.Close()
+	})
+	return kv
+}
+
+func TestKV_SaveGet(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	past := time.Now().Add(-time.Minute)
+
+	require.NoError(t, kv.Save(ctx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, kv.Save(ctx, "exp", "https://example.com/expired", &past))
+
+	tests := []struct {
+		name    string
+		short   string
+		long    string
+		wantErr error
+	}{
+		{name: "live", short: "aaa", long: "https://example.com/a"},
+		{name: "expired", short: "exp", wantErr: ErrURLExpired},
+		{name: "missing", short: "zzz", wantErr: service.ErrURLNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			long, err := kv.Get(ctx, tt.short)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.long, long)
+		})
+	}
+
+	t.Run("taken short", func(t *testing.T) {
+		err := kv.Save(ctx, "aaa", "https://example.com/other", nil)
+		assert.ErrorIs(t, err, service.ErrShortURLExists)
+	})
+	t.Run("duplicate long", func(t *testing.T) {
+		err := kv.Save(ctx, "bbb", "https://example.com/a", nil)
+		var dup *DuplicateRecordError
+		require.ErrorAs(t, err, &dup)
+		assert.Equal(t, "aaa", dup.Message)
+	})
+	t.Run("long of an expired url", func(t *testing.T) {
+		require.NoError(t, kv.Save(ctx, "new", "https://example.com/expired", nil))
+		r, err := kv.GetURLRecord(ctx, "exp")
+		require.NoError(t, err)
+		assert.True(t, r.Deleted, "expired record is retired")
+	})
+	t.Run("deleted short is reused", func(t *testing.T) {
+		require.NoError(t, kv.DeleteURLs(ctx, models.DeleteURLs{"aaa"}))
+		_, err := kv.Get(ctx, "aaa")
+		assert.ErrorIs(t, err, ErrURLDeleted)
+		require.NoError(t, kv.Save(ctx, "aaa", "https://example.com/a", nil))
+	})
+}
+
+func TestKV_BatchSave(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+
+	saved, err := kv.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "1", ShortURL: "aaa", OriginalURL: "https://example.com/a"},
+		{CorrelationID: "2", ShortURL: "bbb", OriginalURL: "https://example.com/b"},
+	})
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.Equal(t, "http://localhost:8080/aaa", saved[0].ShortURL)
+
+	_, err = kv.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "3", ShortURL: "ccc", OriginalURL: "https://example.com/c"},
+		{CorrelationID: "4", ShortURL: "aaa", OriginalURL: "https://example.com/d"},
+	})
+	assert.ErrorIs(t, err, service.ErrShortURLExists)
+	_, err = kv.Get(ctx, "ccc")
+	assert.ErrorIs(t, err, service.ErrURLNotFound, "failed batch is rolled back")
+}
+
+func TestKV_UserURLs(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx1 := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	ctx2 := context.WithValue(context.Background(), models.CtxUserIDKey, user2)
+	for _, short := range []string{"c", "a", "d", "b"} {
+		require.NoError(t, kv.Save(ctx1, short, "https://example.com/"+short, nil))
+	}
+	require.NoError(t, kv.Save(ctx2, "e", "https://example.com/e", nil))
+	require.NoError(t, kv.DeleteURLs(ctx2, models.DeleteURLs{"a"}), "foreign url is left as is")
+	require.NoError(t, kv.DeleteURLs(ctx1, models.DeleteURLs{"c"}))
+
+	rows, err := kv.GetByUserID(ctx1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.BaseRow{
+		{Short: "a", Long: "https://example.com/a"},
+		{Short: "b", Long: "https://example.com/b"},
+		{Short: "d", Long: "https://example.com/d"},
+	}, rows)
+
+	page, err := kv.GetByUserIDPage(ctx1, "a", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.BaseRow{{Short: "b", Long: "https://example.com/b"}}, page)
+
+	records, err := kv.ListURLsByUser(ctx1, user1)
+	require.NoError(t, err)
+	assert.Len(t, records, 4, "deleted records are listed")
+
+	stats, err := kv.ServiceStats(ctx1)
+	require.NoError(t, err)
+	assert.Equal(t, models.Stats{URLs: 5, Users: 2}, stats)
+}
+
+func TestKV_Cleanup(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, kv.Save(ctx, "live", "https://example.com/live", nil))
+	require.NoError(t, kv.Save(ctx, "gone", "https://example.com/gone", nil))
+	require.NoError(t, kv.Save(ctx, "exp", "https://example.com/exp", &past))
+	require.NoError(t, kv.DeleteURLs(ctx, models.DeleteURLs{"gone"}))
+	now := time.Now()
+	require.NoError(t, kv.SaveClicks(ctx, []models.Click{
+		{Short: "live", Time: now}, {Short: "gone", Time: now}, {Short: "live", Time: now},
+	}))
+
+	cleaned, err := kv.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"gone", "exp"}, cleaned)
+
+	stats, err := kv.ClickStats(ctx, "live")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	_, err = kv.ClickStats(ctx, "gone")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+	require.NoError(t, kv.Save(ctx, "again", "https://example.com/gone", nil), "long of a purged url is free")
+}
+
+func TestKV_ImportExport(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx := context.Background()
+	records := []models.URLRecord{
+		{ShortURL: "aaa", OriginalURL: "https://example.com/a", UserID: "u1"},
+		{ShortURL: "bbb", OriginalURL: "https://example.com/b", UserID: "u2", Deleted: true},
+	}
+	res, err := kv.ImportURLs(ctx, records, models.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Imported: 2}, res)
+
+	changed := []models.URLRecord{
+		{ShortURL: "aaa", OriginalURL: "https://example.com/changed", UserID: "u1"},
+		{ShortURL: "ccc", OriginalURL: "https://example.com/c", UserID: "u3"},
+	}
+	_, err = kv.ImportURLs(ctx, changed, models.ConflictFail)
+	assert.ErrorIs(t, err, service.ErrImportConflict)
+	_, err = kv.Get(ctx, "ccc")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+	res, err = kv.ImportURLs(ctx, changed, models.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Imported: 1, Skipped: 1}, res)
+
+	res, err = kv.ImportURLs(ctx, changed, models.ConflictOverwrite)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Overwritten: 2}, res)
+	long, err := kv.Get(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/changed", long)
+
+	res, err = kv.ImportURLs(ctx, []models.URLRecord{
+		{ShortURL: "ddd", OriginalURL: "https://example.com/c", UserID: "u3"},
+	}, models.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Skipped: 1}, res, "live long url conflicts")
+
+	var exported []string
+	require.NoError(t, kv.ExportURLs(ctx, func(r models.URLRecord) error {
+		exported = append(exported, r.ShortURL)
+		return nil
+	}))
+	assert.Equal(t, []string{"aaa", "bbb", "ccc"}, exported)
+}
+
+func TestKV_AccountsAndKeys(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	anonCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "anonymous")
+	account := models.Account{ID: "account-1", Login: "alice", PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+
+	require.NoError(t, kv.CreateAccount(anonCtx, account))
+	assert.ErrorIs(t, kv.CreateAccount(anonCtx, account), service.ErrLoginTaken)
+	got, err := kv.GetAccount(anonCtx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, got.ID)
+	_, err = kv.GetAccount(anonCtx, "bob")
+	assert.ErrorIs(t, err, service.ErrAccountNotFound)
+
+	require.NoError(t, kv.Save(anonCtx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, kv.Save(anonCtx, "bbb", "https://example.com/b", nil))
+	require.NoError(t, kv.DeleteURLs(anonCtx, models.DeleteURLs{"bbb"}))
+	claimed, err := kv.ClaimURLs(anonCtx, account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	accountCtx := context.WithValue(context.Background(), models.CtxUserIDKey, account.ID)
+	rows, err := kv.GetByUserID(accountCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.BaseRow{{Short: "aaa", Long: "https://example.com/a"}}, rows)
+
+	key := models.APIKey{ID: "key-1", Name: "ci", Prefix: "pfx", Hash: "hash-1", CreatedAt: time.Now().UTC()}
+	require.NoError(t, kv.SaveAPIKey(accountCtx, key))
+	keys, err := kv.ListAPIKeys(accountCtx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, account.ID, keys[0].UserID)
+	assert.ErrorIs(t, kv.RevokeAPIKey(anonCtx, "key-1"), service.ErrAPIKeyNotFound)
+	require.NoError(t, kv.RevokeAPIKey(accountCtx, "key-1"))
+	got2, err := kv.GetAPIKey(anonCtx, "hash-1")
+	require.NoError(t, err)
+	assert.NotNil(t, got2.RevokedAt)
+}
+
+func TestKV_Admin(t *testing.T) {
+	kv := newTestKV(t, path.Join(t.TempDir(), "kv.db"))
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	require.NoError(t, kv.Save(ctx, "aaa", "https://example.com/a", nil))
+
+	require.NoError(t, kv.SetURLDeleted(ctx, "aaa", true))
+	require.NoError(t, kv.Save(ctx, "bbb", "https://example.com/a", nil))
+	assert.ErrorIs(t, kv.SetURLDeleted(ctx, "aaa", false), service.ErrRestoreConflict)
+	assert.ErrorIs(t, kv.SetURLDeleted(ctx, "zzz", true), service.ErrURLNotFound)
+	require.NoError(t, kv.SetURLDeleted(ctx, "bbb", true))
+	require.NoError(t, kv.SetURLDeleted(ctx, "aaa", false))
+
+	require.NoError(t, kv.SetUserDisabled(ctx, user1, true))
+	disabled, err := kv.IsUserDisabled(ctx, user1)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	require.NoError(t, kv.SetUserDisabled(ctx, user1, false))
+	disabled, err = kv.IsUserDisabled(ctx, user1)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+}
+
+func TestKV_Reopen(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "kv.db")
+	kv := newTestKV(t, filePath)
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	require.NoError(t, kv.Save(ctx, "aaa", "https://example.com/a", nil))
+	seq, err := kv.NextSequence(ctx)
+	require.NoError(t, err)
+	require.NoError(t, kv.Ping(ctx))
+	require.NoError(t, kv.Close())
+
+	kv = newTestKV(t, filePath)
+	long, err := kv.Get(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", long)
+	next, err := kv.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, seq+1, next, "sequence survives the restart")
+}
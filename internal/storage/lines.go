@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// The log and the files kept next to it hold a line per record: the hex CRC-32C of the JSON, a space and the JSON.
+
+// encodeLine returns the line of the value.
+func encodeLine(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal line: %w", err)
+	}
+	line := make([]byte, 0, checksumLen+1+len(data)+1)
+	line = fmt.Appendf(line, "%08x ", crc32.Checksum(data, crcTable))
+	line = append(line, data...)
+	return append(line, '\n'), nil
+}
+
+// decodeLine checks the line without its newline and returns its JSON.
+//
+// A line holding bare JSON is the format before the checksums and is returned as is.
+func decodeLine(line []byte) ([]byte, error) {
+	if isLegacyLine(line) {
+		return line, nil
+	}
+	if len(line) < checksumLen+1 || line[checksumLen] != ' ' {
+		return nil, fmt.Errorf("no checksum: %w", errBadRecord)
+	}
+	var sum [4]byte
+	if _, err := hex.Decode(sum[:], line[:checksumLen]); err != nil {
+		return nil, fmt.Errorf("checksum: %w", errBadRecord)
+	}
+	data := line[checksumLen+1:]
+	if crc32.Checksum(data, crcTable) != uint32(sum[0])<<24|uint32(sum[1])<<16|uint32(sum[2])<<8|uint32(sum[3]) {
+		return nil, fmt.Errorf("checksum mismatch: %w", errBadRecord)
+	}
+	return data, nil
+}
+
+// isLegacyLine reports whether the line holds bare JSON without a checksum.
+func isLegacyLine(line []byte) bool {
+	return len(line) > 0 && line[0] == '{'
+}
+
+// readLines calls fn with every line of r without its newline and returns the size of the valid part
+// and the number of lines in it. fn reports a line it can not decode with errBadRecord.
+//
+// The lines end at the first one cut short or failing its checksum, which a crash in the middle of
+// an append leaves behind. Such a line followed by more lines fails with ErrCorruptLog instead.
+func readLines(r io.Reader, fn func(line []byte) error) (int64, int, error) {
+	reader := bufio.NewReader(r)
+	var (
+		valid int64
+		lines int
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// an empty or a torn last line
+			return valid, lines, nil
+		}
+		if err != nil {
+			return valid, lines, fmt.Errorf("failed to read file: %w", err)
+		}
+		if err = fn(bytes.TrimSuffix(line, []byte{'\n'})); err != nil {
+			if !errors.Is(err, errBadRecord) {
+				return valid, lines, err
+			}
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				return valid, lines, nil
+			}
+			return valid, lines, fmt.Errorf("offset %d: %w: %w", valid, ErrCorruptLog, err)
+		}
+		valid += int64(len(line))
+		lines++
+	}
+}
+
+// truncateTail cuts the file down to its valid part and syncs it, so the next append does not
+// continue a torn line. It returns the number of bytes dropped.
+func truncateTail(file *os.File, valid int64) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	dropped := info.Size() - valid
+	if dropped <= 0 {
+		return 0, nil
+	}
+	if err = file.Truncate(valid); err != nil {
+		return 0, fmt.Errorf("failed to truncate torn tail: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync file: %w", err)
+	}
+	return dropped, nil
+}
+
+// appendJSONLines appends the values as lines to the file created with owner only permissions.
+// A failed write is cut off, so it does not glue to the next line.
+func appendJSONLines[T any](filename string, values []T) error {
+	data := make([]byte, 0)
+	for _, v := range values {
+		line, err := encodeLine(v)
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to stat file: %w", err), file.Close())
+	}
+	if _, err = file.Write(data); err != nil {
+		return errors.Join(fmt.Errorf("failed to write file: %w", err), file.Truncate(info.Size()), file.Close())
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
+
+// readJSONLines reads every line of the file and cuts off a torn tail. A missing file has no lines.
+func readJSONLines[T any](filename string) ([]T, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	values := make([]T, 0)
+	valid, _, err := readLines(file, func(line []byte) error {
+		data, err := decodeLine(line)
+		if err != nil {
+			return err
+		}
+		var v T
+		if err = json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("line: %w", errBadRecord)
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	if _, err = truncateTail(file, valid); err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	if err = file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close file: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/models"
+)
+
+func TestJSONLines_TornTail(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename+clicksFileSuffix)
+	clicks := []models.Click{
+		{Short: "aaa", Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Short: "bbb", Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	require.NoError(t, appendJSONLines(filePath, clicks))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	for offset := 0; offset <= len(data); offset++ {
+		require.NoError(t, os.WriteFile(filePath, data[:offset], 0600))
+		complete := bytes.LastIndexByte(data[:offset], '\n') + 1
+
+		read, err := readJSONLines[models.Click](filePath)
+		require.NoError(t, err, "offset %d", offset)
+		assert.Len(t, read, bytes.Count(data[:complete], []byte{'\n'}), "offset %d", offset)
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(complete), info.Size(), "torn tail is cut off at offset %d", offset)
+
+		require.NoError(t, appendJSONLines(filePath, clicks[:1]))
+		read, err = readJSONLines[models.Click](filePath)
+		require.NoError(t, err, "append after recovery at offset %d", offset)
+		assert.Equal(t, clicks[0].Short, read[len(read)-1].Short)
+	}
+}
+
+func TestJSONLines_Read(t *testing.T) {
+	line, err := encodeLine(models.APIKey{ID: "key1"})
+	require.NoError(t, err)
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr error
+	}{
+		{name: "missing file"},
+		{name: "framed lines", data: string(line) + string(line), want: []string{"key1", "key1"}},
+		{name: "legacy lines", data: `{"id":"key0"}` + "\n" + string(line), want: []string{"key0", "key1"}},
+		{name: "changed last line", data: string(line) + string(line[:len(line)-3]) + "X}\n", want: []string{"key1"}},
+		{
+			name:    "changed line in the middle",
+			data:    string(line[:len(line)-3]) + "X}\n" + string(line),
+			wantErr: ErrCorruptLog,
+		},
+		{name: "garbage line in the middle", data: "garbage\n" + string(line), wantErr: ErrCorruptLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := path.Join(t.TempDir(), filename+apiKeysFileSuffix)
+			if tt.data != "" {
+				require.NoError(t, os.WriteFile(filePath, []byte(tt.data), 0600))
+			}
+			keys, err := readJSONLines[models.APIKey](filePath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var ids []string
+			for _, k := range keys {
+				ids = append(ids, k.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestAppendJSONLines_Permissions(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename+accountsFileSuffix)
+	require.NoError(t, appendJSONLines(filePath, []models.Account{{ID: "acc1"}}))
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
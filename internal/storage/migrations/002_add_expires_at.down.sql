@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_expires_at;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS expires_at;
+
+COMMIT;
\ No newline at end of file
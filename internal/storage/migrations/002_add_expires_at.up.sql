@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_expires_at ON urls (expires_at) WHERE expires_at IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_clicks_short_clicked_at;
+
+DROP TABLE IF EXISTS clicks;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS clicks (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    short VARCHAR(200) NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_short_clicked_at ON clicks (short, clicked_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+CREATE SEQUENCE IF NOT EXISTS short_code_seq;
+
+COMMIT;
\ No newline at end of file
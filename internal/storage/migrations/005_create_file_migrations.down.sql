@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS file_migrations;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS file_migrations (
+    source TEXT PRIMARY KEY,
+    total INT NOT NULL,
+    copied INT NOT NULL DEFAULT 0,
+    base_count BIGINT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+DROP INDEX IF EXISTS idx_api_keys_hash;
+
+DROP TABLE IF EXISTS api_keys;
+
+COMMIT;
\ No newline at end of file
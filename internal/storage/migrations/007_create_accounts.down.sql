@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_accounts_login;
+
+DROP TABLE IF EXISTS accounts;
+
+COMMIT;
\ No newline at end of file
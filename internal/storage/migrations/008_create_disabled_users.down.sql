@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS disabled_users;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS disabled_users (
+    user_id VARCHAR(200) PRIMARY KEY,
+    disabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
\ No newline at end of file
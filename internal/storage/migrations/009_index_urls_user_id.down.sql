@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_user_id_short;
+
+COMMIT;
\ No newline at end of file
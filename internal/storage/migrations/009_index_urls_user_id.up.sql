@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+CREATE INDEX IF NOT EXISTS idx_user_id_short ON urls (user_id, short) WHERE is_deleted = FALSE;
+
+COMMIT;
\ No newline at end of file
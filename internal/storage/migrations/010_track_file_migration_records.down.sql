@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS file_migration_records;
+
+ALTER TABLE file_migrations ADD COLUMN IF NOT EXISTS base_count BIGINT NOT NULL DEFAULT 0;
+
+COMMIT;
\ No newline at end of file
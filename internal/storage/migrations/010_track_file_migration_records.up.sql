@@ -0,0 +1,15 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS file_migration_records (
+    source TEXT NOT NULL,
+    uuid TEXT NOT NULL,
+    short VARCHAR(200) NOT NULL,
+    long VARCHAR(200) NOT NULL,
+    user_id VARCHAR(200) NOT NULL,
+    is_deleted BOOLEAN NOT NULL,
+    PRIMARY KEY (source, uuid, short)
+);
+
+ALTER TABLE file_migrations DROP COLUMN IF EXISTS base_count;
+
+COMMIT;
\ No newline at end of file
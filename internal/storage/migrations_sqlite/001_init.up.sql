@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS urls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    short VARCHAR(200) NOT NULL,
+    long VARCHAR(200) NOT NULL,
+    user_id VARCHAR(200) NOT NULL DEFAULT 'NULL_USER',
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_long_is_not_deleted ON urls (long) WHERE is_deleted = FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_short_is_not_deleted ON urls (short) WHERE is_deleted = FALSE;
@@ -0,0 +1,4 @@
+-- timestamps are stored as unix microseconds
+ALTER TABLE urls ADD COLUMN expires_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_expires_at ON urls (expires_at) WHERE expires_at IS NOT NULL;
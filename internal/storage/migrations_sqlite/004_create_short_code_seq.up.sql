@@ -0,0 +1,6 @@
+-- SQLite has no sequences, the single row keeps the last issued value
+CREATE TABLE IF NOT EXISTS short_code_seq (
+    value INTEGER NOT NULL
+);
+
+INSERT INTO short_code_seq (value) SELECT 0 WHERE NOT EXISTS (SELECT 1 FROM short_code_seq);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS file_migrations (
+    source TEXT PRIMARY KEY,
+    total INTEGER NOT NULL,
+    copied INTEGER NOT NULL DEFAULT 0,
+    base_count INTEGER NOT NULL,
+    started_at INTEGER NOT NULL,
+    finished_at INTEGER
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id VARCHAR(36) PRIMARY KEY,
+    login VARCHAR(64) NOT NULL,
+    password_hash VARCHAR(72) NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_login ON accounts (login);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS disabled_users (
+    user_id VARCHAR(200) PRIMARY KEY,
+    disabled_at INTEGER NOT NULL
+);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_user_id_short ON urls (user_id, short) WHERE is_deleted = FALSE;
@@ -5,11 +5,21 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
+	"sync"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"shortener/internal/tracing"
+)
+
+// Migration errors reported by CheckMigrations.
+var (
+	ErrMigrationDirty   = errors.New("migration failed halfway")
+	ErrMigrationPending = errors.New("migrations not applied")
 )
 
 // DBStore connect pool.
@@ -28,6 +38,7 @@ func initPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 	}
 	poolCfg.MinConns = minConns
 	poolCfg.MaxConns = maxConns
+	poolCfg.ConnConfig.Tracer = tracing.PgxTracer{}
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize a connection pool: %w", err)
@@ -37,7 +48,7 @@ func initPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 
 // New creates new DBStore.
 func New(ctx context.Context, dsn string) (*DBStore, error) {
-	if err := runMigrations(dsn); err != nil {
+	if err := runMigrations(migrationsDir, "migrations", dsn); err != nil {
 		return nil, fmt.Errorf("failed to run DB migrations: %w", err)
 	}
 
@@ -49,11 +60,17 @@ func New(ctx context.Context, dsn string) (*DBStore, error) {
 	return &DBStore{pool}, nil
 }
 
+// Stat returns the connection pool statistics.
+func (d *DBStore) Stat() *pgxpool.Stat {
+	return d.pool.Stat()
+}
+
 //go:embed migrations/*.sql
 var migrationsDir embed.FS
 
-func runMigrations(dsn string) error {
-	d, err := iofs.New(migrationsDir, "migrations")
+// runMigrations applies the migrations from the directory of the file system to the database of the DSN.
+func runMigrations(fsys fs.FS, dir, dsn string) error {
+	d, err := iofs.New(fsys, dir)
 	if err != nil {
 		return fmt.Errorf("failed to return an iofs driver: %w", err)
 	}
@@ -70,3 +87,60 @@ func runMigrations(dsn string) error {
 
 	return nil
 }
+
+// latestMigration returns the version of the last embedded migration.
+var latestMigration = sync.OnceValues(func() (uint, error) {
+	return lastMigration(migrationsDir, "migrations")
+})
+
+// lastMigration returns the version of the last migration in the directory of the file system.
+func lastMigration(fsys fs.FS, dir string) (uint, error) {
+	d, err := iofs.New(fsys, dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to return an iofs driver: %w", err)
+	}
+	version, err := d.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the first migration: %w", err)
+	}
+	for {
+		next, err := d.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read the next migration: %w", err)
+		}
+		version = next
+	}
+}
+
+// CheckMigrations reports an error unless the schema is at the last embedded migration.
+func (d *DBStore) CheckMigrations(ctx context.Context) error {
+	latest, err := latestMigration()
+	if err != nil {
+		return err
+	}
+	var (
+		version uint
+		dirty   bool
+	)
+	if err = d.pool.QueryRow(ctx, schemaVersionStmt).Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return checkSchemaVersion(version, latest, dirty)
+}
+
+// schemaVersionStmt reads the state golang-migrate keeps for the schema.
+const schemaVersionStmt = `SELECT version, dirty FROM schema_migrations`
+
+// checkSchemaVersion reports an error unless the schema is at the latest version.
+func checkSchemaVersion(version, latest uint, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("version %d: %w", version, ErrMigrationDirty)
+	}
+	if version < latest {
+		return fmt.Errorf("version %d of %d: %w", version, latest, ErrMigrationPending)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// NextSequence returns the next value of the database short code sequence.
+func (d *inDatabase) NextSequence(ctx context.Context) (uint64, error) {
+	const stmt = `SELECT nextval('short_code_seq')`
+	var n int64
+	if err := d.pool.QueryRow(ctx, stmt).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+	return uint64(n), nil
+}
+
+// NextSequence returns the next value of the in-memory counter.
+func (m *inMemory) NextSequence(_ context.Context) (uint64, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.sequence++
+	return m.sequence, nil
+}
+
+// NextSequence returns the next value of the file storage counter.
+//
+// Values are reserved in the log by blocks, so a restart skips the rest of the block
+// instead of issuing its values again.
+func (f *inFile) NextSequence(_ context.Context) (uint64, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.sequence >= f.sequenceLimit {
+		limit := f.sequence + sequenceBlock
+		if err := f.appendLog(walEntry{Op: opCounters, Sequence: limit}); err != nil {
+			return 0, fmt.Errorf("failed to reserve sequence values: %w", err)
+		}
+		f.sequenceLimit = limit
+	}
+	f.sequence++
+	return f.sequence, nil
+}
+
+// NextSequence returns the next value of the key-value storage sequence bucket.
+func (k *inKV) NextSequence(_ context.Context) (uint64, error) {
+	var n uint64
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.Bucket(bucketSequence).NextSequence()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+	return n, nil
+}
+
+// NextSequence returns the next value of the SQLite short code sequence row.
+func (s *inSQLite) NextSequence(ctx context.Context) (uint64, error) {
+	const stmt = `UPDATE short_code_seq SET value = value + 1 RETURNING value`
+	var n int64
+	if err := s.db.QueryRowContext(ctx, stmt).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get next sequence value: %w", err)
+	}
+	return uint64(n), nil
+}
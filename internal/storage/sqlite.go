@@ -0,0 +1,354 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// SQLiteScheme is the DatabaseDSN scheme selecting the SQLite storage, as in sqlite:///var/lib/shortener.db.
+const SQLiteScheme = "sqlite://"
+
+// sqlitePragmas are appended to the DSN of the storage connection.
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+
+//go:embed migrations_sqlite/*.sql
+var sqliteMigrationsDir embed.FS
+
+// latestSQLiteMigration returns the version of the last embedded SQLite migration.
+var latestSQLiteMigration = sync.OnceValues(func() (uint, error) {
+	return lastMigration(sqliteMigrationsDir, "migrations_sqlite")
+})
+
+// inSQLite represents a SQLite-based URL storage.
+type inSQLite struct {
+	db  *sql.DB
+	cfg *config.Config
+	log *logger.Log
+}
+
+// IsSQLiteDSN reports whether the DSN selects the SQLite storage.
+func IsSQLiteDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, SQLiteScheme)
+}
+
+// newSQLite applies the SQLite migrations and opens the database of the DSN.
+//
+// The database has a single connection, as SQLite allows one writer at a time anyway.
+func newSQLite(cfg *config.Config, log *logger.Log) (*inSQLite, error) {
+	dsn := cfg.App.DatabaseDSN
+	name, _, hasQuery := strings.Cut(strings.TrimPrefix(dsn, SQLiteScheme), "?")
+	if err := prepareDir(name); err != nil {
+		return nil, fmt.Errorf("failed to prepare directory: %w", err)
+	}
+	if err := runMigrations(sqliteMigrationsDir, "migrations_sqlite", dsn); err != nil {
+		return nil, fmt.Errorf("failed to run DB migrations: %w", err)
+	}
+	sep := "?"
+	if hasQuery {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite", strings.TrimPrefix(dsn, SQLiteScheme)+sep+sqlitePragmas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	return &inSQLite{db: db, cfg: cfg, log: log}, nil
+}
+
+// isUniqueViolation reports whether the error is a unique constraint failure on the column, as in "urls.long".
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), column)
+}
+
+// toMicros returns the time as unix microseconds, the way the SQLite schema keeps timestamps.
+func toMicros(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMicro()
+}
+
+// fromMicros returns the time of nullable unix microseconds.
+func fromMicros(micros sql.NullInt64) *time.Time {
+	if !micros.Valid {
+		return nil
+	}
+	t := time.UnixMicro(micros.Int64).UTC()
+	return &t
+}
+
+// rollback rolls the transaction back unless it is already committed.
+func (s *inSQLite) rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		s.log.Err("failed to rollback transaction: ", err)
+	}
+}
+
+// Get retrieves a URL by its short link from the database.
+func (s *inSQLite) Get(ctx context.Context, shortLink string) (string, error) {
+	const stmt = `SELECT long, is_deleted, expires_at FROM urls WHERE short = ? ORDER BY is_deleted LIMIT 1`
+	var (
+		long      string
+		isDeleted bool
+		expiresAt sql.NullInt64
+	)
+	err := s.db.QueryRowContext(ctx, stmt, shortLink).Scan(&long, &isDeleted, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", service.ErrURLNotFound
+		}
+		return "", fmt.Errorf("failed get row: %w", err)
+	}
+	if isDeleted {
+		return "", ErrURLDeleted
+	}
+	if expires := fromMicros(expiresAt); expires != nil && !expires.After(time.Now()) {
+		return "", ErrURLExpired
+	}
+	return long, nil
+}
+
+// Save saves a new URL record to the database.
+//
+// A live record for the same long URL that has already expired is retired so the URL can be shortened again.
+func (s *inSQLite) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
+	const (
+		selectStmt = `SELECT short, expires_at FROM urls WHERE long = ? AND is_deleted = FALSE`
+		retireStmt = `UPDATE urls SET is_deleted = TRUE WHERE short = ? AND is_deleted = FALSE`
+		insertStmt = `INSERT INTO urls (short, long, user_id, expires_at) VALUES (?, ?, ?, ?)`
+	)
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	_, err := s.db.ExecContext(ctx, insertStmt, shortLink, longLink, userID, toMicros(expiresAt))
+	switch {
+	case err == nil:
+		return nil
+	case isUniqueViolation(err, "urls.long"):
+		var (
+			existingShortLink string
+			existingExpiresAt sql.NullInt64
+		)
+		selectErr := s.db.QueryRowContext(ctx, selectStmt, longLink).Scan(&existingShortLink, &existingExpiresAt)
+		if selectErr != nil {
+			return fmt.Errorf("failed to select row: %w", selectErr)
+		}
+		if expires := fromMicros(existingExpiresAt); expires != nil && !expires.After(time.Now()) {
+			if _, err = s.db.ExecContext(ctx, retireStmt, existingShortLink); err != nil {
+				return fmt.Errorf("failed to retire expired row: %w", err)
+			}
+			return s.Save(ctx, shortLink, longLink, expiresAt)
+		}
+		return &DuplicateRecordError{Message: existingShortLink, Err: err}
+	case isUniqueViolation(err, "urls.short"):
+		return fmt.Errorf("short %s: %w", shortLink, service.ErrShortURLExists)
+	}
+	return fmt.Errorf("failed to execute row: %w", err)
+}
+
+// BatchSave saves multiple URL records to the database in a single transaction.
+func (s *inSQLite) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
+	const stmt = `INSERT INTO urls (short, long, user_id, expires_at) VALUES (?, ?, ?, ?)`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer s.rollback(tx)
+	insert, err := tx.PrepareContext(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close() //nolint:errcheck // closed with the transaction
+	for _, in := range input {
+		_, err = insert.ExecContext(ctx, in.ShortURL, in.OriginalURL, userID, toMicros(in.ExpiresAt))
+		if isUniqueViolation(err, "urls.short") {
+			return nil, fmt.Errorf("failed execute batch request: %w", service.ErrShortURLExists)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed execute batch request: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	var resp models.BatchArray
+	for _, in := range input {
+		shortURL, err := url.JoinPath(s.cfg.App.BaseURL, "/", in.ShortURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join url: %w", err)
+		}
+		resp = append(resp, models.Batch{
+			CorrelationID: in.CorrelationID,
+			ShortURL:      shortURL,
+			OriginalURL:   in.OriginalURL,
+			ExpiresAt:     in.ExpiresAt,
+		})
+	}
+	return resp, nil
+}
+
+// GetByUserID retrieves URLs for a given user ID from the database.
+func (s *inSQLite) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
+	const stmt = `SELECT short, long FROM urls WHERE user_id = ? AND is_deleted = FALSE`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	return s.queryBaseRows(ctx, stmt, userID)
+}
+
+// GetByUserIDPage retrieves up to limit URLs of the user with the short link after the given one, ordered by it.
+func (s *inSQLite) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	const stmt = `SELECT short, long FROM urls WHERE user_id = ? AND is_deleted = FALSE AND short > ?
+		ORDER BY short LIMIT ?`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	return s.queryBaseRows(ctx, stmt, userID, after, limit)
+}
+
+func (s *inSQLite) queryBaseRows(ctx context.Context, stmt string, args ...any) ([]models.BaseRow, error) {
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed get urls: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // rows.Err is checked
+	var data []models.BaseRow
+	for rows.Next() {
+		var row models.BaseRow
+		if err = rows.Scan(&row.Short, &row.Long); err != nil {
+			return nil, fmt.Errorf("failed scan rows into BaseRow: %w", err)
+		}
+		data = append(data, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed read urls: %w", err)
+	}
+	return data, nil
+}
+
+// DeleteURLs marks URLs of the user from the context as deleted in the database.
+func (s *inSQLite) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
+	const stmt = `UPDATE urls SET is_deleted = TRUE WHERE short = ? AND user_id = ? AND is_deleted = FALSE`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer s.rollback(tx)
+	for _, short := range input {
+		if _, err = tx.ExecContext(ctx, stmt, short, userID); err != nil {
+			return fmt.Errorf("failed to delete url: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes deleted and expired URLs and their click events from the database.
+func (s *inSQLite) Cleanup(ctx context.Context) ([]string, error) {
+	const (
+		stmt       = `DELETE FROM urls WHERE is_deleted = TRUE OR expires_at <= ? RETURNING short`
+		clicksStmt = `DELETE FROM clicks WHERE NOT EXISTS
+		(SELECT 1 FROM urls u WHERE u.short = clicks.short AND u.is_deleted = FALSE)`
+	)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer s.rollback(tx)
+	rows, err := tx.QueryContext(ctx, stmt, time.Now().UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed query db: %w", err)
+	}
+	result := make([]string, 0)
+	for rows.Next() {
+		var short string
+		if err = rows.Scan(&short); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed scan short from row: %w", err), rows.Close())
+		}
+		result = append(result, short)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return nil, fmt.Errorf("failed to read deleted rows: %w", err)
+	}
+	if len(result) > 0 {
+		if _, err = tx.ExecContext(ctx, clicksStmt); err != nil {
+			return nil, fmt.Errorf("failed to delete orphan clicks: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// ServiceStats returns the number of distinct long URLs and users.
+func (s *inSQLite) ServiceStats(ctx context.Context) (models.Stats, error) {
+	const selectStmt = `SELECT
+    (SELECT COUNT(DISTINCT user_id) FROM urls) AS users_cnt,
+    (SELECT COUNT(DISTINCT long) FROM urls) AS long_cnt`
+	resp := models.Stats{}
+	if err := s.db.QueryRowContext(ctx, selectStmt).Scan(&resp.Users, &resp.URLs); err != nil {
+		return models.Stats{}, fmt.Errorf("failed to get rows from table: %w", err)
+	}
+	return resp, nil
+}
+
+// CheckMigrations reports an error unless the schema is at the last embedded SQLite migration.
+func (s *inSQLite) CheckMigrations(ctx context.Context) error {
+	latest, err := latestSQLiteMigration()
+	if err != nil {
+		return err
+	}
+	var (
+		version uint
+		dirty   bool
+	)
+	if err = s.db.QueryRowContext(ctx, schemaVersionStmt).Scan(&version, &dirty); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return checkSchemaVersion(version, latest, dirty)
+}
+
+// Ping checks the database connection.
+func (s *inSQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database.
+func (s *inSQLite) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close sqlite: %w", err)
+	}
+	return nil
+}
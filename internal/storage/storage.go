@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -22,16 +25,33 @@ import (
 // inMemory represents an in-memory URL storage.
 type inMemory struct {
 	*logger.Log
-	mux     *sync.Mutex
-	cfg     *config.Config
-	urls    map[string]URLRecord
-	counter uint64
+	mux      *sync.Mutex
+	cfg      *config.Config
+	clicks   map[string][]models.Click
+	apiKeys  map[string]models.APIKey
+	accounts map[string]models.Account
+	disabled map[string]struct{}
+	// urls holds the records under their own locks, mux guards the rest.
+	urls     *urlTable
+	counter  atomic.Uint64
+	sequence uint64
 }
 
+// journalFunc persists an operation before it is applied to the in-memory records.
+type journalFunc func(e walEntry) error
+
+// noJournal keeps the operations in memory only.
+func noJournal(walEntry) error { return nil }
+
 // inFile represents a file-based URL storage.
 type inFile struct {
 	inMemory
-	filePath string
+	filePath      string
+	fsync         string
+	fsyncInterval time.Duration
+	journal       *wal
+	// sequenceLimit is the highest sequence value reserved in the log.
+	sequenceLimit uint64
 }
 
 // inDatabase represents a database-based URL storage.
@@ -41,20 +61,29 @@ type inDatabase struct {
 	log *logger.Log
 }
 
-// Cleanup removes deleted URLs from the database.
+// Cleanup removes deleted and expired URLs from the database.
 func (d *inDatabase) Cleanup(ctx context.Context) ([]string, error) {
-	const stmt = `DELETE FROM urls WHERE is_deleted = TRUE RETURNING id`
+	const (
+		stmt       = `DELETE FROM urls WHERE is_deleted = TRUE OR expires_at <= NOW() RETURNING short`
+		clicksStmt = `DELETE FROM clicks c WHERE NOT EXISTS
+		(SELECT 1 FROM urls u WHERE u.short = c.short AND u.is_deleted = FALSE)`
+	)
 	result := make([]string, 0)
 	rows, err := d.pool.Query(ctx, stmt)
 	if err != nil {
 		return nil, fmt.Errorf("failed query db: %w", err)
 	}
 	for rows.Next() {
-		var id string
-		if err = rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("failed scan id from row: %w", err)
+		var short string
+		if err = rows.Scan(&short); err != nil {
+			return nil, fmt.Errorf("failed scan short from row: %w", err)
+		}
+		result = append(result, short)
+	}
+	if len(result) > 0 {
+		if _, err = d.pool.Exec(ctx, clicksStmt); err != nil {
+			return nil, fmt.Errorf("failed to delete orphan clicks: %w", err)
 		}
-		result = append(result, id)
 	}
 
 	return result, nil
@@ -124,15 +153,44 @@ func (d *inDatabase) GetByUserID(ctx context.Context) ([]models.BaseRow, error)
 	return data, nil
 }
 
+// GetByUserIDPage retrieves up to limit URLs of the user with the short link after the given one, ordered by it.
+func (d *inDatabase) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	const stmt = `SELECT short, long FROM urls WHERE user_id = $1 AND is_deleted = FALSE AND short > $2
+		ORDER BY short LIMIT $3`
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	rows, err := d.pool.Query(ctx, stmt, userID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed get urls page for user_id = %s: %w", userID, err)
+	}
+	defer rows.Close()
+	data := make([]models.BaseRow, 0, limit)
+	for rows.Next() {
+		var row models.BaseRow
+		if err = rows.Scan(&row.Short, &row.Long); err != nil {
+			return nil, fmt.Errorf("failed scan rows into BaseRow: %w", err)
+		}
+		data = append(data, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed read urls page: %w", err)
+	}
+	return data, nil
+}
+
 // Get retrieves a URL by its short link from the database.
 func (d *inDatabase) Get(ctx context.Context, shortLink string) (string, error) {
-	const stmt = `SELECT long, is_deleted FROM urls WHERE short = $1`
+	const stmt = `SELECT long, is_deleted, expires_at FROM urls WHERE short = $1
+		ORDER BY is_deleted LIMIT 1`
 
 	var (
 		long      string
 		isDeleted bool
+		expiresAt *time.Time
 	)
-	err := d.pool.QueryRow(ctx, stmt, shortLink).Scan(&long, &isDeleted)
+	err := d.pool.QueryRow(ctx, stmt, shortLink).Scan(&long, &isDeleted, &expiresAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", service.ErrURLNotFound
@@ -142,33 +200,51 @@ func (d *inDatabase) Get(ctx context.Context, shortLink string) (string, error)
 	if isDeleted {
 		return "", ErrURLDeleted
 	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", ErrURLExpired
+	}
 	return long, nil
 }
 
 // Save saves a new URL record to the database.
-func (d *inDatabase) Save(ctx context.Context, shortLink, longLink string) error {
+//
+// A live record for the same long URL that has already expired is retired so the URL can be shortened again.
+func (d *inDatabase) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
 	const (
 		longConstraint = "idx_long_is_not_deleted"
-		selectStmt     = `SELECT short FROM urls WHERE long = $1`
-		insertStmt     = `INSERT INTO urls (short, long, user_id) VALUES ($1, $2, $3)`
+		selectStmt     = `SELECT short, expires_at FROM urls WHERE long = $1 AND is_deleted = FALSE`
+		retireStmt     = `UPDATE urls SET is_deleted = TRUE WHERE short = $1 AND is_deleted = FALSE`
+		insertStmt     = `INSERT INTO urls (short, long, user_id, expires_at) VALUES ($1, $2, $3, $4)`
+	)
+	var (
+		existingShortLink string
+		existingExpiresAt *time.Time
 	)
-	var existingShortLink string
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return errGetUserFromContext
 	}
 	// через транзакцию в этом случае нельзя, т.к. если будет получена ошибка, то
 	// все последующие команды не будут до роллбэк/коммита выполняться. Savepoints использовать - тут оверхед
-	_, err := d.pool.Exec(ctx, insertStmt, shortLink, longLink, userID)
+	_, err := d.pool.Exec(ctx, insertStmt, shortLink, longLink, userID, expiresAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			if pgErr.ConstraintName == longConstraint {
-				selectErr := d.pool.QueryRow(ctx, selectStmt, longLink).Scan(&existingShortLink)
+			switch pgErr.ConstraintName {
+			case longConstraint:
+				selectErr := d.pool.QueryRow(ctx, selectStmt, longLink).Scan(&existingShortLink, &existingExpiresAt)
 				if selectErr != nil {
 					return fmt.Errorf("failed to select row: %w", selectErr)
 				}
+				if existingExpiresAt != nil && !existingExpiresAt.After(time.Now()) {
+					if _, err = d.pool.Exec(ctx, retireStmt, existingShortLink); err != nil {
+						return fmt.Errorf("failed to retire expired row: %w", err)
+					}
+					return d.Save(ctx, shortLink, longLink, expiresAt)
+				}
 				return &DuplicateRecordError{Message: existingShortLink, Err: err}
+			case shortConstraint:
+				return fmt.Errorf("short %s: %w", shortLink, service.ErrShortURLExists)
 			}
 		}
 		return fmt.Errorf("failed to execute row: %w", err)
@@ -179,7 +255,7 @@ func (d *inDatabase) Save(ctx context.Context, shortLink, longLink string) error
 
 // BatchSave saves multiple URL records to the database.
 func (d *inDatabase) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
-	const stmt = `INSERT INTO urls (short, long, user_id) VALUES (@short, @long, @user_id)`
+	const stmt = `INSERT INTO urls (short, long, user_id, expires_at) VALUES (@short, @long, @user_id, @expires_at)`
 
 	tx, err := d.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: "read committed"})
 	if err != nil {
@@ -197,9 +273,10 @@ func (d *inDatabase) BatchSave(ctx context.Context, input models.BatchArray) (mo
 	batch := pgx.Batch{}
 	for _, in := range input {
 		args := pgx.NamedArgs{
-			"short":   in.ShortURL,
-			"long":    in.OriginalURL,
-			"user_id": userID,
+			"short":      in.ShortURL,
+			"long":       in.OriginalURL,
+			"user_id":    userID,
+			"expires_at": in.ExpiresAt,
 		}
 		batch.Queue(stmt, args)
 	}
@@ -210,6 +287,10 @@ func (d *inDatabase) BatchSave(ctx context.Context, input models.BatchArray) (mo
 	_, batchErr := batchResults.Exec()
 
 	if batchErr != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(batchErr, &pgErr) && pgErr.ConstraintName == shortConstraint {
+			return nil, fmt.Errorf("failed execute batch request: %w", service.ErrShortURLExists)
+		}
 		return nil, fmt.Errorf("failed execute batch request: %w", batchErr)
 	}
 
@@ -230,6 +311,7 @@ func (d *inDatabase) BatchSave(ctx context.Context, input models.BatchArray) (mo
 			CorrelationID: in.CorrelationID,
 			ShortURL:      shortURL,
 			OriginalURL:   in.OriginalURL,
+			ExpiresAt:     in.ExpiresAt,
 		})
 	}
 
@@ -252,20 +334,31 @@ func (d *inDatabase) ServiceStats(ctx context.Context) (models.Stats, error) {
 	return resp, nil
 }
 
-// Cleanup removes deleted URLs from the in-memory storage.
+// Cleanup removes deleted and expired URLs from the in-memory storage.
 func (m *inMemory) Cleanup(_ context.Context) ([]string, error) {
+	cleaned, err := m.cleanup(noJournal)
+	if err != nil {
+		return nil, err
+	}
 	m.mux.Lock()
 	defer m.mux.Unlock()
-	cleaned := make([]string, 0)
-	for _, u := range m.urls {
-		if u.Deleted {
-			cleaned = append(cleaned, u.ShortURL)
-			delete(m.urls, u.ShortURL)
-		}
-	}
+	m.purgeOrphanClicks()
 	return cleaned, nil
 }
 
+// cleanup journals and removes the deleted and expired records.
+func (m *inMemory) cleanup(journal journalFunc) ([]string, error) {
+	now := time.Now()
+	stale := m.urls.stale(now)
+	if len(stale) == 0 {
+		return stale, nil
+	}
+	if err := journal(walEntry{Op: opPurge, Shorts: stale}); err != nil {
+		return nil, err
+	}
+	return m.urls.purge(stale, now), nil
+}
+
 // GetByUserID retrieves URLs for a given user ID from the in-memory storage.
 func (m *inMemory) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
 	var data []models.BaseRow
@@ -273,10 +366,8 @@ func (m *inMemory) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
 	if !ok {
 		return nil, errGetUserFromContext
 	}
-	m.mux.Lock()
-	defer m.mux.Unlock()
-	for _, u := range m.urls {
-		if userID == u.UserID && !u.Deleted {
+	for _, u := range m.urls.userRecords(userID) {
+		if !u.Deleted {
 			data = append(data, models.BaseRow{
 				Long:  u.OriginalURL,
 				Short: u.ShortURL,
@@ -286,216 +377,306 @@ func (m *inMemory) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
 	return data, nil
 }
 
+// GetByUserIDPage retrieves up to limit URLs of the user with the short link after the given one, ordered by it.
+func (m *inMemory) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return nil, errGetUserFromContext
+	}
+	var data []models.BaseRow
+	for _, u := range m.urls.userRecords(userID) {
+		if !u.Deleted && u.ShortURL > after {
+			data = append(data, models.BaseRow{Long: u.OriginalURL, Short: u.ShortURL})
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Short < data[j].Short
+	})
+	if len(data) > limit {
+		data = data[:limit]
+	}
+	return data, nil
+}
+
 // DeleteURLs marks URLs as deleted in the in-memory storage.
 func (m *inMemory) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
-	var wg sync.WaitGroup
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return errGetUserFromContext
 	}
-	for _, short := range input {
-		short := short
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			u, ok := m.urls[short]
+	return m.deleteURLs(userID, input, noJournal)
+}
+
+// deleteURLs journals and marks deleted the live URLs of the user, the others are skipped.
+func (m *inMemory) deleteURLs(userID string, input models.DeleteURLs, journal journalFunc) error {
+	return m.urls.update(input, nil, func(tx *urlTx) error {
+		deleted := make([]URLRecord, 0, len(input))
+		for _, short := range input {
+			u, ok := tx.get(short)
 			if !ok {
 				m.Log.Err("url not found", short)
-				return
+				continue
 			}
-
 			if u.UserID == userID && !u.Deleted {
-				m.urls[short] = URLRecord{
-					OriginalURL: u.OriginalURL,
-					ShortURL:    u.ShortURL,
-					UUID:        u.UUID,
-					UserID:      u.UserID,
-					Deleted:     true,
-				}
-				m.Log.Debug("deleted url", "short", u.ShortURL)
+				u.Deleted = true
+				deleted = append(deleted, u)
 			}
-		}()
-	}
-	wg.Wait()
-
-	return nil
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		shorts := make([]string, 0, len(deleted))
+		for _, u := range deleted {
+			shorts = append(shorts, u.ShortURL)
+		}
+		if err := journal(walEntry{Op: opDelete, Shorts: shorts}); err != nil {
+			return err
+		}
+		for _, u := range deleted {
+			tx.put(u)
+			m.Log.Debug("deleted url", "short", u.ShortURL)
+		}
+		return nil
+	})
 }
 
 // Get retrieves a URL by its short link from the in-memory storage.
 func (m *inMemory) Get(_ context.Context, shortLink string) (string, error) {
-	m.mux.Lock()
-	defer m.mux.Unlock()
-	longLink, ok := m.urls[shortLink]
+	longLink, ok := m.urls.get(shortLink)
 	if ok {
+		if longLink.Deleted {
+			return "", ErrURLDeleted
+		}
+		if longLink.Expired(time.Now()) {
+			return "", ErrURLExpired
+		}
 		return longLink.OriginalURL, nil
 	}
 	return "", service.ErrURLNotFound
 }
 
 // Save saves a new URL record to the in-memory storage.
-func (m *inMemory) Save(ctx context.Context, shortLink, longLink string) error {
-	m.mux.Lock()
+//
+// A live record for the same long URL that has already expired is retired so the URL can be shortened again.
+func (m *inMemory) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return errGetUserFromContext
 	}
-	defer m.mux.Unlock()
-	m.urls[shortLink] = URLRecord{
-		UUID:        strconv.FormatUint(m.counter, 10),
-		OriginalURL: longLink,
-		ShortURL:    shortLink,
-		UserID:      userID,
-		Deleted:     false,
-	}
-	m.counter++
-	return nil
+	return m.save(userID, shortLink, longLink, expiresAt, noJournal)
+}
+
+// save journals and stores a new record of the user.
+func (m *inMemory) save(userID, shortLink, longLink string, expiresAt *time.Time, journal journalFunc) error {
+	return m.urls.update([]string{shortLink}, []string{longLink}, func(tx *urlTx) error {
+		if tx.taken(shortLink) {
+			return fmt.Errorf("short %s: %w", shortLink, service.ErrShortURLExists)
+		}
+		records, err := checkLong(tx, longLink, time.Now())
+		if err != nil {
+			return err
+		}
+		records = append(records, URLRecord{
+			UUID:        strconv.FormatUint(m.counter.Add(1), 10),
+			OriginalURL: longLink,
+			ShortURL:    shortLink,
+			UserID:      userID,
+			ExpiresAt:   expiresAt,
+		})
+		if err = journal(walEntry{Op: opSave, Records: records}); err != nil {
+			return err
+		}
+		for _, r := range records {
+			tx.put(r)
+		}
+		return nil
+	})
 }
 
 // BatchSave saves multiple URL records to the in-memory storage.
 func (m *inMemory) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
-	var result models.BatchArray
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return nil, errGetUserFromContext
 	}
+	return m.batchSave(userID, input, noJournal)
+}
+
+// batchSave journals and stores the records of the user, none of them when any short link is taken
+// or a long URL is shortened twice.
+func (m *inMemory) batchSave(userID string, input models.BatchArray, journal journalFunc) (models.BatchArray, error) {
+	shorts := make([]string, 0, len(input))
+	longs := make([]string, 0, len(input))
 	for _, item := range input {
-		m.mux.Lock()
-		m.urls[item.ShortURL] = URLRecord{OriginalURL: item.OriginalURL, UUID: item.CorrelationID, UserID: userID}
-		m.mux.Unlock()
-		m.counter++
-		result = append(result, models.Batch{
-			CorrelationID: item.CorrelationID,
-			ShortURL:      item.CorrelationID,
-			OriginalURL:   item.OriginalURL,
-		})
+		shorts = append(shorts, item.ShortURL)
+		longs = append(longs, item.OriginalURL)
+	}
+	result := make(models.BatchArray, 0, len(input))
+	err := m.urls.update(shorts, longs, func(tx *urlTx) error {
+		now := time.Now()
+		// the items only own their long URLs once the batch is put, so the batch itself is checked first
+		batchShorts := make(map[string]struct{}, len(input))
+		batchLongs := make(map[string]string, len(input))
+		for _, item := range input {
+			if _, ok := batchShorts[item.ShortURL]; ok || tx.taken(item.ShortURL) {
+				return fmt.Errorf("short %s: %w", item.ShortURL, service.ErrShortURLExists)
+			}
+			if short, ok := batchLongs[item.OriginalURL]; ok {
+				return &DuplicateRecordError{Message: short, Err: errDuplicateLongURL}
+			}
+			batchShorts[item.ShortURL] = struct{}{}
+			batchLongs[item.OriginalURL] = item.ShortURL
+		}
+		records := make([]URLRecord, 0, len(input))
+		for _, item := range input {
+			retired, err := checkLong(tx, item.OriginalURL, now)
+			if err != nil {
+				return err
+			}
+			shortURL, err := url.JoinPath(m.cfg.App.BaseURL, "/", item.ShortURL)
+			if err != nil {
+				return fmt.Errorf("failed to build short url: %w", err)
+			}
+			records = append(records, retired...)
+			records = append(records, URLRecord{
+				UUID:        strconv.FormatUint(m.counter.Add(1), 10),
+				OriginalURL: item.OriginalURL,
+				ShortURL:    item.ShortURL,
+				UserID:      userID,
+				ExpiresAt:   item.ExpiresAt,
+			})
+			result = append(result, models.Batch{
+				CorrelationID: item.CorrelationID,
+				ShortURL:      shortURL,
+				OriginalURL:   item.OriginalURL,
+				ExpiresAt:     item.ExpiresAt,
+			})
+		}
+		if err := journal(walEntry{Op: opSave, Records: records}); err != nil {
+			return err
+		}
+		for _, r := range records {
+			tx.put(r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return result, nil
 }
 
+// checkLong fails with DuplicateRecordError when a live record shortens the long URL
+// and returns the expired one retired instead.
+func checkLong(tx *urlTx, longLink string, now time.Time) ([]URLRecord, error) {
+	u, ok := tx.owner(longLink)
+	if !ok {
+		return nil, nil
+	}
+	if !u.Expired(now) {
+		return nil, &DuplicateRecordError{Message: u.ShortURL, Err: errDuplicateLongURL}
+	}
+	u.Deleted = true
+	return []URLRecord{u}, nil
+}
+
 // ServiceStats returns a counter of saved urls and users.
 func (m *inMemory) ServiceStats(_ context.Context) (models.Stats, error) {
 	result := models.Stats{
-		URLs:  int(m.counter),
-		Users: getUniqUsers(m.urls),
+		URLs:  int(m.counter.Load()),
+		Users: m.urls.users(),
 	}
 	return result, nil
 }
 
-func getUniqUsers(m map[string]URLRecord) int {
-	uniqUsers := make(map[string]struct{})
-	for _, urlRecord := range m {
-		uniqUsers[urlRecord.UserID] = struct{}{}
-	}
-	return len(uniqUsers)
-}
-
-// Cleanup removes deleted URLs from the file-based storage.
+// Cleanup removes deleted and expired URLs from the file-based storage.
 func (f *inFile) Cleanup(_ context.Context) ([]string, error) {
-	urls := make([]URLRecord, 0)
 	f.mux.Lock()
-	for _, u := range f.inMemory.urls {
-		if !u.Deleted {
-			urls = append(urls, URLRecord{
-				UUID:        u.UUID,
-				OriginalURL: u.OriginalURL,
-				ShortURL:    u.ShortURL,
-				UserID:      u.UserID,
-				Deleted:     u.Deleted,
-			})
-		}
-	}
-	f.mux.Unlock()
-
-	if err := BatchUpdate(f.filePath, urls); err != nil {
-		return nil, fmt.Errorf("failed batch update: %w", err)
+	defer f.mux.Unlock()
+	cleaned, err := f.cleanup(f.appendLog)
+	if err != nil {
+		return nil, err
 	}
-	result := make([]string, 0, len(urls))
-	for _, u := range urls {
-		result = append(result, u.ShortURL)
+	if f.purgeOrphanClicks() {
+		// the purge is already durable, stale clicks are dropped again on the next cleanup
+		if err = f.rewriteClicks(); err != nil {
+			f.Err("failed to rewrite clicks file", err)
+		}
 	}
-
-	return result, nil
+	f.compactLog()
+	return cleaned, nil
 }
 
 // Save saves a new URL record to the file-based storage.
-func (f *inFile) Save(ctx context.Context, shortLink, longLink string) error {
-	f.mux.Lock()
+//
+// A live record for the same long URL that has already expired is retired so the URL can be shortened again.
+func (f *inFile) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return errGetUserFromContext
 	}
+	f.mux.Lock()
 	defer f.mux.Unlock()
-	urlRecord := URLRecord{
-		UUID:        strconv.FormatUint(f.counter+1, 10),
-		OriginalURL: longLink,
-		UserID:      userID,
-		ShortURL:    shortLink,
+	if err := f.save(userID, shortLink, longLink, expiresAt, f.appendLog); err != nil {
+		return err
 	}
-	f.urls[shortLink] = urlRecord
-
-	err := AppendToFile(f.Log, f.filePath, urlRecord)
-	if err != nil {
-		return fmt.Errorf("failed append to file: %w", err)
-	}
-	f.counter++
+	f.compactLog()
 	return nil
 }
 
 // BatchSave saves multiple URL records to the file-based storage.
 func (f *inFile) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
-	f.mux.Lock()
 	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
 	if !ok {
 		return nil, errGetUserFromContext
 	}
+	f.mux.Lock()
 	defer f.mux.Unlock()
-	saved, err := BatchAppend(f.Log, f.filePath, f.cfg.App.BaseURL, userID, input, f.counter)
+	saved, err := f.batchSave(userID, input, f.appendLog)
 	if err != nil {
-		return nil, fmt.Errorf("failed append rows to file: %w", err)
+		return nil, err
 	}
-	f.counter += uint64(len(saved))
+	f.compactLog()
 	return saved, nil
 }
 
-// DeleteURLs marks URLs as deleted in the file-based storage.
+// DeleteURLs marks URLs of the user from the context as deleted in the file-based storage.
 func (f *inFile) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
-	err := f.inMemory.DeleteURLs(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed delete user urls: %w", err)
+	userID, ok := ctx.Value(models.CtxUserIDKey).(string)
+	if !ok {
+		return errGetUserFromContext
 	}
-	urls := make([]URLRecord, 0, len(input))
 	f.mux.Lock()
 	defer f.mux.Unlock()
-
-	for _, u := range f.inMemory.urls {
-		urls = append(urls, URLRecord{
-			UUID:        u.UUID,
-			OriginalURL: u.OriginalURL,
-			ShortURL:    u.ShortURL,
-			UserID:      u.UserID,
-			Deleted:     u.Deleted,
-		})
+	if err := f.deleteURLs(userID, input, f.appendLog); err != nil {
+		return err
 	}
-
-	if err = BatchUpdate(f.filePath, urls); err != nil {
-		return fmt.Errorf("failed batch update: %w", err)
-	}
-
+	f.compactLog()
 	return nil
 }
 
-// restore restores the file-based storage from a file.
+// restore replays the storage log, cuts off a record torn by a crash and keeps the log open for appending.
 func (f *inFile) restore() error {
-	if f.filePath != "" {
-		mapping, err := ReadFileStorage(f.filePath)
-		if err != nil {
-			return fmt.Errorf("failed to restore from file %w", err)
-		}
-		f.mux.Lock()
-		defer f.mux.Unlock()
-		f.urls = mapping
-		f.counter = uint64(len(mapping))
+	if f.filePath == "" {
+		return nil
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	mapping := make(map[string]URLRecord)
+	var counters walCounters
+	journal, dropped, err := openWAL(f.filePath, f.fsync, f.fsyncInterval, mapping, &counters)
+	if err != nil {
+		return fmt.Errorf("failed to restore from file %w", err)
+	}
+	if dropped > 0 {
+		f.Warn("dropped torn record at the end of file storage log", "path", f.filePath, "bytes", dropped)
 	}
+	f.journal = journal
+	f.urls = urlTableOf(mapping)
+	f.counter.Store(counters.counter)
+	// a log written before the sequence was persisted has issued no more values than record UUIDs
+	f.sequence = max(counters.sequence, counters.counter)
+	f.sequenceLimit = f.sequence
 	return nil
 }
 
@@ -508,8 +689,42 @@ func (f *inFile) ServiceStats(ctx context.Context) (models.Stats, error) {
 	return res, nil
 }
 
+// Storage backends.
+const (
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+	BackendKV       = "kv"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+)
+
+// Backend returns the name of the storage backend LoadStorage picks for the config.
+func Backend(cfg *config.Config) string {
+	switch {
+	case IsSQLiteDSN(cfg.App.DatabaseDSN):
+		return BackendSQLite
+	case cfg.App.DatabaseDSN != "":
+		return BackendPostgres
+	case cfg.App.KVStoragePath != "":
+		return BackendKV
+	case cfg.App.FileStoragePath != "":
+		return BackendFile
+	default:
+		return BackendMemory
+	}
+}
+
 // LoadStorage loads the appropriate URL storage based on the configuration.
 func LoadStorage(ctx context.Context, cfg *config.Config, log *logger.Log) (service.URLStorage, error) {
+	if IsSQLiteDSN(cfg.App.DatabaseDSN) {
+		db, err := newSQLite(cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sqlite storage: %w", err)
+		}
+		log.Info("using sqlite storage..")
+		return db, nil
+	}
+
 	if cfg.App.DatabaseDSN != "" {
 		db, err := New(ctx, cfg.App.DatabaseDSN)
 		if err != nil {
@@ -519,10 +734,19 @@ func LoadStorage(ctx context.Context, cfg *config.Config, log *logger.Log) (serv
 		return &inDatabase{db, cfg, log}, nil
 	}
 
+	if cfg.App.KVStoragePath != "" {
+		kv, err := newKV(cfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kv storage: %w", err)
+		}
+		log.Info("using kv storage..")
+		return kv, nil
+	}
+
 	if cfg.App.FileStoragePath == "" {
 		log.Info("using memory storage..")
 		return &inMemory{
-			urls: make(map[string]URLRecord),
+			urls: newURLTable(memoryShards),
 			mux:  &sync.Mutex{},
 			cfg:  cfg,
 			Log:  log,
@@ -530,17 +754,31 @@ func LoadStorage(ctx context.Context, cfg *config.Config, log *logger.Log) (serv
 	}
 	storage := &inFile{
 		inMemory: inMemory{
-			urls: make(map[string]URLRecord),
+			urls: newURLTable(memoryShards),
 			mux:  &sync.Mutex{},
 			cfg:  cfg,
 			Log:  log,
 		},
-		filePath: cfg.App.FileStoragePath,
+		filePath:      cfg.App.FileStoragePath,
+		fsync:         cfg.App.FileStorageFsync,
+		fsyncInterval: cfg.App.FileStorageFsyncInterval,
 	}
 	err := storage.restore()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build storage: %w", err)
 	}
+	if err = storage.restoreClicks(); err != nil {
+		return nil, fmt.Errorf("failed to build storage: %w", err)
+	}
+	if err = storage.restoreAPIKeys(); err != nil {
+		return nil, fmt.Errorf("failed to build storage: %w", err)
+	}
+	if err = storage.restoreAccounts(); err != nil {
+		return nil, fmt.Errorf("failed to build storage: %w", err)
+	}
+	if err = storage.restoreDisabledUsers(); err != nil {
+		return nil, fmt.Errorf("failed to build storage: %w", err)
+	}
 	log.Debug("using file storage..")
 
 	return storage, nil
@@ -557,9 +795,19 @@ func (m *inMemory) Close() error {
 	return nil
 }
 
-// Close does nothing for in-file storage.
+// Close syncs and closes the file storage log.
 func (f *inFile) Close() error {
-	return nil
+	if f.mux == nil {
+		return nil
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.journal == nil {
+		return nil
+	}
+	err := f.journal.close()
+	f.journal = nil
+	return err
 }
 
 // DuplicateRecordError ...
@@ -594,8 +842,12 @@ func (m *inMemory) Ping(_ context.Context) error {
 	return nil
 }
 
+// shortConstraint is the partial unique index guarding live short links.
+const shortConstraint = "idx_short_is_not_deleted"
+
 // ErrURLDeleted ...
 var (
 	ErrURLDeleted         = errors.New("url has been deleted")
+	ErrURLExpired         = errors.New("url has expired")
 	errGetUserFromContext = errors.New("failed get user from context")
 )
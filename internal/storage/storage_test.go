@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"os"
 	"path"
 	"strconv"
@@ -11,10 +12,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"shortener/internal/config"
 	"shortener/internal/logger"
 	"shortener/internal/models"
+	"shortener/internal/service"
 )
 
 const (
@@ -70,17 +73,50 @@ func TestPing_InFile(t *testing.T) {
 	}
 }
 
+func TestCheckWritable_InFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		filePath string
+		wantErr  bool
+	}{
+		{name: "writable", filePath: path.Join(dir, "urls.json")},
+		{name: "missing directory", filePath: path.Join(dir, "missing", "urls.json"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileStorage := &inFile{filePath: tt.filePath}
+			err := fileStorage.CheckWritable(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			info, err := os.Stat(tt.filePath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestLatestMigration(t *testing.T) {
+	ups, err := fs.Glob(migrationsDir, "migrations/*.up.sql")
+	require.NoError(t, err)
+	latest, err := latestMigration()
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(ups)), latest)
+}
+
 func TestSave_InMemory(t *testing.T) {
 	log := &logger.Log{}
 	log.Initialize("INFO")
 	memStorage := &inMemory{
-		mux:     &sync.Mutex{},
-		counter: 0,
-		Log:     log,
-		urls:    make(map[string]URLRecord),
+		mux:  &sync.Mutex{},
+		Log:  log,
+		urls: newURLTable(memoryShards),
 	}
 	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user_id")
-	if err := memStorage.Save(ctx, baseShortURL, baseLongURL); err != nil {
+	if err := memStorage.Save(ctx, baseShortURL, baseLongURL, nil); err != nil {
 		assert.NoError(t, err)
 	}
 
@@ -95,15 +131,15 @@ func TestGet_InMemory(t *testing.T) {
 	log := &logger.Log{}
 	log.Initialize("INFO")
 	memStorage := &inMemory{
-		mux:     &sync.Mutex{},
-		counter: 0,
-		Log:     log,
-		urls:    make(map[string]URLRecord),
+		mux:  &sync.Mutex{},
+		Log:  log,
+		urls: newURLTable(memoryShards),
 	}
 	if err := memStorage.Save(
 		context.WithValue(context.Background(), models.CtxUserIDKey, "user_id"),
 		baseShortURL,
 		baseLongURL,
+		nil,
 	); err != nil {
 		assert.NoError(t, err)
 	}
@@ -115,14 +151,109 @@ func TestGet_InMemory(t *testing.T) {
 	assert.Equal(t, baseLongURL, longLink)
 }
 
+func TestGetByUserIDPage_InMemory(t *testing.T) {
+	memStorage := &inMemory{
+		mux: &sync.Mutex{},
+		urls: urlTableOf(map[string]URLRecord{
+			"c": {OriginalURL: "https://example.com/c", UserID: user1},
+			"a": {OriginalURL: "https://example.com/a", UserID: user1},
+			"b": {OriginalURL: "https://example.com/b", UserID: user1, Deleted: true},
+			"d": {OriginalURL: "https://example.com/d", UserID: user1},
+			"e": {OriginalURL: "https://example.com/e", UserID: user2},
+		}),
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	tests := []struct {
+		name  string
+		after string
+		limit int
+		want  []string
+	}{
+		{name: "first page", limit: 2, want: []string{"a", "c"}},
+		{name: "next page", after: "c", limit: 2, want: []string{"d"}},
+		{name: "past the end", after: "d", limit: 2, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := memStorage.GetByUserIDPage(ctx, tt.after, tt.limit)
+			require.NoError(t, err)
+			var got []string
+			for _, row := range rows {
+				got = append(got, row.Short)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSave_InMemoryShortTaken(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	memStorage := &inMemory{
+		mux:  &sync.Mutex{},
+		Log:  log,
+		urls: newURLTable(memoryShards),
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user_id")
+	assert.NoError(t, memStorage.Save(ctx, baseShortURL, baseLongURL, nil))
+
+	err := memStorage.Save(ctx, baseShortURL, baseLongURL+"/other", nil)
+	assert.ErrorIs(t, err, service.ErrShortURLExists)
+
+	_, err = memStorage.BatchSave(ctx, models.BatchArray{
+		{ShortURL: "fresh", OriginalURL: "https://example.com/fresh", CorrelationID: "1"},
+		{ShortURL: baseShortURL, OriginalURL: "https://example.com/taken", CorrelationID: "2"},
+	})
+	assert.ErrorIs(t, err, service.ErrShortURLExists)
+	_, err = memStorage.Get(ctx, "fresh")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+}
+
+func TestNextSequence_InMemory(t *testing.T) {
+	memStorage := &inMemory{mux: &sync.Mutex{}, urls: newURLTable(memoryShards)}
+	first, err := memStorage.NextSequence(context.Background())
+	assert.NoError(t, err)
+	second, err := memStorage.NextSequence(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), first)
+	assert.Equal(t, uint64(2), second)
+}
+
+func TestGet_InMemoryExpired(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	memStorage := &inMemory{
+		mux:  &sync.Mutex{},
+		Log:  log,
+		urls: newURLTable(memoryShards),
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, memStorage.Save(ctx, short1, baseLongURL+"/1", &past))
+	assert.NoError(t, memStorage.Save(ctx, short2, baseLongURL+"/2", &future))
+
+	_, err := memStorage.Get(ctx, short1)
+	assert.ErrorIs(t, err, ErrURLExpired)
+	long, err := memStorage.Get(ctx, short2)
+	assert.NoError(t, err)
+	assert.Equal(t, baseLongURL+"/2", long)
+
+	cleaned, err := memStorage.Cleanup(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{short1}, cleaned)
+	_, err = memStorage.Get(ctx, short1)
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+}
+
 func TestBatchSave_InMemory(t *testing.T) {
 	log := &logger.Log{}
 	log.Initialize("INFO")
 	memStorage := &inMemory{
-		mux:     &sync.Mutex{},
-		counter: 0,
-		Log:     log,
-		urls:    make(map[string]URLRecord),
+		mux:  &sync.Mutex{},
+		Log:  log,
+		cfg:  &config.Config{App: config.AppConfig{BaseURL: "http://localhost:8080"}},
+		urls: newURLTable(memoryShards),
 	}
 	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user_id")
 
@@ -136,32 +267,34 @@ func TestBatchSave_InMemory(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
-	assert.Equal(t, len(batchInput), len(saved))
+	require.Len(t, saved, len(batchInput))
+	assert.Equal(t, "1", saved[0].CorrelationID)
+	assert.Equal(t, "http://localhost:8080/short1", saved[0].ShortURL)
 }
 
 func TestInMemoryCleanup(t *testing.T) {
 	log := &logger.Log{}
 	log.Initialize("INFO")
 	mem := &inMemory{
-		Log:  log,
-		mux:  &sync.Mutex{},
-		cfg:  &config.Config{},
-		urls: make(map[string]URLRecord),
-	}
-
-	mem.urls["short1"] = URLRecord{
-		UUID:        "1",
-		OriginalURL: "https://example1.com",
-		ShortURL:    short1,
-		UserID:      user1,
-		Deleted:     true,
-	}
-	mem.urls["short2"] = URLRecord{
-		UUID:        "2",
-		OriginalURL: "https://example2.com",
-		ShortURL:    short2,
-		UserID:      user2,
-		Deleted:     false,
+		Log: log,
+		mux: &sync.Mutex{},
+		cfg: &config.Config{},
+		urls: urlTableOf(map[string]URLRecord{
+			"short1": {
+				UUID:        "1",
+				OriginalURL: "https://example1.com",
+				ShortURL:    short1,
+				UserID:      user1,
+				Deleted:     true,
+			},
+			"short2": {
+				UUID:        "2",
+				OriginalURL: "https://example2.com",
+				ShortURL:    short2,
+				UserID:      user2,
+				Deleted:     false,
+			},
+		}),
 	}
 
 	cleaned, err := mem.Cleanup(context.Background())
@@ -170,10 +303,10 @@ func TestInMemoryCleanup(t *testing.T) {
 	expected := []string{short1}
 	assert.Equal(t, len(expected), len(cleaned))
 
-	if _, ok := mem.urls[short1]; ok {
+	if _, ok := mem.urls.get(short1); ok {
 		t.Errorf("expected URL %s to be deleted, but it still exists", short1)
 	}
-	if _, ok := mem.urls["short2"]; !ok {
+	if _, ok := mem.urls.get("short2"); !ok {
 		t.Errorf("expected URL %s to still exist, but it was deleted", short2)
 	}
 }
@@ -202,34 +335,24 @@ func TestInFileDeleteURLs(t *testing.T) {
 			Log:  log,
 			mux:  &sync.Mutex{},
 			cfg:  &config.Config{},
-			urls: make(map[string]URLRecord),
+			urls: newURLTable(memoryShards),
 		},
 		filePath: tmpFile.Name(),
 	}
 
-	file.inMemory.urls["short1"] = URLRecord{
-		UUID:        "1",
-		OriginalURL: "https://example1.com",
-		ShortURL:    "short1",
-		UserID:      "user1",
-		Deleted:     false,
-	}
-	file.inMemory.urls["short2"] = URLRecord{
-		UUID:        "2",
-		OriginalURL: "https://example2.com",
-		ShortURL:    "short2",
-		UserID:      "user2",
-		Deleted:     false,
-	}
+	require.NoError(t, file.Save(ctx, "short1", "https://example1.com", nil))
+	otherCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "user2")
+	require.NoError(t, file.Save(otherCtx, "short2", "https://example2.com", nil))
+	require.NoError(t, file.DeleteURLs(otherCtx, models.DeleteURLs{"short1"}), "foreign url is left as is")
 
 	input := models.DeleteURLs{"short1"}
 	err = file.DeleteURLs(ctx, input)
 	assert.NoError(t, err)
 
-	if _, ok := file.inMemory.urls["short1"]; ok && !file.inMemory.urls["short1"].Deleted {
+	if u, ok := file.inMemory.urls.get("short1"); ok && !u.Deleted {
 		t.Errorf("expected URL short1 to be deleted, but it still exists")
 	}
-	if _, ok := file.inMemory.urls["short2"]; !ok || file.inMemory.urls["short2"].Deleted {
+	if u, ok := file.inMemory.urls.get("short2"); !ok || u.Deleted {
 		t.Errorf("expected URL short2 to still exist and not be deleted, but it was deleted")
 	}
 
@@ -260,35 +383,250 @@ func TestInFileCleanup(t *testing.T) {
 	defer func() {
 		assert.NoError(t, os.RemoveAll(tmpDir))
 	}()
-	mem := inMemory{
-		Log:  log,
-		mux:  &sync.Mutex{},
-		cfg:  &config.Config{},
-		urls: make(map[string]URLRecord),
+	inFl := &inFile{
+		inMemory: inMemory{
+			Log:  log,
+			mux:  &sync.Mutex{},
+			cfg:  &config.Config{},
+			urls: newURLTable(memoryShards),
+		},
+		filePath: tmpFile.Name(),
 	}
-	inFl := &inFile{inMemory: mem, filePath: tmpFile.Name()}
 
-	inFl.inMemory.urls["short1"] = URLRecord{
-		UUID:        "1",
-		OriginalURL: "https://example1.com",
-		ShortURL:    short1,
-		UserID:      user1,
-		Deleted:     true,
-	}
-	inFl.inMemory.urls["short2"] = URLRecord{
-		UUID:        "2",
-		OriginalURL: "https://example2.com",
-		ShortURL:    short2,
-		UserID:      user2,
-		Deleted:     false,
-	}
+	ctx1 := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	ctx2 := context.WithValue(context.Background(), models.CtxUserIDKey, user2)
+	require.NoError(t, inFl.Save(ctx1, short1, "https://example1.com", nil))
+	require.NoError(t, inFl.Save(ctx2, short2, "https://example2.com", nil))
+	require.NoError(t, inFl.DeleteURLs(ctx1, models.DeleteURLs{short1}))
 
 	cleaned, err := inFl.Cleanup(context.Background())
 	assert.NoError(t, err)
+	assert.Equal(t, []string{short1}, cleaned)
 
-	expected := []string{short1}
-	assert.Equal(t, len(expected), len(cleaned))
+	assert.NotContains(t, urlMap(inFl.urls), short1)
+	assert.False(t, urlMap(inFl.urls)[short2].Deleted)
+	require.NoError(t, inFl.Close())
+
+	urls, err := ReadFileStorage(inFl.filePath)
+	require.NoError(t, err)
+	assert.NotContains(t, urls, short1)
+	assert.Contains(t, urls, short2)
+}
+
+func TestInFileCleanup_Clicks(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	filePath := path.Join(t.TempDir(), filename)
+	newStorage := func() *inFile {
+		mapping, err := ReadFileStorage(filePath)
+		require.NoError(t, err)
+		inFl := &inFile{
+			inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: urlTableOf(mapping)},
+			filePath: filePath,
+		}
+		require.NoError(t, inFl.restoreClicks())
+		return inFl
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+	now := time.Now()
+
+	inFl := newStorage()
+	require.NoError(t, inFl.Save(ctx, "live", "https://example.com/live", nil))
+	require.NoError(t, inFl.Save(ctx, "gone", "https://example.com/gone", nil))
+	require.NoError(t, inFl.SaveClicks(ctx, []models.Click{
+		{Short: "live", Time: now}, {Short: "gone", Time: now}, {Short: "gone", Time: now},
+	}))
+	require.NoError(t, inFl.DeleteURLs(ctx, models.DeleteURLs{"gone"}))
+	_, err := inFl.Cleanup(ctx)
+	require.NoError(t, err)
+	require.NoError(t, inFl.Save(ctx, "gone", "https://example.com/reused", nil))
+	require.NoError(t, inFl.Close())
+
+	restored := newStorage()
+	stats, err := restored.ClickStats(ctx, "gone")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total, "clicks of the purged link are not counted for the reused one")
+	stats, err = restored.ClickStats(ctx, "live")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+}
+
+func TestImportURLs_InMemory(t *testing.T) {
+	ctx := context.Background()
+	memStorage := &inMemory{mux: &sync.Mutex{}, urls: newURLTable(memoryShards)}
+	records := []models.URLRecord{
+		{ShortURL: "aaa", OriginalURL: "https://example.com/a", UserID: "u1"},
+		{ShortURL: "bbb", OriginalURL: "https://example.com/b", UserID: "u2", Deleted: true},
+	}
+	res, err := memStorage.ImportURLs(ctx, records, models.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Imported: 2}, res)
 
-	assert.True(t, inFl.inMemory.urls[short1].Deleted)
-	assert.False(t, inFl.inMemory.urls[short2].Deleted)
+	changed := []models.URLRecord{
+		{ShortURL: "aaa", OriginalURL: "https://example.com/changed", UserID: "u1"},
+		{ShortURL: "ccc", OriginalURL: "https://example.com/c", UserID: "u3"},
+	}
+	_, err = memStorage.ImportURLs(ctx, changed, models.ConflictFail)
+	assert.ErrorIs(t, err, service.ErrImportConflict)
+	_, err = memStorage.Get(ctx, "ccc")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+	res, err = memStorage.ImportURLs(ctx, changed, models.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Imported: 1, Skipped: 1}, res)
+
+	res, err = memStorage.ImportURLs(ctx, changed, models.ConflictOverwrite)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Overwritten: 2}, res)
+	long, err := memStorage.Get(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/changed", long)
+
+	var exported []string
+	require.NoError(t, memStorage.ExportURLs(ctx, func(r models.URLRecord) error {
+		exported = append(exported, r.ShortURL)
+		return nil
+	}))
+	assert.Equal(t, []string{"aaa", "bbb", "ccc"}, exported)
+}
+
+func TestInFileAPIKeys(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	filePath := path.Join(t.TempDir(), filename)
+	newStorage := func() *inFile {
+		inFl := &inFile{
+			inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: newURLTable(memoryShards)},
+			filePath: filePath,
+		}
+		require.NoError(t, inFl.restoreAPIKeys())
+		return inFl
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "key-owner")
+	otherCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "someone-else")
+
+	inFl := newStorage()
+	key := models.APIKey{ID: "key-1", Name: "ci", Prefix: "sk_abc", Hash: "hash-1", CreatedAt: time.Now().UTC()}
+	require.NoError(t, inFl.SaveAPIKey(ctx, key))
+	assert.ErrorIs(t, inFl.RevokeAPIKey(otherCtx, key.ID), service.ErrAPIKeyNotFound)
+
+	got, err := newStorage().GetAPIKey(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "key-owner", got.UserID)
+	assert.Nil(t, got.RevokedAt)
+
+	require.NoError(t, inFl.RevokeAPIKey(ctx, key.ID))
+	restored := newStorage()
+	got, err = restored.GetAPIKey(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.NotNil(t, got.RevokedAt)
+	keys, err := restored.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	keys, err = restored.ListAPIKeys(otherCtx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestInFileAccounts(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	filePath := path.Join(t.TempDir(), filename)
+	newStorage := func() *inFile {
+		mapping, err := ReadFileStorage(filePath)
+		require.NoError(t, err)
+		inFl := &inFile{
+			inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: urlTableOf(mapping)},
+			filePath: filePath,
+		}
+		require.NoError(t, inFl.restoreAccounts())
+		return inFl
+	}
+	anonCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "anonymous")
+	account := models.Account{ID: "account-1", Login: "alice", PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+
+	inFl := newStorage()
+	require.NoError(t, inFl.CreateAccount(anonCtx, account))
+	assert.ErrorIs(t, inFl.CreateAccount(anonCtx, account), service.ErrLoginTaken)
+	require.NoError(t, inFl.Save(anonCtx, "live", "https://example.com/live", nil))
+	require.NoError(t, inFl.Save(anonCtx, "gone", "https://example.com/gone", nil))
+	require.NoError(t, inFl.DeleteURLs(anonCtx, models.DeleteURLs{"gone"}))
+
+	claimed, err := inFl.ClaimURLs(anonCtx, account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+
+	restored := newStorage()
+	got, err := restored.GetAccount(anonCtx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, got.ID)
+	_, err = restored.GetAccount(anonCtx, "bob")
+	assert.ErrorIs(t, err, service.ErrAccountNotFound)
+
+	rows, err := restored.GetByUserID(context.WithValue(context.Background(), models.CtxUserIDKey, account.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []models.BaseRow{{Short: "live", Long: "https://example.com/live"}}, rows)
+	rows, err = restored.GetByUserID(anonCtx)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestInMemoryClaimURLs_JournalFailed(t *testing.T) {
+	anonCtx := context.WithValue(context.Background(), models.CtxUserIDKey, "anonymous")
+	mem := &inMemory{mux: &sync.Mutex{}, cfg: &config.Config{}, urls: urlTableOf(map[string]URLRecord{
+		"live": {UUID: "1", ShortURL: "live", OriginalURL: "https://example.com/live", UserID: "anonymous"},
+	})}
+
+	_, err := mem.claimURLs(anonCtx, "account-1", func(walEntry) error { return errors.New("disk full") })
+	require.Error(t, err)
+
+	rows, err := mem.GetByUserID(anonCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.BaseRow{{Short: "live", Long: "https://example.com/live"}}, rows,
+		"the owner is kept when the claim is not persisted")
+}
+
+func TestInFileAdmin(t *testing.T) {
+	log := &logger.Log{}
+	log.Initialize("INFO")
+	filePath := path.Join(t.TempDir(), filename)
+	newStorage := func() *inFile {
+		mapping, err := ReadFileStorage(filePath)
+		require.NoError(t, err)
+		inFl := &inFile{
+			inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: urlTableOf(mapping)},
+			filePath: filePath,
+		}
+		require.NoError(t, inFl.restoreDisabledUsers())
+		return inFl
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "owner")
+
+	inFl := newStorage()
+	require.NoError(t, inFl.Save(ctx, "abc", "https://example.com/abc", nil))
+	require.NoError(t, inFl.SetURLDeleted(ctx, "abc", true))
+	assert.ErrorIs(t, inFl.SetURLDeleted(ctx, "missing", true), service.ErrURLNotFound)
+	require.NoError(t, inFl.SetUserDisabled(ctx, "owner", true))
+	require.NoError(t, inFl.SetUserDisabled(ctx, "other", true))
+	require.NoError(t, inFl.SetUserDisabled(ctx, "other", false))
+
+	restored := newStorage()
+	_, err := restored.Get(ctx, "abc")
+	assert.ErrorIs(t, err, ErrURLDeleted)
+	record, err := restored.GetURLRecord(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "owner", record.UserID)
+	assert.True(t, record.Deleted)
+	disabled, err := restored.IsUserDisabled(ctx, "owner")
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	disabled, err = restored.IsUserDisabled(ctx, "other")
+	require.NoError(t, err)
+	assert.False(t, disabled)
+
+	require.NoError(t, restored.SetURLDeleted(ctx, "abc", false))
+	records, err := newStorage().ListURLsByUser(ctx, "owner")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.False(t, records[0].Deleted)
 }
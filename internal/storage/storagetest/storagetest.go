@@ -0,0 +1,436 @@
+// Package storagetest contains the behavioural test suite every URL storage must pass.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+	"shortener/internal/storage"
+)
+
+// BaseURL is the base URL the storages under test must be configured with.
+const BaseURL = "http://localhost:8080"
+
+const (
+	user1 = "user1"
+	user2 = "user2"
+)
+
+// Factory returns an empty storage configured with BaseURL, closing it is up to the factory.
+type Factory func(t *testing.T) service.URLStorage
+
+// Run runs the suite against storages of the factory, each test gets a new one.
+func Run(t *testing.T, newStorage Factory) {
+	t.Helper()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s service.URLStorage)
+	}{
+		{name: "save and get", run: testSaveGet},
+		{name: "duplicate long url", run: testDuplicateLong},
+		{name: "batch save", run: testBatchSave},
+		{name: "user urls", run: testUserURLs},
+		{name: "delete ownership", run: testDeleteOwnership},
+		{name: "cleanup", run: testCleanup},
+		{name: "stats", run: testStats},
+		{name: "import", run: testImport},
+		{name: "admin", run: testAdmin},
+		{name: "concurrency", run: testConcurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorage(t))
+		})
+	}
+}
+
+func withUser(userID string) context.Context {
+	return context.WithValue(context.Background(), models.CtxUserIDKey, userID)
+}
+
+func testSaveGet(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, s.Save(ctx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, s.Save(ctx, "fut", "https://example.com/future", &future))
+	require.NoError(t, s.Save(ctx, "exp", "https://example.com/expired", &past))
+
+	tests := []struct {
+		name    string
+		short   string
+		want    string
+		wantErr error
+	}{
+		{name: "live", short: "aaa", want: "https://example.com/a"},
+		{name: "not expired yet", short: "fut", want: "https://example.com/future"},
+		{name: "expired", short: "exp", wantErr: storage.ErrURLExpired},
+		{name: "missing", short: "zzz", wantErr: service.ErrURLNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			long, err := s.Get(ctx, tt.short)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, long)
+		})
+	}
+
+	err := s.Save(withUser(user2), "aaa", "https://example.com/other", nil)
+	assert.ErrorIs(t, err, service.ErrShortURLExists, "short url of another user")
+}
+
+func testDuplicateLong(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, s.Save(ctx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, s.Save(ctx, "exp", "https://example.com/expired", &past))
+
+	err := s.Save(withUser(user2), "bbb", "https://example.com/a", nil)
+	var dup *storage.DuplicateRecordError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "aaa", dup.Message, "the error holds the short url already given out")
+	_, err = s.Get(ctx, "bbb")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+	require.NoError(t, s.Save(ctx, "new", "https://example.com/expired", nil), "long url of an expired one")
+	long, err := s.Get(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/expired", long)
+
+	require.NoError(t, s.DeleteURLs(ctx, models.DeleteURLs{"aaa"}))
+	require.NoError(t, s.Save(ctx, "ccc", "https://example.com/a", nil), "long url of a deleted one")
+}
+
+func testBatchSave(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	saved, err := s.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "1", ShortURL: "aaa", OriginalURL: "https://example.com/a"},
+		{CorrelationID: "2", ShortURL: "bbb", OriginalURL: "https://example.com/b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.BatchArray{
+		{CorrelationID: "1", ShortURL: BaseURL + "/aaa", OriginalURL: "https://example.com/a"},
+		{CorrelationID: "2", ShortURL: BaseURL + "/bbb", OriginalURL: "https://example.com/b"},
+	}, saved)
+	long, err := s.Get(ctx, "bbb")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/b", long)
+
+	_, err = s.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "3", ShortURL: "ccc", OriginalURL: "https://example.com/c"},
+		{CorrelationID: "4", ShortURL: "aaa", OriginalURL: "https://example.com/d"},
+	})
+	assert.ErrorIs(t, err, service.ErrShortURLExists)
+	_, err = s.Get(ctx, "ccc")
+	assert.ErrorIs(t, err, service.ErrURLNotFound, "failed batch saves nothing")
+
+	_, err = s.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "5", ShortURL: "eee", OriginalURL: "https://example.com/e"},
+		{CorrelationID: "6", ShortURL: "fff", OriginalURL: "https://example.com/e"},
+	})
+	assert.Error(t, err, "one long url twice in a batch")
+	for _, short := range []string{"eee", "fff"} {
+		_, err = s.Get(ctx, short)
+		assert.ErrorIs(t, err, service.ErrURLNotFound, short)
+	}
+
+	_, err = s.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "7", ShortURL: "ggg", OriginalURL: "https://example.com/g"},
+		{CorrelationID: "8", ShortURL: "ggg", OriginalURL: "https://example.com/h"},
+	})
+	assert.ErrorIs(t, err, service.ErrShortURLExists, "one short url twice in a batch")
+	_, err = s.Get(ctx, "ggg")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+}
+
+func testUserURLs(t *testing.T, s service.URLStorage) {
+	ctx1, ctx2 := withUser(user1), withUser(user2)
+	for _, short := range []string{"c", "a", "e", "b", "d"} {
+		require.NoError(t, s.Save(ctx1, short, "https://example.com/"+short, nil))
+	}
+	require.NoError(t, s.Save(ctx2, "f", "https://example.com/f", nil))
+	require.NoError(t, s.DeleteURLs(ctx1, models.DeleteURLs{"c"}))
+
+	rows, err := s.GetByUserID(ctx1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.BaseRow{
+		{Short: "a", Long: "https://example.com/a"},
+		{Short: "b", Long: "https://example.com/b"},
+		{Short: "d", Long: "https://example.com/d"},
+		{Short: "e", Long: "https://example.com/e"},
+	}, rows)
+
+	tests := []struct {
+		name  string
+		after string
+		limit int
+		want  []string
+	}{
+		{name: "first page", limit: 2, want: []string{"a", "b"}},
+		{name: "next page skips deleted", after: "b", limit: 2, want: []string{"d", "e"}},
+		{name: "past the end", after: "e", limit: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.GetByUserIDPage(ctx1, tt.after, tt.limit)
+			require.NoError(t, err)
+			var shorts []string
+			for _, row := range page {
+				shorts = append(shorts, row.Short)
+			}
+			assert.Equal(t, tt.want, shorts)
+		})
+	}
+
+	rows, err = s.GetByUserID(withUser("stranger"))
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func testDeleteOwnership(t *testing.T, s service.URLStorage) {
+	ctx1, ctx2 := withUser(user1), withUser(user2)
+	require.NoError(t, s.Save(ctx1, "aaa", "https://example.com/a", nil))
+	require.NoError(t, s.Save(ctx1, "bbb", "https://example.com/b", nil))
+
+	require.NoError(t, s.DeleteURLs(ctx2, models.DeleteURLs{"aaa", "missing"}))
+	long, err := s.Get(ctx1, "aaa")
+	require.NoError(t, err, "url of another user is left as is")
+	assert.Equal(t, "https://example.com/a", long)
+
+	require.NoError(t, s.DeleteURLs(ctx1, models.DeleteURLs{"aaa", "missing"}))
+	_, err = s.Get(ctx1, "aaa")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+	_, err = s.Get(ctx1, "bbb")
+	assert.NoError(t, err)
+	require.NoError(t, s.DeleteURLs(ctx1, models.DeleteURLs{"aaa"}), "deleting twice")
+
+	require.NoError(t, s.Save(ctx2, "aaa", "https://example.com/other", nil), "short url of a deleted one")
+	long, err = s.Get(ctx2, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/other", long)
+}
+
+func testCleanup(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, s.Save(ctx, "live", "https://example.com/live", nil))
+	require.NoError(t, s.Save(ctx, "gone", "https://example.com/gone", nil))
+	require.NoError(t, s.Save(ctx, "exp", "https://example.com/exp", &past))
+	_, err := s.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "1", ShortURL: "batch", OriginalURL: "https://example.com/batch"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteURLs(ctx, models.DeleteURLs{"gone", "batch"}))
+
+	cleaned, err := s.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"gone", "exp", "batch"}, cleaned)
+	for _, short := range cleaned {
+		_, err = s.Get(ctx, short)
+		assert.ErrorIs(t, err, service.ErrURLNotFound, short)
+	}
+	long, err := s.Get(ctx, "live")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/live", long)
+
+	cleaned, err = s.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, cleaned)
+}
+
+func testStats(t *testing.T, s service.URLStorage) {
+	ctx1, ctx2 := withUser(user1), withUser(user2)
+	stats, err := s.ServiceStats(ctx1)
+	require.NoError(t, err)
+	assert.Equal(t, models.Stats{}, stats)
+
+	require.NoError(t, s.Save(ctx1, "aaa", "https://example.com/a", nil))
+	_, err = s.BatchSave(ctx1, models.BatchArray{
+		{CorrelationID: "1", ShortURL: "bbb", OriginalURL: "https://example.com/b"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Save(ctx2, "ccc", "https://example.com/c", nil))
+
+	stats, err = s.ServiceStats(ctx1)
+	require.NoError(t, err)
+	assert.Equal(t, models.Stats{URLs: 3, Users: 2}, stats)
+}
+
+func testImport(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	res, err := s.ImportURLs(ctx, []models.URLRecord{
+		{ShortURL: "aaa", OriginalURL: "https://example.com/a", UserID: user1},
+		{ShortURL: "bbb", OriginalURL: "https://example.com/b", UserID: user2, Deleted: true},
+	}, models.ConflictFail)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Imported: 2}, res)
+
+	tests := []struct {
+		name    string
+		policy  models.ConflictPolicy
+		records []models.URLRecord
+		want    models.ImportResult
+		wantErr error
+	}{
+		{
+			name:    "fail on taken short url",
+			policy:  models.ConflictFail,
+			records: []models.URLRecord{{ShortURL: "aaa", OriginalURL: "https://example.com/other", UserID: user2}},
+			wantErr: service.ErrImportConflict,
+		},
+		{
+			name:    "skip live long url of another short one",
+			policy:  models.ConflictSkip,
+			records: []models.URLRecord{{ShortURL: "ccc", OriginalURL: "https://example.com/a", UserID: user2}},
+			want:    models.ImportResult{Skipped: 1},
+		},
+		{
+			name:    "overwrite keeps live long url of another short one",
+			policy:  models.ConflictOverwrite,
+			records: []models.URLRecord{{ShortURL: "ccc", OriginalURL: "https://example.com/a", UserID: user2}},
+			wantErr: service.ErrImportConflict,
+		},
+		{
+			name:   "overwrite same short url",
+			policy: models.ConflictOverwrite,
+			records: []models.URLRecord{
+				{ShortURL: "bbb", OriginalURL: "https://example.com/b2", UserID: user2},
+				{ShortURL: "ddd", OriginalURL: "https://example.com/d", UserID: user2},
+			},
+			want: models.ImportResult{Imported: 1, Overwritten: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.ImportURLs(ctx, tt.records, tt.policy)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res)
+		})
+	}
+
+	long, err := s.Get(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", long, "the owner of the long url is left as is")
+	_, err = s.Get(ctx, "ccc")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+	long, err = s.Get(ctx, "bbb")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/b2", long)
+}
+
+func testAdmin(t *testing.T, s service.URLStorage) {
+	ctx := withUser(user1)
+	require.NoError(t, s.Save(ctx, "aaa", "https://example.com/a", nil))
+	require.NoError(t, s.SetURLDeleted(ctx, "aaa", true))
+	require.NoError(t, s.Save(withUser(user2), "bbb", "https://example.com/a", nil))
+
+	assert.ErrorIs(t, s.SetURLDeleted(ctx, "aaa", false), service.ErrRestoreConflict,
+		"another live link owns the long url")
+	_, err := s.Get(ctx, "aaa")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+	assert.ErrorIs(t, s.SetURLDeleted(ctx, "zzz", true), service.ErrURLNotFound)
+
+	require.NoError(t, s.SetURLDeleted(ctx, "bbb", true))
+	require.NoError(t, s.SetURLDeleted(ctx, "aaa", false))
+	require.NoError(t, s.SetURLDeleted(ctx, "aaa", false), "restoring a live link")
+	long, err := s.Get(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", long)
+	record, err := s.GetURLRecord(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, user1, record.UserID)
+	assert.False(t, record.Deleted)
+	_, err = s.GetURLRecord(ctx, "zzz")
+	assert.ErrorIs(t, err, service.ErrURLNotFound)
+
+	records, err := s.ListURLsByUser(ctx, user2)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "bbb", records[0].ShortURL)
+	assert.True(t, records[0].Deleted)
+
+	require.NoError(t, s.SetUserDisabled(ctx, user1, true))
+	disabled, err := s.IsUserDisabled(ctx, user1)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	disabled, err = s.IsUserDisabled(ctx, user2)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+	require.NoError(t, s.SetUserDisabled(ctx, user1, false))
+	disabled, err = s.IsUserDisabled(ctx, user1)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+}
+
+func testConcurrency(t *testing.T, s service.URLStorage) {
+	const (
+		workers = 8
+		perUser = 20
+	)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := withUser(fmt.Sprintf("worker-%d", w))
+			err := s.Save(ctx, "contested", fmt.Sprintf("https://example.com/contested/%d", w), nil)
+			if err == nil {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, service.ErrShortURLExists)
+			}
+			var deleted models.DeleteURLs
+			for i := 0; i < perUser; i++ {
+				short := fmt.Sprintf("w%d-%d", w, i)
+				long := fmt.Sprintf("https://example.com/%d/%d", w, i)
+				if !assert.NoError(t, s.Save(ctx, short, long, nil)) {
+					return
+				}
+				got, err := s.Get(ctx, short)
+				assert.NoError(t, err)
+				assert.Equal(t, long, got)
+				if i%2 == 0 {
+					deleted = append(deleted, short)
+				}
+			}
+			assert.NoError(t, s.DeleteURLs(ctx, deleted))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, winners, "one save takes the short url")
+
+	for w := 0; w < workers; w++ {
+		ctx := withUser(fmt.Sprintf("worker-%d", w))
+		rows, err := s.GetByUserID(ctx)
+		require.NoError(t, err)
+		live := 0
+		for _, row := range rows {
+			if row.Short != "contested" {
+				live++
+			}
+		}
+		assert.Equal(t, perUser/2, live, "worker %d", w)
+	}
+}
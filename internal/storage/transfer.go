@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	bolt "go.etcd.io/bbolt"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// ExportURLs passes every record of the database ordered by id to fn.
+func (d *inDatabase) ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls ORDER BY id`
+	rows, err := d.pool.Query(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("failed query db: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		record, err := scanURLRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+	return nil
+}
+
+// ImportURLs stores the records in a single transaction resolving conflicts by the policy.
+//
+// Besides the same short URL a live record with the same long URL is a conflict, as the database keeps them unique.
+// The overwrite policy replaces only the record with the same short URL, a live long URL owned by another
+// short one fails the import instead.
+func (d *inDatabase) ImportURLs(
+	ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	const (
+		longConflict = `long = @long::varchar AND is_deleted = FALSE AND @is_deleted::boolean = FALSE
+			AND short <> @short::varchar`
+		deleteStmt = `DELETE FROM urls WHERE short = @short::varchar
+			AND NOT EXISTS (SELECT 1 FROM urls WHERE ` + longConflict + `)`
+		insertStmt = `INSERT INTO urls (short, long, user_id, is_deleted, expires_at)
+			SELECT @short::varchar, @long::varchar, @user_id::varchar, @is_deleted::boolean, @expires_at::timestamptz
+			WHERE NOT EXISTS (SELECT 1 FROM urls WHERE short = @short::varchar OR ` + longConflict + `)`
+	)
+	var result models.ImportResult
+	if err := checkPolicy(policy); err != nil {
+		return result, err
+	}
+
+	tx, err := d.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: "read committed"})
+	if err != nil {
+		return result, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			d.log.Err("failed to rollback transaction: ", err)
+		}
+	}()
+
+	batch := pgx.Batch{}
+	for _, r := range records {
+		args := pgx.NamedArgs{
+			"short":      r.ShortURL,
+			"long":       r.OriginalURL,
+			"user_id":    r.UserID,
+			"is_deleted": r.Deleted,
+			"expires_at": r.ExpiresAt,
+		}
+		if policy == models.ConflictOverwrite {
+			batch.Queue(deleteStmt, args)
+		}
+		batch.Queue(insertStmt, args)
+	}
+	batchResults := tx.SendBatch(ctx, &batch)
+	for _, r := range records {
+		overwritten := false
+		if policy == models.ConflictOverwrite {
+			tag, err := batchResults.Exec()
+			if err != nil {
+				return result, errors.Join(fmt.Errorf("failed to delete conflicting rows: %w", err), batchResults.Close())
+			}
+			overwritten = tag.RowsAffected() > 0
+		}
+		tag, err := batchResults.Exec()
+		if err != nil {
+			return result, errors.Join(fmt.Errorf("failed to insert row: %w", err), batchResults.Close())
+		}
+		switch {
+		case tag.RowsAffected() == 0 && policy == models.ConflictSkip:
+			result.Skipped++
+		case tag.RowsAffected() == 0:
+			return result, errors.Join(fmt.Errorf("short %s: %w", r.ShortURL, service.ErrImportConflict),
+				batchResults.Close())
+		case overwritten:
+			result.Overwritten++
+		default:
+			result.Imported++
+		}
+	}
+	if err = batchResults.Close(); err != nil {
+		return result, fmt.Errorf("failed to close connection results: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// ExportURLs passes a snapshot of every record ordered by short URL to fn.
+func (m *inMemory) ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error {
+	for _, record := range m.urls.records() {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("export interrupted: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportURLs stores the records resolving conflicts by the policy.
+func (m *inMemory) ImportURLs(
+	_ context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	return m.importRecords(records, policy, noJournal)
+}
+
+// importRecords journals and stores the records with the whole table locked.
+//
+// Nothing is stored when a record conflicts with the fail policy or its live long URL belongs to another short one.
+func (m *inMemory) importRecords(
+	records []models.URLRecord, policy models.ConflictPolicy, journal journalFunc,
+) (models.ImportResult, error) {
+	var result models.ImportResult
+	if err := checkPolicy(policy); err != nil {
+		return result, err
+	}
+	err := m.urls.updateAll(func(tx *urlTx) error {
+		// pending holds the records stored so far by short URL, pendingLongs their live long URLs
+		pending := make(map[string]URLRecord, len(records))
+		pendingLongs := make(map[string]string, len(records))
+		// ownedElsewhere reports whether the long URL of a live record belongs to another live short URL
+		ownedElsewhere := func(r URLRecord) bool {
+			if r.Deleted {
+				return false
+			}
+			if short, ok := pendingLongs[r.OriginalURL]; ok {
+				p := pending[short]
+				if !p.Deleted && p.OriginalURL == r.OriginalURL {
+					return short != r.ShortURL
+				}
+			}
+			owner, ok := tx.owner(r.OriginalURL)
+			if !ok || owner.ShortURL == r.ShortURL {
+				return false
+			}
+			_, replaced := pending[owner.ShortURL]
+			return !replaced
+		}
+		stored := make([]URLRecord, 0, len(records))
+		counter := m.counter.Load()
+		for _, r := range records {
+			_, exists := tx.get(r.ShortURL)
+			_, repeated := pending[r.ShortURL]
+			owned := ownedElsewhere(r)
+			switch {
+			case (exists || repeated || owned) && policy == models.ConflictSkip:
+				result.Skipped++
+				continue
+			case owned || ((exists || repeated) && policy == models.ConflictFail):
+				return fmt.Errorf("short %s: %w", r.ShortURL, service.ErrImportConflict)
+			case exists || repeated:
+				result.Overwritten++
+			default:
+				result.Imported++
+			}
+			if n, err := strconv.ParseUint(r.UUID, 10, 64); err == nil {
+				counter = max(counter, n)
+			} else if r.UUID == "" {
+				counter++
+				r.UUID = strconv.FormatUint(counter, 10)
+			}
+			pending[r.ShortURL] = r
+			if !r.Deleted {
+				pendingLongs[r.OriginalURL] = r.ShortURL
+			}
+			stored = append(stored, r)
+		}
+		if err := journal(walEntry{Op: opSave, Records: stored}); err != nil {
+			return err
+		}
+		for _, r := range stored {
+			tx.put(r)
+		}
+		m.counter.Store(counter)
+		return nil
+	})
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	return result, nil
+}
+
+// ImportURLs stores the records resolving conflicts by the policy and logs the stored ones.
+func (f *inFile) ImportURLs(
+	_ context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	result, err := f.importRecords(records, policy, f.appendLog)
+	if err != nil {
+		return result, err
+	}
+	f.compactLog()
+	return result, nil
+}
+
+func checkPolicy(policy models.ConflictPolicy) error {
+	switch policy {
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail:
+		return nil
+	}
+	return fmt.Errorf("unknown conflict policy %q", policy)
+}
+
+// ExportURLs passes every record of the key-value storage ordered by short URL to fn.
+//
+// The records are read in a single read transaction, so fn sees a consistent snapshot.
+func (k *inKV) ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error {
+	return k.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketURLs).ForEach(func(_, data []byte) error {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("export interrupted: %w", err)
+			}
+			var r URLRecord
+			if err := json.Unmarshal(data, &r); err != nil {
+				return fmt.Errorf("failed to unmarshal record: %w", err)
+			}
+			return fn(r)
+		})
+	})
+}
+
+// ImportURLs stores the records in a single transaction resolving conflicts by the policy.
+//
+// Besides the same short URL a live record with the same long URL is a conflict, as the storage keeps them unique.
+func (k *inKV) ImportURLs(
+	_ context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	var result models.ImportResult
+	if err := checkPolicy(policy); err != nil {
+		return result, err
+	}
+	err := k.db.Update(func(tx *bolt.Tx) error {
+		for _, r := range records {
+			stored, exists, err := getRecord(tx, r.ShortURL)
+			if err != nil {
+				return err
+			}
+			owned := longOwnedElsewhere(tx, r)
+			switch {
+			case (exists || owned) && policy == models.ConflictSkip:
+				result.Skipped++
+				continue
+			case owned || (exists && policy == models.ConflictFail):
+				return fmt.Errorf("short %s: %w", r.ShortURL, service.ErrImportConflict)
+			case exists:
+				if err = deleteRecord(tx, stored); err != nil {
+					return err
+				}
+				result.Overwritten++
+			default:
+				result.Imported++
+			}
+			r.UUID = ""
+			if err = putRecord(tx, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	return result, nil
+}
+
+// longOwnedElsewhere reports whether the long URL of a live record belongs to the live record of another short URL.
+func longOwnedElsewhere(tx *bolt.Tx, r models.URLRecord) bool {
+	if r.Deleted {
+		return false
+	}
+	existing := tx.Bucket(bucketLongs).Get([]byte(r.OriginalURL))
+	return existing != nil && string(existing) != r.ShortURL
+}
+
+// ExportURLs passes every record of the SQLite database ordered by id to fn.
+//
+// The rows are read on the only connection, so fn must not call the storage.
+func (s *inSQLite) ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error {
+	const stmt = `SELECT id, short, long, user_id, is_deleted, expires_at FROM urls ORDER BY id`
+	return s.queryURLRecords(ctx, fn, stmt)
+}
+
+// ImportURLs stores the records in a single transaction resolving conflicts by the policy.
+//
+// Besides the same short URL a live record with the same long URL is a conflict, as the database keeps them unique.
+func (s *inSQLite) ImportURLs(
+	ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy,
+) (models.ImportResult, error) {
+	const (
+		longConflict = `long = @long AND is_deleted = FALSE AND @is_deleted = FALSE AND short <> @short`
+		deleteStmt   = `DELETE FROM urls WHERE short = @short AND NOT EXISTS (SELECT 1 FROM urls WHERE ` +
+			longConflict + `)`
+		insertStmt = `INSERT INTO urls (short, long, user_id, is_deleted, expires_at)
+			SELECT @short, @long, @user_id, @is_deleted, @expires_at
+			WHERE NOT EXISTS (SELECT 1 FROM urls WHERE short = @short OR ` + longConflict + `)`
+	)
+	var result models.ImportResult
+	if err := checkPolicy(policy); err != nil {
+		return result, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer s.rollback(tx)
+	for _, r := range records {
+		args := []any{
+			sql.Named("short", r.ShortURL),
+			sql.Named("long", r.OriginalURL),
+			sql.Named("user_id", r.UserID),
+			sql.Named("is_deleted", r.Deleted),
+			sql.Named("expires_at", toMicros(r.ExpiresAt)),
+		}
+		overwritten := false
+		if policy == models.ConflictOverwrite {
+			res, err := tx.ExecContext(ctx, deleteStmt, args...)
+			if err != nil {
+				return result, fmt.Errorf("failed to delete conflicting rows: %w", err)
+			}
+			deleted, err := res.RowsAffected()
+			if err != nil {
+				return result, fmt.Errorf("failed to count deleted rows: %w", err)
+			}
+			overwritten = deleted > 0
+		}
+		res, err := tx.ExecContext(ctx, insertStmt, args...)
+		if err != nil {
+			return result, fmt.Errorf("failed to insert row: %w", err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return result, fmt.Errorf("failed to count inserted rows: %w", err)
+		}
+		switch {
+		case inserted == 0 && policy == models.ConflictSkip:
+			result.Skipped++
+		case inserted == 0:
+			return result, fmt.Errorf("short %s: %w", r.ShortURL, service.ErrImportConflict)
+		case overwritten:
+			result.Overwritten++
+		default:
+			result.Imported++
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
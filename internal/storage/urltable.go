@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"hash/maphash"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryShards is the number of shards of the in-memory records, a power of two.
+const memoryShards = 64
+
+// urlShard holds the records whose short URLs hash to it.
+type urlShard struct {
+	mu   sync.RWMutex
+	urls map[string]URLRecord
+	// byUser indexes the short URLs of the shard by user, deleted records included.
+	byUser map[string]map[string]struct{}
+}
+
+// longShard maps the long URLs hashing to it to the short URL of their live record.
+//
+// An entry may outlive its record going deleted or being purged, so it is checked against the record.
+type longShard struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+// urlTable is the in-memory set of records split into shards by the hash of the short URL.
+//
+// Locks are taken in a fixed order: long shards before short URL shards, each kind by index,
+// so operations touching several shards can not deadlock.
+type urlTable struct {
+	seed   maphash.Seed
+	shards []urlShard
+	longs  []longShard
+}
+
+// newURLTable returns an empty table of n shards, n must be a power of two.
+func newURLTable(n int) *urlTable {
+	t := &urlTable{
+		seed:   maphash.MakeSeed(),
+		shards: make([]urlShard, n),
+		longs:  make([]longShard, n),
+	}
+	for i := range t.shards {
+		t.shards[i].urls = make(map[string]URLRecord)
+		t.shards[i].byUser = make(map[string]map[string]struct{})
+		t.longs[i].owners = make(map[string]string)
+	}
+	return t
+}
+
+// urlTableOf returns a table holding the records keyed by short URL.
+func urlTableOf(records map[string]URLRecord) *urlTable {
+	t := newURLTable(memoryShards)
+	tx := t.begin(t.allShards(), t.allShards())
+	defer tx.commit()
+	for short, r := range records {
+		r.ShortURL = short
+		tx.put(r)
+	}
+	return t
+}
+
+func (t *urlTable) index(key string) int {
+	return int(maphash.String(t.seed, key) & uint64(len(t.shards)-1))
+}
+
+func (t *urlTable) shard(short string) *urlShard {
+	return &t.shards[t.index(short)]
+}
+
+func (t *urlTable) allShards() []int {
+	all := make([]int, len(t.shards))
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// get returns the record of the short URL.
+func (t *urlTable) get(short string) (URLRecord, bool) {
+	s := t.shard(short)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.urls[short]
+	return r, ok
+}
+
+// userRecords returns every record of the user, deleted ones included, in no particular order.
+func (t *urlTable) userRecords(userID string) []URLRecord {
+	records := make([]URLRecord, 0)
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mu.RLock()
+		for short := range s.byUser[userID] {
+			records = append(records, s.urls[short])
+		}
+		s.mu.RUnlock()
+	}
+	return records
+}
+
+// records returns every record ordered by short URL. Each shard is copied under its own lock.
+func (t *urlTable) records() []URLRecord {
+	records := make([]URLRecord, 0)
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mu.RLock()
+		for _, r := range s.urls {
+			records = append(records, r)
+		}
+		s.mu.RUnlock()
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ShortURL < records[j].ShortURL })
+	return records
+}
+
+// len returns the number of records.
+func (t *urlTable) len() int {
+	n := 0
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mu.RLock()
+		n += len(s.urls)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// users returns the number of users having records, deleted ones included.
+func (t *urlTable) users() int {
+	users := make(map[string]struct{})
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mu.RLock()
+		for userID := range s.byUser {
+			users[userID] = struct{}{}
+		}
+		s.mu.RUnlock()
+	}
+	return len(users)
+}
+
+// stale returns the short URLs of the deleted records and the ones expired by now.
+func (t *urlTable) stale(now time.Time) []string {
+	shorts := make([]string, 0)
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mu.RLock()
+		for short, r := range s.urls {
+			if r.Deleted || r.Expired(now) {
+				shorts = append(shorts, short)
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return shorts
+}
+
+// purge removes the records of the short URLs still deleted or expired by now shard by shard
+// and returns the short URLs of the removed ones.
+func (t *urlTable) purge(shorts []string, now time.Time) []string {
+	byShard := make(map[int][]string)
+	for _, short := range shorts {
+		i := t.index(short)
+		byShard[i] = append(byShard[i], short)
+	}
+	purged := make([]string, 0, len(shorts))
+	removed := make([]URLRecord, 0, len(shorts))
+	for i, shorts := range byShard {
+		tx := t.begin(nil, []int{i})
+		for _, short := range shorts {
+			if r, ok := tx.get(short); ok && (r.Deleted || r.Expired(now)) {
+				tx.remove(short)
+				purged = append(purged, short)
+				removed = append(removed, r)
+			}
+		}
+		tx.commit()
+	}
+	t.forgetOwners(removed)
+	return purged
+}
+
+// forgetOwners drops the long URL entries still pointing to the removed records.
+func (t *urlTable) forgetOwners(removed []URLRecord) {
+	for _, r := range removed {
+		l := &t.longs[t.index(r.OriginalURL)]
+		l.mu.Lock()
+		if l.owners[r.OriginalURL] == r.ShortURL {
+			delete(l.owners, r.OriginalURL)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// update runs fn with the shards of the short and the long URLs locked for writing.
+//
+// The shards of the records currently owning the long URLs are locked too, so fn may retire them.
+func (t *urlTable) update(shorts, longs []string, fn func(tx *urlTx) error) error {
+	longIdx := make([]int, 0, len(longs))
+	for _, long := range longs {
+		longIdx = append(longIdx, t.index(long))
+	}
+	longIdx = uniqueSorted(longIdx)
+	for _, i := range longIdx {
+		t.longs[i].mu.Lock()
+	}
+	shardIdx := make([]int, 0, len(shorts)+len(longs))
+	for _, short := range shorts {
+		shardIdx = append(shardIdx, t.index(short))
+	}
+	for _, long := range longs {
+		if owner, ok := t.longs[t.index(long)].owners[long]; ok {
+			shardIdx = append(shardIdx, t.index(owner))
+		}
+	}
+	tx := t.lockShards(longIdx, uniqueSorted(shardIdx))
+	defer tx.commit()
+	return fn(tx)
+}
+
+// updateAll runs fn with the whole table locked for writing.
+func (t *urlTable) updateAll(fn func(tx *urlTx) error) error {
+	tx := t.begin(t.allShards(), t.allShards())
+	defer tx.commit()
+	return fn(tx)
+}
+
+// begin locks the long and the short URL shards by their sorted indexes.
+func (t *urlTable) begin(longIdx, shardIdx []int) *urlTx {
+	for _, i := range longIdx {
+		t.longs[i].mu.Lock()
+	}
+	return t.lockShards(longIdx, shardIdx)
+}
+
+// lockShards locks the short URL shards, the long shards are already locked.
+func (t *urlTable) lockShards(longIdx, shardIdx []int) *urlTx {
+	tx := &urlTx{t: t, longIdx: longIdx, shards: make([]*urlShard, 0, len(shardIdx))}
+	for _, i := range shardIdx {
+		s := &t.shards[i]
+		s.mu.Lock()
+		tx.shards = append(tx.shards, s)
+	}
+	return tx
+}
+
+func uniqueSorted(idx []int) []int {
+	sort.Ints(idx)
+	out := idx[:0]
+	for _, v := range idx {
+		if len(out) == 0 || v != out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// urlTx is a write access to the locked shards of the table.
+//
+// Records outside of the locked shards must not be touched.
+type urlTx struct {
+	t       *urlTable
+	longIdx []int
+	shards  []*urlShard
+}
+
+// commit releases the locks in the reverse order.
+func (tx *urlTx) commit() {
+	for i := len(tx.shards) - 1; i >= 0; i-- {
+		tx.shards[i].mu.Unlock()
+	}
+	for i := len(tx.longIdx) - 1; i >= 0; i-- {
+		tx.t.longs[tx.longIdx[i]].mu.Unlock()
+	}
+}
+
+func (tx *urlTx) get(short string) (URLRecord, bool) {
+	r, ok := tx.t.shard(short).urls[short]
+	return r, ok
+}
+
+// taken reports whether the short URL belongs to a live record.
+func (tx *urlTx) taken(short string) bool {
+	r, ok := tx.get(short)
+	return ok && !r.Deleted
+}
+
+// owner returns the live record of the long URL, its long shard must be locked.
+func (tx *urlTx) owner(long string) (URLRecord, bool) {
+	short, ok := tx.t.longs[tx.t.index(long)].owners[long]
+	if !ok {
+		return URLRecord{}, false
+	}
+	r, ok := tx.get(short)
+	if !ok || r.Deleted || r.OriginalURL != long {
+		return URLRecord{}, false
+	}
+	return r, true
+}
+
+// put stores the record. A live one becomes the owner of its long URL when the long shard is locked.
+func (tx *urlTx) put(r URLRecord) {
+	s := tx.t.shard(r.ShortURL)
+	if old, ok := s.urls[r.ShortURL]; ok && old.UserID != r.UserID {
+		s.unindex(old)
+	}
+	s.urls[r.ShortURL] = r
+	shorts, ok := s.byUser[r.UserID]
+	if !ok {
+		shorts = make(map[string]struct{})
+		s.byUser[r.UserID] = shorts
+	}
+	shorts[r.ShortURL] = struct{}{}
+	if !r.Deleted && tx.longLocked(r.OriginalURL) {
+		tx.t.longs[tx.t.index(r.OriginalURL)].owners[r.OriginalURL] = r.ShortURL
+	}
+}
+
+// remove deletes the record of the short URL.
+func (tx *urlTx) remove(short string) {
+	s := tx.t.shard(short)
+	if r, ok := s.urls[short]; ok {
+		s.unindex(r)
+		delete(s.urls, short)
+	}
+}
+
+func (tx *urlTx) longLocked(long string) bool {
+	i := tx.t.index(long)
+	n := sort.SearchInts(tx.longIdx, i)
+	return n < len(tx.longIdx) && tx.longIdx[n] == i
+}
+
+// unindex drops the record from the user index. The caller must hold the lock.
+func (s *urlShard) unindex(r URLRecord) {
+	shorts := s.byUser[r.UserID]
+	delete(shorts, r.ShortURL)
+	if len(shorts) == 0 {
+		delete(s.byUser, r.UserID)
+	}
+}
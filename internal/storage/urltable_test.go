@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+// urlMap copies the records of the table keyed by short URL.
+func urlMap(t *urlTable) map[string]URLRecord {
+	urls := make(map[string]URLRecord)
+	for _, r := range t.records() {
+		urls[r.ShortURL] = r
+	}
+	return urls
+}
+
+func TestURLTable_Indexes(t *testing.T) {
+	table := urlTableOf(map[string]URLRecord{
+		"a": {OriginalURL: "https://example.com/a", UserID: user1},
+		"b": {OriginalURL: "https://example.com/b", UserID: user1, Deleted: true},
+		"c": {OriginalURL: "https://example.com/c", UserID: user2},
+	})
+	shorts := func(records []URLRecord) []string {
+		res := make([]string, 0, len(records))
+		for _, r := range records {
+			res = append(res, r.ShortURL)
+		}
+		return res
+	}
+	assert.ElementsMatch(t, []string{"a", "b"}, shorts(table.userRecords(user1)))
+	assert.Equal(t, 2, table.users())
+	assert.Equal(t, 3, table.len())
+
+	owner := func(long string) (URLRecord, bool) {
+		var (
+			r  URLRecord
+			ok bool
+		)
+		require.NoError(t, table.update(nil, []string{long}, func(tx *urlTx) error {
+			r, ok = tx.owner(long)
+			return nil
+		}))
+		return r, ok
+	}
+	r, ok := owner("https://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, "a", r.ShortURL)
+	_, ok = owner("https://example.com/b")
+	assert.False(t, ok, "deleted record owns nothing")
+
+	require.NoError(t, table.update([]string{"c"}, nil, func(tx *urlTx) error {
+		r, _ := tx.get("c")
+		r.UserID = user1
+		tx.put(r)
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, shorts(table.userRecords(user1)))
+	assert.Empty(t, table.userRecords(user2), "moved record leaves the old user")
+	assert.Equal(t, 1, table.users())
+
+	require.NoError(t, table.update([]string{"a"}, nil, func(tx *urlTx) error {
+		r, _ := tx.get("a")
+		r.Deleted = true
+		tx.put(r)
+		return nil
+	}))
+	_, ok = owner("https://example.com/a")
+	assert.False(t, ok, "stale owner entry is ignored")
+
+	now := time.Now()
+	purged := table.purge(append(table.stale(now), "missing"), now)
+	assert.ElementsMatch(t, []string{"a", "b"}, purged)
+	assert.ElementsMatch(t, []string{"c"}, shorts(table.userRecords(user1)))
+	for i := range table.longs {
+		assert.NotContains(t, table.longs[i].owners, "https://example.com/a", "purged owner is forgotten")
+	}
+}
+
+func TestInMemory_ConcurrentBatches(t *testing.T) {
+	m := newBenchMemory(memoryShards)
+	const (
+		workers = 8
+		batches = 50
+		size    = 5
+	)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), models.CtxUserIDKey, fmt.Sprintf("user-%d", w))
+			for b := 0; b < batches; b++ {
+				// every batch spans several shards and shares long URLs with the other workers
+				batch := make(models.BatchArray, 0, size)
+				for i := 0; i < size; i++ {
+					batch = append(batch, models.Batch{
+						ShortURL:    fmt.Sprintf("w%d-b%d-%d", w, b, i),
+						OriginalURL: fmt.Sprintf("https://example.com/%d/%d", b, i),
+					})
+				}
+				if _, err := m.BatchSave(ctx, batch); err == nil {
+					shorts := make(models.DeleteURLs, 0, size)
+					for _, item := range batch {
+						shorts = append(shorts, item.ShortURL)
+					}
+					assert.NoError(t, m.DeleteURLs(ctx, shorts))
+				}
+				_, err := m.GetByUserID(ctx)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	longs := make(map[string]string)
+	for _, r := range m.urls.records() {
+		if r.Deleted {
+			continue
+		}
+		if short, ok := longs[r.OriginalURL]; ok {
+			t.Errorf("%s is shortened by both %s and %s", r.OriginalURL, short, r.ShortURL)
+		}
+		longs[r.OriginalURL] = r.ShortURL
+	}
+}
+
+func newBenchMemory(shards int) *inMemory {
+	log := &logger.Log{}
+	log.Initialize("ERROR")
+	return &inMemory{
+		Log:  log,
+		mux:  &sync.Mutex{},
+		cfg:  &config.Config{App: config.AppConfig{BaseURL: "http://localhost:8080"}},
+		urls: newURLTable(shards),
+	}
+}
+
+// BenchmarkInMemory_Parallel compares a single shard, which is one lock as before the sharding,
+// with the default number of shards under parallel reads and writes.
+func BenchmarkInMemory_Parallel(b *testing.B) {
+	const preloaded = 10000
+	workloads := []struct {
+		name      string
+		saveEvery int
+	}{
+		{name: "get", saveEvery: 0},
+		{name: "get 90 save 10", saveEvery: 10},
+		{name: "save", saveEvery: 1},
+	}
+	for _, shards := range []int{1, memoryShards} {
+		for _, w := range workloads {
+			b.Run(fmt.Sprintf("shards=%d/%s", shards, w.name), func(b *testing.B) {
+				m := newBenchMemory(shards)
+				ctx := context.WithValue(context.Background(), models.CtxUserIDKey, user1)
+				for i := 0; i < preloaded; i++ {
+					short := strconv.Itoa(i)
+					require.NoError(b, m.Save(ctx, short, "https://example.com/"+short, nil))
+				}
+				var next atomic.Uint64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for i := 0; pb.Next(); i++ {
+						n := next.Add(1)
+						if w.saveEvery > 0 && i%w.saveEvery == 0 {
+							short := "new-" + strconv.FormatUint(n, 10)
+							if err := m.Save(ctx, short, "https://example.com/"+short, nil); err != nil {
+								b.Error(err)
+							}
+							continue
+						}
+						if _, err := m.Get(ctx, strconv.FormatUint(n%preloaded, 10)); err != nil {
+							b.Error(err)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkInMemory_GetByUserID reads the links of one user among many others.
+func BenchmarkInMemory_GetByUserID(b *testing.B) {
+	const (
+		users   = 1000
+		perUser = 10
+	)
+	m := newBenchMemory(memoryShards)
+	for u := 0; u < users; u++ {
+		ctx := context.WithValue(context.Background(), models.CtxUserIDKey, strconv.Itoa(u))
+		for i := 0; i < perUser; i++ {
+			short := fmt.Sprintf("%d-%d", u, i)
+			require.NoError(b, m.Save(ctx, short, "https://example.com/"+short, nil))
+		}
+	}
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "42")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := m.GetByUserID(ctx)
+		if err != nil || len(rows) != perUser {
+			b.Fatalf("got %d rows: %v", len(rows), err)
+		}
+	}
+}
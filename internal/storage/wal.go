@@ -0,0 +1,410 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Operations of the file storage log.
+const (
+	// opSave puts the records replacing the ones with the same short URL.
+	opSave = "save"
+	// opDelete marks the short URLs deleted.
+	opDelete = "delete"
+	// opPurge removes the short URLs.
+	opPurge = "purge"
+	// opCounters raises the counters, which must not go back when the records holding them are purged.
+	opCounters = "counters"
+)
+
+// Fsync policies of the file storage log.
+const (
+	// FsyncAlways syncs every operation before it is acknowledged.
+	FsyncAlways = "always"
+	// FsyncInterval syncs at most once per interval, a crash of the machine loses the operations since.
+	FsyncInterval = "interval"
+	// FsyncNever leaves syncing to the operating system.
+	FsyncNever = "never"
+)
+
+const (
+	// compactMinEntries is the log size below which it is never compacted.
+	compactMinEntries = 1000
+	// compactChunk is the number of records per save operation of a compacted log.
+	compactChunk = 1000
+	// checksumLen is the length of the hex CRC-32C prefix of a log line.
+	checksumLen = 8
+	// sequenceBlock is the number of short code sequence values reserved by a single log entry.
+	sequenceBlock = 100
+)
+
+var (
+	// ErrCorruptLog error indicates a damaged record followed by valid ones, which a crash can not cause.
+	ErrCorruptLog = errors.New("file storage log is corrupt")
+	// ErrLogFailed error indicates a log left broken by a failed write or sync, which takes no appends until reopened.
+	ErrLogFailed = errors.New("file storage log failed")
+	// ErrInvalidFsync error indicates an unknown fsync policy.
+	ErrInvalidFsync = errors.New("invalid fsync policy")
+	// errBadRecord error indicates a record failing the checksum or the decoding.
+	errBadRecord = errors.New("bad log record")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walEntry is a single operation of the file storage log.
+type walEntry struct {
+	Op      string      `json:"op"`
+	Records []URLRecord `json:"records,omitempty"`
+	Shorts  []string    `json:"shorts,omitempty"`
+	// Counter is the highest record UUID issued.
+	Counter uint64 `json:"counter,omitempty"`
+	// Sequence is the highest short code sequence value reserved.
+	Sequence uint64 `json:"sequence,omitempty"`
+}
+
+// walCounters are the highest record UUID and sequence value found in the log.
+type walCounters struct {
+	counter  uint64
+	sequence uint64
+}
+
+// empty reports whether the operation changes nothing.
+func (e walEntry) empty() bool {
+	return len(e.Records) == 0 && len(e.Shorts) == 0 && e.Counter == 0 && e.Sequence == 0
+}
+
+// apply applies the operation to the records keyed by short URL and raises the counters.
+func (e walEntry) apply(urls map[string]URLRecord, counters *walCounters) {
+	switch e.Op {
+	case opSave:
+		for _, r := range e.Records {
+			urls[r.ShortURL] = r
+			if n, err := strconv.ParseUint(r.UUID, 10, 64); err == nil {
+				counters.counter = max(counters.counter, n)
+			}
+		}
+	case opDelete:
+		for _, short := range e.Shorts {
+			if r, ok := urls[short]; ok {
+				r.Deleted = true
+				urls[short] = r
+			}
+		}
+	case opPurge:
+		for _, short := range e.Shorts {
+			delete(urls, short)
+		}
+	case opCounters:
+		counters.counter = max(counters.counter, e.Counter)
+		counters.sequence = max(counters.sequence, e.Sequence)
+	}
+}
+
+// encodeEntry returns the log line of the operation.
+func encodeEntry(e walEntry) ([]byte, error) {
+	line, err := encodeLine(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode log entry: %w", err)
+	}
+	return line, nil
+}
+
+// decodeEntry parses the log line without its newline.
+//
+// A line holding a bare JSON record is the format before the log and is read as its save.
+func decodeEntry(line []byte) (walEntry, error) {
+	data, err := decodeLine(line)
+	if err != nil {
+		return walEntry{}, err
+	}
+	if isLegacyLine(line) {
+		var r URLRecord
+		if err = json.Unmarshal(data, &r); err != nil {
+			return walEntry{}, fmt.Errorf("legacy record: %w", errBadRecord)
+		}
+		return walEntry{Op: opSave, Records: []URLRecord{r}}, nil
+	}
+	var e walEntry
+	if err = json.Unmarshal(data, &e); err != nil {
+		return walEntry{}, fmt.Errorf("entry: %w", errBadRecord)
+	}
+	switch e.Op {
+	case opSave, opDelete, opPurge, opCounters:
+		return e, nil
+	}
+	return walEntry{}, fmt.Errorf("operation %q: %w", e.Op, errBadRecord)
+}
+
+// replayLog applies the operations of the log to urls and counters and returns the size of its valid part
+// and their number. A torn tail is left out as readLines describes.
+func replayLog(r io.Reader, urls map[string]URLRecord, counters *walCounters) (int64, int, error) {
+	return readLines(r, func(line []byte) error {
+		e, err := decodeEntry(line)
+		if err != nil {
+			return err
+		}
+		e.apply(urls, counters)
+		return nil
+	})
+}
+
+// wal is the append-only log of the file storage. The caller must serialize the calls,
+// the lock only guards the file against the background sync.
+type wal struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	fsync    string
+	interval time.Duration
+	// size is the length of the entries written in full, a failed append is cut back to it.
+	size int64
+	// dirty reports whether the log has appends the background sync has not synced yet.
+	dirty bool
+	// failed is the error the log is left broken with, no append is taken after it.
+	failed error
+	// entries counts the operations in the log to decide on the compaction.
+	entries int
+	// stop ends the background sync, which closes done when it returns.
+	stop chan struct{}
+	done chan struct{}
+}
+
+// openWAL replays the log into urls and counters, cuts off a torn tail and opens the log for appending.
+// A missing log is created readable by the owner only, as the compacted one is.
+// Under FsyncInterval it starts syncing the log in the background until the log is closed.
+func openWAL(
+	path, fsync string, interval time.Duration, urls map[string]URLRecord, counters *walCounters,
+) (*wal, int64, error) {
+	if err := checkFsync(fsync); err != nil {
+		return nil, 0, err
+	}
+	if err := prepareDir(path); err != nil {
+		return nil, 0, fmt.Errorf("failed to prepare directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	valid, entries, err := replayLog(file, urls, counters)
+	if err != nil {
+		return nil, 0, errors.Join(err, file.Close())
+	}
+	dropped, err := truncateTail(file, valid)
+	if err != nil {
+		return nil, 0, errors.Join(err, file.Close())
+	}
+	if _, err = file.Seek(0, io.SeekEnd); err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to seek file: %w", err), file.Close())
+	}
+	w := &wal{path: path, file: file, fsync: fsync, interval: interval, size: valid, entries: entries}
+	if fsync == FsyncInterval && interval > 0 {
+		w.stop, w.done = make(chan struct{}), make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, dropped, nil
+}
+
+// checkFsync returns ErrInvalidFsync for an unknown policy. Empty one means FsyncAlways.
+func checkFsync(fsync string) error {
+	switch fsync {
+	case "", FsyncAlways, FsyncInterval, FsyncNever:
+		return nil
+	}
+	return fmt.Errorf("%q: %w", fsync, ErrInvalidFsync)
+}
+
+// append writes the operation and syncs it by the policy.
+//
+// A failed write is cut off the log, so the next entry does not follow a partial one.
+// When that fails too, the log is marked failed and takes no more appends.
+func (w *wal) append(e walEntry) error {
+	line, err := encodeEntry(e)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed != nil {
+		return fmt.Errorf("%w: %w", ErrLogFailed, w.failed)
+	}
+	if _, err = w.file.Write(line); err != nil {
+		err = fmt.Errorf("failed to write log entry: %w", err)
+		w.rewind(err)
+		return err
+	}
+	w.size += int64(len(line))
+	w.entries++
+	return w.syncByPolicy()
+}
+
+// rewind cuts the log back to the entries written in full or marks it failed with cause when it can not.
+// The caller must hold the lock.
+func (w *wal) rewind(cause error) {
+	if err := w.file.Truncate(w.size); err != nil {
+		w.failed = errors.Join(cause, fmt.Errorf("failed to truncate log: %w", err))
+		return
+	}
+	if _, err := w.file.Seek(w.size, io.SeekStart); err != nil {
+		w.failed = errors.Join(cause, fmt.Errorf("failed to seek log: %w", err))
+	}
+}
+
+// syncByPolicy syncs the appended entry now under FsyncAlways, leaves it to the background sync
+// under FsyncInterval and to the operating system under FsyncNever. The caller must hold the lock.
+func (w *wal) syncByPolicy() error {
+	switch w.fsync {
+	case FsyncNever:
+		return nil
+	case FsyncInterval:
+		if w.stop != nil {
+			w.dirty = true
+			return nil
+		}
+	}
+	return w.sync()
+}
+
+// sync syncs the log. A failed sync marks the log failed, as the written pages may be lost already
+// and a retry can not tell. The caller must hold the lock.
+func (w *wal) sync() error {
+	if err := w.file.Sync(); err != nil {
+		w.failed = fmt.Errorf("failed to sync log: %w", err)
+		return w.failed
+	}
+	w.dirty = false
+	return nil
+}
+
+// syncLoop syncs the log every interval when it has appends not synced yet, until stop is closed.
+func (w *wal) syncLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.dirty && w.failed == nil {
+				_ = w.sync() // the next append reports the failure
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// needsCompaction reports whether the log has grown well past the live records.
+func (w *wal) needsCompaction(live int) bool {
+	return w.entries > compactMinEntries && w.entries > 2*live
+}
+
+// compact replaces the log with the counters and the save operations of the records.
+//
+// The records are written to a temporary file next to the log, synced and renamed over it,
+// so a crash leaves either the old or the new log. Once renamed the new file takes the appends,
+// and a failure to sync the directory marks the log failed like any other failed sync.
+func (w *wal) compact(records []URLRecord, counters walCounters) error {
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".compact-*")
+	if err != nil {
+		return fmt.Errorf("failed to create compacted log: %w", err)
+	}
+	entries, size, err := writeCompacted(tmp, records, counters)
+	if err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmp.Name()))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err = os.Rename(tmp.Name(), w.path); err != nil {
+		return errors.Join(fmt.Errorf("failed to replace log: %w", err), tmp.Close(), os.Remove(tmp.Name()))
+	}
+	old := w.file
+	w.file, w.size, w.entries, w.dirty = tmp, size, entries, false
+	if err = syncDir(filepath.Dir(w.path)); err != nil {
+		w.failed = fmt.Errorf("failed to sync log: %w", err)
+	}
+	if closeErr := old.Close(); closeErr != nil {
+		return errors.Join(w.failed, fmt.Errorf("failed to close old log: %w", closeErr))
+	}
+	return w.failed
+}
+
+// writeCompacted writes the counters and the records by chunks, syncs the file and returns
+// the number of entries and the size written.
+func writeCompacted(file *os.File, records []URLRecord, counters walCounters) (int, int64, error) {
+	writer := bufio.NewWriter(file)
+	var (
+		entries int
+		size    int64
+	)
+	write := func(e walEntry) error {
+		line, err := encodeEntry(e)
+		if err != nil {
+			return err
+		}
+		if _, err = writer.Write(line); err != nil {
+			return fmt.Errorf("failed to write compacted log: %w", err)
+		}
+		entries++
+		size += int64(len(line))
+		return nil
+	}
+	header := walEntry{Op: opCounters, Counter: counters.counter, Sequence: counters.sequence}
+	if !header.empty() {
+		if err := write(header); err != nil {
+			return 0, 0, err
+		}
+	}
+	for start := 0; start < len(records); start += compactChunk {
+		end := min(start+compactChunk, len(records))
+		if err := write(walEntry{Op: opSave, Records: records[start:end]}); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, 0, fmt.Errorf("failed to write compacted log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, 0, fmt.Errorf("failed to sync compacted log: %w", err)
+	}
+	return entries, size, nil
+}
+
+// syncDir syncs the directory to persist a rename in it.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	if err = d.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync directory: %w", err), d.Close())
+	}
+	if err = d.Close(); err != nil {
+		return fmt.Errorf("failed to close directory: %w", err)
+	}
+	return nil
+}
+
+// close stops the background sync, syncs and closes the log.
+func (w *wal) close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	if err := w.file.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("failed to sync log: %w", err), w.file.Close())
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/config"
+	"shortener/internal/logger"
+	"shortener/internal/models"
+)
+
+func newTestFileStorage(t *testing.T, filePath string) *inFile {
+	t.Helper()
+	log := &logger.Log{}
+	log.Initialize("ERROR")
+	f := &inFile{
+		inMemory: inMemory{Log: log, mux: &sync.Mutex{}, cfg: &config.Config{}, urls: newURLTable(memoryShards)},
+		filePath: filePath,
+	}
+	require.NoError(t, f.restore())
+	t.Cleanup(func() {
+		assert.NoError(t, f.Close())
+	})
+	return f
+}
+
+// writeTestLog runs a fixed sequence of operations and returns the log and its state after each line.
+func writeTestLog(t *testing.T) ([]byte, []map[string]URLRecord) {
+	t.Helper()
+	filePath := path.Join(t.TempDir(), filename)
+	f := newTestFileStorage(t, filePath)
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user1")
+
+	states := []map[string]URLRecord{{}}
+	snapshot := func() {
+		states = append(states, urlMap(f.urls))
+	}
+	require.NoError(t, f.Save(ctx, "aaa", "https://example.com/a", nil))
+	snapshot()
+	_, err := f.BatchSave(ctx, models.BatchArray{
+		{CorrelationID: "1", ShortURL: "bbb", OriginalURL: "https://example.com/b"},
+		{CorrelationID: "2", ShortURL: "ccc", OriginalURL: "https://example.com/c"},
+	})
+	require.NoError(t, err)
+	snapshot()
+	require.NoError(t, f.DeleteURLs(ctx, models.DeleteURLs{"aaa", "bbb"}))
+	snapshot()
+	_, err = f.Cleanup(ctx)
+	require.NoError(t, err)
+	snapshot()
+	require.NoError(t, f.SetURLDeleted(ctx, "ccc", true))
+	snapshot()
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, len(states)-1, bytes.Count(data, []byte{'\n'}))
+	return data, states
+}
+
+func TestWAL_CrashRecovery(t *testing.T) {
+	data, states := writeTestLog(t)
+	dir := t.TempDir()
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user2")
+
+	for offset := 0; offset <= len(data); offset++ {
+		filePath := filepath.Join(dir, fmt.Sprintf("log-%d", offset))
+		require.NoError(t, os.WriteFile(filePath, data[:offset], 0666))
+
+		complete := bytes.LastIndexByte(data[:offset], '\n') + 1
+		f := newTestFileStorage(t, filePath)
+		assert.Equal(t, states[bytes.Count(data[:complete], []byte{'\n'})], urlMap(f.urls), "offset %d", offset)
+
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(complete), info.Size(), "torn tail is cut off at offset %d", offset)
+
+		require.NoError(t, f.Save(ctx, "zzz", "https://example.com/z", nil))
+		require.NoError(t, f.Close())
+		urls, err := ReadFileStorage(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, urls, "zzz", "append after recovery at offset %d", offset)
+	}
+}
+
+func TestWAL_Corruption(t *testing.T) {
+	data, _ := writeTestLog(t)
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+		wantErr error
+	}{
+		{
+			name: "flipped byte in the middle",
+			corrupt: func(b []byte) []byte {
+				b[bytes.IndexByte(b, '\n')+20] ^= 0x01
+				return b
+			},
+			wantErr: ErrCorruptLog,
+		},
+		{
+			name: "garbage line in the middle",
+			corrupt: func(b []byte) []byte {
+				first := bytes.IndexByte(b, '\n') + 1
+				return append(append(b[:first:first], []byte("garbage\n")...), b[first:]...)
+			},
+			wantErr: ErrCorruptLog,
+		},
+		{
+			name: "flipped byte in the last record",
+			corrupt: func(b []byte) []byte {
+				b[len(b)-5] ^= 0x01
+				return b
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := path.Join(t.TempDir(), filename)
+			require.NoError(t, os.WriteFile(filePath, tt.corrupt(bytes.Clone(data)), 0666))
+			_, err := ReadFileStorage(filePath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWAL_LegacyFormat(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
+	var legacy []byte
+	for _, r := range []URLRecord{
+		{UUID: "1", OriginalURL: "https://example.com/1", ShortURL: "short1", UserID: "user1"},
+		{UUID: "2", OriginalURL: "https://example.com/2", ShortURL: "short2", UserID: "user1", Deleted: true},
+	} {
+		line, err := json.Marshal(r)
+		require.NoError(t, err)
+		legacy = append(append(legacy, line...), '\n')
+	}
+	require.NoError(t, os.WriteFile(filePath, legacy, 0666))
+
+	f := newTestFileStorage(t, filePath)
+	urls := urlMap(f.urls)
+	require.Len(t, urls, 2)
+	assert.True(t, urls["short2"].Deleted)
+
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user1")
+	require.NoError(t, f.DeleteURLs(ctx, models.DeleteURLs{"short1"}))
+	require.NoError(t, f.Close())
+	urls, err := ReadFileStorage(filePath)
+	require.NoError(t, err)
+	assert.True(t, urls["short1"].Deleted, "log entries follow the legacy lines")
+}
+
+func TestWAL_Compaction(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
+	f := newTestFileStorage(t, filePath)
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user1")
+
+	const live = 10
+	for i := 0; i < live; i++ {
+		require.NoError(t, f.Save(ctx, fmt.Sprintf("live-%d", i), fmt.Sprintf("https://example.com/live/%d", i), nil))
+	}
+	var maxSize int64
+	// every round logs three entries, so the log outgrows compactMinEntries
+	for i := 0; i < compactMinEntries/3+10; i++ {
+		short := fmt.Sprintf("temp-%d", i)
+		require.NoError(t, f.Save(ctx, short, "https://example.com/temp", nil))
+		require.NoError(t, f.DeleteURLs(ctx, models.DeleteURLs{short}))
+		_, err := f.Cleanup(ctx)
+		require.NoError(t, err)
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		maxSize = max(maxSize, info.Size())
+	}
+	require.NoError(t, f.Save(ctx, "after", "https://example.com/after", nil))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), maxSize/10)
+	assert.Less(t, f.journal.entries, compactMinEntries/10, "the log was compacted")
+
+	logInfo, err := f.journal.file.Stat()
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(info, logInfo), "the appends go to the compacted log")
+
+	matches, err := filepath.Glob(filePath + ".compact-*")
+	require.NoError(t, err)
+	assert.Empty(t, matches, "no temporary file is left")
+
+	require.NoError(t, f.Close())
+	urls, err := ReadFileStorage(filePath)
+	require.NoError(t, err)
+	assert.Len(t, urls, live+1)
+	assert.Contains(t, urls, "after")
+}
+
+func TestWAL_Counters(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
+	ctx := context.WithValue(context.Background(), models.CtxUserIDKey, "user1")
+	f := newTestFileStorage(t, filePath)
+	for _, short := range []string{"aaa", "bbb"} {
+		require.NoError(t, f.Save(ctx, short, "https://example.com/"+short, nil))
+	}
+	var issued uint64
+	for i := 0; i < 3; i++ {
+		n, err := f.NextSequence(ctx)
+		require.NoError(t, err)
+		issued = n
+	}
+	require.NoError(t, f.DeleteURLs(ctx, models.DeleteURLs{"bbb"}))
+	_, err := f.Cleanup(ctx)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	check := func(t *testing.T, f *inFile) {
+		t.Helper()
+		n, err := f.NextSequence(ctx)
+		require.NoError(t, err)
+		assert.Greater(t, n, issued, "sequence values are not issued again")
+		require.NoError(t, f.Save(ctx, "ccc", "https://example.com/c", nil))
+		r, ok := f.urls.get("ccc")
+		require.True(t, ok)
+		assert.Equal(t, "3", r.UUID, "the UUID of the purged record is not reused")
+	}
+	restored := newTestFileStorage(t, filePath)
+	check(t, restored)
+	require.NoError(t, restored.DeleteURLs(ctx, models.DeleteURLs{"ccc"}))
+	_, err = restored.Cleanup(ctx)
+	require.NoError(t, err)
+	require.NoError(t, restored.journal.compact(restored.urls.records(),
+		walCounters{counter: restored.counter.Load(), sequence: restored.sequenceLimit}))
+	require.NoError(t, restored.Close())
+
+	issued += sequenceBlock
+	compacted := newTestFileStorage(t, filePath)
+	n, err := compacted.NextSequence(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, n, issued, "the compacted log keeps the reserved sequence values")
+	assert.Equal(t, uint64(3), compacted.counter.Load(), "the compacted log keeps the counter")
+}
+
+func TestWAL_Fsync(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsync   string
+		wantErr error
+	}{
+		{name: "default", fsync: ""},
+		{name: "always", fsync: FsyncAlways},
+		{name: "interval", fsync: FsyncInterval},
+		{name: "never", fsync: FsyncNever},
+		{name: "unknown", fsync: "sometimes", wantErr: ErrInvalidFsync},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := path.Join(t.TempDir(), filename)
+			journal, _, err := openWAL(filePath, tt.fsync, time.Hour, make(map[string]URLRecord), &walCounters{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, journal.append(walEntry{Op: opPurge, Shorts: []string{"aaa"}}))
+			require.NoError(t, journal.close())
+		})
+	}
+}
+
+func TestWAL_AppendFailure(t *testing.T) {
+	entry := walEntry{Op: opPurge, Shorts: []string{"aaa"}}
+	open := func(t *testing.T) (*wal, string) {
+		t.Helper()
+		filePath := path.Join(t.TempDir(), filename)
+		journal, _, err := openWAL(filePath, FsyncAlways, 0, make(map[string]URLRecord), &walCounters{})
+		require.NoError(t, err)
+		require.NoError(t, journal.append(entry))
+		return journal, filePath
+	}
+
+	t.Run("partial write is cut off", func(t *testing.T) {
+		journal, filePath := open(t)
+		// a write failing halfway leaves a part of the entry behind
+		_, err := journal.file.Write([]byte(`0000abcd {"op":"pu`))
+		require.NoError(t, err)
+		journal.rewind(assert.AnError)
+		require.NoError(t, journal.failed)
+
+		require.NoError(t, journal.append(entry))
+		require.NoError(t, journal.close())
+		file, err := os.Open(filePath)
+		require.NoError(t, err)
+		defer file.Close() //nolint:errcheck // read only
+		valid, lines, err := replayLog(file, make(map[string]URLRecord), &walCounters{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, lines)
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), valid, "nothing follows the entries")
+	})
+
+	t.Run("log is failed when it can not be cut", func(t *testing.T) {
+		journal, filePath := open(t)
+		writable := journal.file
+		readOnly, err := os.Open(filePath)
+		require.NoError(t, err)
+		journal.file = readOnly
+		assert.Error(t, journal.append(entry))
+		assert.ErrorIs(t, journal.append(entry), ErrLogFailed, "no append is taken after the failure")
+
+		journal.file = writable
+		assert.ErrorIs(t, journal.append(entry), ErrLogFailed)
+		require.NoError(t, readOnly.Close())
+		require.NoError(t, journal.close())
+	})
+}
+
+func TestWAL_FsyncInterval(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
+	journal, _, err := openWAL(filePath, FsyncInterval, 10*time.Millisecond, make(map[string]URLRecord), &walCounters{})
+	require.NoError(t, err)
+	require.NoError(t, journal.append(walEntry{Op: opPurge, Shorts: []string{"aaa"}}))
+	assert.Eventually(t, func() bool {
+		journal.mu.Lock()
+		defer journal.mu.Unlock()
+		return !journal.dirty
+	}, time.Second, 5*time.Millisecond, "the background sync syncs the appended entry")
+
+	require.NoError(t, journal.close())
+	select {
+	case <-journal.done:
+	default:
+		t.Error("close stops the background sync")
+	}
+}
+
+func TestWAL_Permissions(t *testing.T) {
+	filePath := path.Join(t.TempDir(), filename)
+	f := newTestFileStorage(t, filePath)
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "a new log")
+
+	require.NoError(t, f.journal.compact(f.urls.records(), walCounters{}))
+	info, err = os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "a compacted log")
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"time"
 
+	"shortener/internal/health"
 	"shortener/internal/logger"
+	"shortener/internal/metrics"
 	"shortener/internal/service"
 )
 
 // Run runs a background task to clean up the storage periodically.
 //
-// It uses a ticker to schedule the cleanup at the specified interval.
-func Run(ctx context.Context, store service.URLStorage, log *logger.Log, interval time.Duration) {
+// Each run purges both soft-deleted and expired URLs. It uses a ticker to schedule the cleanup at the specified interval.
+// The heartbeat, if any, beats on start and on every run for the readiness check.
+func Run(ctx context.Context, store service.URLStorage, log *logger.Log, interval time.Duration, beat *health.Heartbeat) {
 	log.Debug("starting storage cleanup task", "period", interval)
+	beat.Beat()
 
 	ticker := time.NewTicker(interval)
 	for range ticker.C {
@@ -22,12 +26,15 @@ func Run(ctx context.Context, store service.URLStorage, log *logger.Log, interva
 			ticker.Stop()
 			return
 		default:
+			beat.Beat()
 			urls, err := store.Cleanup(ctx)
+			metrics.CleanupRuns.WithLabelValues(metrics.Result(err)).Inc()
+			metrics.CleanupDeleted.Add(float64(len(urls)))
 			if err != nil {
 				log.Err("failed cleanup storage", err)
 			}
 			if len(urls) > 0 {
-				log.Info("The following url IDs has been deleted from the storage", "URLs", urls)
+				log.Info("The following short URLs has been deleted from the storage", "URLs", urls)
 			} else {
 				log.Info("Nothing to delete. Going to sleep", "time", interval)
 			}
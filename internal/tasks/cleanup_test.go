@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"shortener/internal/health"
 	"shortener/internal/logger"
 	"shortener/internal/service/mocks"
 )
@@ -46,11 +47,13 @@ func TestRunBackgroundCleanupDB(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockStore.EXPECT().Cleanup(ctx).AnyTimes().Return(tt.cleanupURLs, tt.cleanupError)
 
-			go Run(ctx, mockStore, log, interval)
+			beat := &health.Heartbeat{}
+			go Run(ctx, mockStore, log, interval, beat)
 
 			time.Sleep(150 * time.Millisecond)
 
 			assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+			assert.NoError(t, beat.Check(time.Second)(ctx))
 		})
 	}
 }
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PgxTracer runs the pgx queries, batches and copies in spans. Query arguments are not recorded.
+type PgxTracer struct{}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = startDB(ctx, "db.query", semconv.DBQueryText(data.SQL))
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	End(trace.SpanFromContext(ctx), data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (PgxTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = startDB(ctx, "db.batch", attribute.Int("db.operation.batch.size", data.Batch.Len()))
+	return ctx
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. The queries are events of the batch span.
+func (PgxTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("db.batch.query", trace.WithAttributes(semconv.DBQueryText(data.SQL)))
+	if data.Err != nil {
+		span.RecordError(data.Err)
+	}
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (PgxTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	End(trace.SpanFromContext(ctx), data.Err)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (PgxTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = startDB(ctx, "db.copy",
+		semconv.DBCollectionName(data.TableName.Sanitize()),
+		semconv.DBQueryText("COPY "+data.TableName.Sanitize()+" ("+strings.Join(data.ColumnNames, ", ")+")"),
+	)
+	return ctx
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (PgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	End(trace.SpanFromContext(ctx), data.Err)
+}
+
+func startDB(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		trace.WithAttributes(attrs...),
+	)
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"shortener/internal/models"
+	"shortener/internal/service"
+)
+
+// Storage wraps any URLStorage and runs the methods on the request and the background paths in a span.
+// The rest go to the embedded storage as is, their pgx queries are still traced.
+type Storage struct {
+	service.URLStorage
+	backend attribute.KeyValue
+}
+
+// NewStorage creates a traced URLStorage, backend is recorded on its spans.
+func NewStorage(store service.URLStorage, backend string) *Storage {
+	return &Storage{URLStorage: store, backend: attribute.String("storage.backend", backend)}
+}
+
+// start opens the span of the storage operation.
+func (s *Storage) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(s.backend),
+	)
+}
+
+// Ping implements service.URLStorage.
+func (s *Storage) Ping(ctx context.Context) error {
+	ctx, span := s.start(ctx, "Ping")
+	err := s.URLStorage.Ping(ctx)
+	End(span, err)
+	return err
+}
+
+// Get implements service.URLStorage.
+func (s *Storage) Get(ctx context.Context, shortLink string) (string, error) {
+	ctx, span := s.start(ctx, "Get")
+	res, err := s.URLStorage.Get(ctx, shortLink)
+	End(span, err)
+	return res, err
+}
+
+// Save implements service.URLStorage.
+func (s *Storage) Save(ctx context.Context, shortLink, longLink string, expiresAt *time.Time) error {
+	ctx, span := s.start(ctx, "Save")
+	err := s.URLStorage.Save(ctx, shortLink, longLink, expiresAt)
+	End(span, err)
+	return err
+}
+
+// BatchSave implements service.URLStorage.
+func (s *Storage) BatchSave(ctx context.Context, input models.BatchArray) (models.BatchArray, error) {
+	ctx, span := s.start(ctx, "BatchSave")
+	res, err := s.URLStorage.BatchSave(ctx, input)
+	End(span, err)
+	return res, err
+}
+
+// GetByUserID implements service.URLStorage.
+func (s *Storage) GetByUserID(ctx context.Context) ([]models.BaseRow, error) {
+	ctx, span := s.start(ctx, "GetByUserID")
+	res, err := s.URLStorage.GetByUserID(ctx)
+	End(span, err)
+	return res, err
+}
+
+// GetByUserIDPage implements service.URLStorage.
+func (s *Storage) GetByUserIDPage(ctx context.Context, after string, limit int) ([]models.BaseRow, error) {
+	ctx, span := s.start(ctx, "GetByUserIDPage")
+	res, err := s.URLStorage.GetByUserIDPage(ctx, after, limit)
+	End(span, err)
+	return res, err
+}
+
+// DeleteURLs implements service.URLStorage.
+func (s *Storage) DeleteURLs(ctx context.Context, input models.DeleteURLs) error {
+	ctx, span := s.start(ctx, "DeleteURLs")
+	err := s.URLStorage.DeleteURLs(ctx, input)
+	End(span, err)
+	return err
+}
+
+// Cleanup implements service.URLStorage.
+func (s *Storage) Cleanup(ctx context.Context) ([]string, error) {
+	ctx, span := s.start(ctx, "Cleanup")
+	res, err := s.URLStorage.Cleanup(ctx)
+	End(span, err)
+	return res, err
+}
+
+// ServiceStats implements service.URLStorage.
+func (s *Storage) ServiceStats(ctx context.Context) (models.Stats, error) {
+	ctx, span := s.start(ctx, "ServiceStats")
+	res, err := s.URLStorage.ServiceStats(ctx)
+	End(span, err)
+	return res, err
+}
+
+// SaveClicks implements service.URLStorage.
+func (s *Storage) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	ctx, span := s.start(ctx, "SaveClicks")
+	err := s.URLStorage.SaveClicks(ctx, clicks)
+	End(span, err)
+	return err
+}
+
+// ClickStats implements service.URLStorage.
+func (s *Storage) ClickStats(ctx context.Context, shortLink string) (models.ClickStats, error) {
+	ctx, span := s.start(ctx, "ClickStats")
+	res, err := s.URLStorage.ClickStats(ctx, shortLink)
+	End(span, err)
+	return res, err
+}
+
+// NextSequence implements service.URLStorage.
+func (s *Storage) NextSequence(ctx context.Context) (uint64, error) {
+	ctx, span := s.start(ctx, "NextSequence")
+	res, err := s.URLStorage.NextSequence(ctx)
+	End(span, err)
+	return res, err
+}
+
+// GetAPIKey implements service.URLStorage.
+func (s *Storage) GetAPIKey(ctx context.Context, hash string) (models.APIKey, error) {
+	ctx, span := s.start(ctx, "GetAPIKey")
+	res, err := s.URLStorage.GetAPIKey(ctx, hash)
+	End(span, err)
+	return res, err
+}
+
+// GetURLRecord implements service.URLStorage.
+func (s *Storage) GetURLRecord(ctx context.Context, shortLink string) (models.URLRecord, error) {
+	ctx, span := s.start(ctx, "GetURLRecord")
+	res, err := s.URLStorage.GetURLRecord(ctx, shortLink)
+	End(span, err)
+	return res, err
+}
+
+// IsUserDisabled implements service.URLStorage.
+func (s *Storage) IsUserDisabled(ctx context.Context, userID string) (bool, error) {
+	ctx, span := s.start(ctx, "IsUserDisabled")
+	res, err := s.URLStorage.IsUserDisabled(ctx, userID)
+	End(span, err)
+	return res, err
+}
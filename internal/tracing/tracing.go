@@ -0,0 +1,88 @@
+// Package tracing sets up OpenTelemetry tracing and instruments the storage with spans.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"shortener/internal/service"
+)
+
+// ServiceName is the service.name resource attribute of the spans.
+const ServiceName = "shortener"
+
+// instrumentationName names the tracer of the service.
+const instrumentationName = "shortener"
+
+func init() {
+	// W3C traceparent is read and written even without an exporter, so the trace is not cut here.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Tracer returns the tracer of the service from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider exporting spans over OTLP gRPC to the endpoint.
+//
+// The exporter reads the rest of its settings, such as OTEL_EXPORTER_OTLP_INSECURE and
+// OTEL_EXPORTER_OTLP_HEADERS, from the environment. The returned function flushes and stops it.
+func Setup(ctx context.Context, endpoint, version string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End records the error on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil && !expected(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// expectedErrors are the outcomes of normal operation that do not fail a span.
+var expectedErrors = []error{
+	service.ErrURLNotFound,
+	service.ErrAPIKeyNotFound,
+	service.ErrAccountNotFound,
+}
+
+// expected reports whether the error is a normal outcome, such as a missing record.
+func expected(err error) bool {
+	for _, target := range expectedErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
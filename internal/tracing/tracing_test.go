@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+
+	"shortener/internal/service"
+	"shortener/internal/service/mocks"
+)
+
+// recordSpans installs a tracer provider keeping the spans in memory for the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+	return exporter
+}
+
+func TestStorage(t *testing.T) {
+	exporter := recordSpans(t)
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockURLStorage(ctrl)
+	store := NewStorage(mockStore, "memory")
+
+	mockStore.EXPECT().Get(gomock.Any(), "found").Return("https://example.com/", nil)
+	mockStore.EXPECT().Get(gomock.Any(), "missing").Return("", service.ErrURLNotFound)
+	mockStore.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+
+	_, err := store.Get(ctx, "found")
+	require.NoError(t, err)
+	_, err = store.Get(ctx, "missing")
+	require.ErrorIs(t, err, service.ErrURLNotFound)
+	require.Error(t, store.Ping(ctx))
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+	tests := []struct {
+		name     string
+		span     tracetest.SpanStub
+		wantName string
+		wantCode codes.Code
+	}{
+		{name: "found", span: spans[0], wantName: "storage.Get", wantCode: codes.Unset},
+		{name: "missing is not an error", span: spans[1], wantName: "storage.Get", wantCode: codes.Unset},
+		{name: "failed", span: spans[2], wantName: "storage.Ping", wantCode: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantName, tt.span.Name)
+			assert.Equal(t, tt.wantCode, tt.span.Status.Code)
+			assert.Equal(t, spans[3].SpanContext.SpanID(), tt.span.Parent.SpanID())
+			assert.Contains(t, tt.span.Attributes, attribute.String("storage.backend", "memory"))
+		})
+	}
+}
+
+func TestPgxTracer(t *testing.T) {
+	exporter := recordSpans(t)
+	tracer := PgxTracer{}
+	ctx := context.Background()
+
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1", Args: []any{"secret"}})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT 1")
+	batch.Queue("SELECT 2")
+	batchCtx := tracer.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{Batch: batch})
+	tracer.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 1"})
+	tracer.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 2", Err: errors.New("boom")})
+	tracer.TraceBatchEnd(batchCtx, nil, pgx.TraceBatchEndData{Err: errors.New("boom")})
+
+	copyCtx := tracer.TraceCopyFromStart(ctx, nil, pgx.TraceCopyFromStartData{
+		TableName:   pgx.Identifier{"clicks"},
+		ColumnNames: []string{"short", "clicked_at"},
+	})
+	tracer.TraceCopyFromEnd(copyCtx, nil, pgx.TraceCopyFromEndData{})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "db.query", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.String("db.query.text", "SELECT 1"))
+	for _, attr := range spans[0].Attributes {
+		assert.NotEqual(t, "secret", attr.Value.Emit(), "query arguments must not be recorded")
+	}
+	assert.Equal(t, "db.batch", spans[1].Name)
+	assert.Len(t, spans[1].Events, 4) // two queries, the failed query and the batch errors
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "db.copy", spans[2].Name)
+	assert.Contains(t, spans[2].Attributes, attribute.String("db.collection.name", `"clicks"`))
+}
@@ -0,0 +1,190 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"shortener/internal/models"
+)
+
+// csvHeader lists the CSV columns in the order they are written.
+var csvHeader = []string{"uuid", "short_url", "original_url", "user_id", "is_deleted", "expires_at"}
+
+type encoder interface {
+	Encode(record models.URLRecord) error
+	Flush() error
+}
+
+type decoder interface {
+	Decode() (models.URLRecord, error)
+}
+
+func newEncoder(w io.Writer, format string) (encoder, error) {
+	switch format {
+	case FormatJSONL, "":
+		bw := bufio.NewWriter(w)
+		return &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("%q: %w", format, ErrUnknownFormat)
+}
+
+func newDecoder(r io.Reader, format string) (decoder, error) {
+	switch format {
+	case FormatJSONL, "":
+		return &jsonlDecoder{dec: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return &csvDecoder{r: cr}, nil
+	}
+	return nil, fmt.Errorf("%q: %w", format, ErrUnknownFormat)
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// Encode writes the record as a single JSON line.
+func (e *jsonlEncoder) Encode(record models.URLRecord) error {
+	if err := e.enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	return nil
+}
+
+// Flush flushes the buffered lines.
+func (e *jsonlEncoder) Flush() error {
+	if err := e.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush records: %w", err)
+	}
+	return nil
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+// Decode reads the next JSON record.
+func (d *jsonlDecoder) Decode() (models.URLRecord, error) {
+	var record models.URLRecord
+	if err := d.dec.Decode(&record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return record, io.EOF
+		}
+		return record, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return record, nil
+}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// Encode writes the record as a CSV row preceded by the header on the first call.
+func (e *csvEncoder) Encode(record models.URLRecord) error {
+	if err := e.header(); err != nil {
+		return err
+	}
+	expiresAt := ""
+	if record.ExpiresAt != nil {
+		expiresAt = record.ExpiresAt.Format(time.RFC3339Nano)
+	}
+	row := []string{
+		record.UUID,
+		record.ShortURL,
+		record.OriginalURL,
+		record.UserID,
+		strconv.FormatBool(record.Deleted),
+		expiresAt,
+	}
+	if err := e.w.Write(row); err != nil {
+		return fmt.Errorf("failed to write csv row: %w", err)
+	}
+	return nil
+}
+
+// Flush flushes the buffered rows writing the header for an empty export.
+func (e *csvEncoder) Flush() error {
+	if err := e.header(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return nil
+}
+
+func (e *csvEncoder) header() error {
+	if e.wroteHeader {
+		return nil
+	}
+	e.wroteHeader = true
+	if err := e.w.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return nil
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+// Decode reads the next CSV row. Columns are matched by the header so their order does not matter.
+func (d *csvDecoder) Decode() (models.URLRecord, error) {
+	var record models.URLRecord
+	if d.columns == nil {
+		header, err := d.r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return record, io.EOF
+			}
+			return record, fmt.Errorf("failed to read csv header: %w", err)
+		}
+		d.columns = make(map[string]int, len(header))
+		for i, name := range header {
+			d.columns[name] = i
+		}
+	}
+	row, err := d.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return record, io.EOF
+		}
+		return record, fmt.Errorf("failed to read csv row: %w", err)
+	}
+	field := func(name string) string {
+		if i, ok := d.columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	record.UUID = field("uuid")
+	record.ShortURL = field("short_url")
+	record.OriginalURL = field("original_url")
+	record.UserID = field("user_id")
+	if v := field("is_deleted"); v != "" {
+		if record.Deleted, err = strconv.ParseBool(v); err != nil {
+			return record, fmt.Errorf("failed to parse is_deleted: %w", err)
+		}
+	}
+	if v := field("expires_at"); v != "" {
+		expiresAt, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return record, fmt.Errorf("failed to parse expires_at: %w", err)
+		}
+		record.ExpiresAt = &expiresAt
+	}
+	return record, nil
+}
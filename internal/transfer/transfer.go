@@ -0,0 +1,141 @@
+// Package transfer streams URL records between a storage and portable JSONL or CSV files.
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"shortener/internal/models"
+)
+
+const (
+	// FormatJSONL is the JSON-lines format written by the file storage.
+	FormatJSONL = "jsonl"
+	// FormatCSV is the CSV format with a header row.
+	FormatCSV = "csv"
+
+	// DefaultPolicy is the conflict policy used when none is given.
+	DefaultPolicy = models.ConflictSkip
+
+	// BatchSize is the number of records imported at once and the progress reporting step.
+	BatchSize = 500
+)
+
+var (
+	// ErrUnknownFormat error indicates the format is neither jsonl nor csv.
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrUnknownPolicy error indicates the conflict policy is not supported.
+	ErrUnknownPolicy = errors.New("unknown conflict policy")
+	// ErrInvalidRecord error indicates the imported record misses mandatory fields.
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+// Source contains contract for reading every stored record.
+type Source interface {
+	ExportURLs(ctx context.Context, fn func(models.URLRecord) error) error
+}
+
+// Sink contains contract for storing a batch of records.
+type Sink interface {
+	ImportURLs(ctx context.Context, records []models.URLRecord, policy models.ConflictPolicy) (models.ImportResult, error)
+}
+
+// Progress is called with the number of records processed so far.
+type Progress func(processed int)
+
+// ParsePolicy returns the conflict policy by its name. An empty name means DefaultPolicy.
+func ParsePolicy(name string) (models.ConflictPolicy, error) {
+	switch policy := models.ConflictPolicy(name); policy {
+	case "":
+		return DefaultPolicy, nil
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail:
+		return policy, nil
+	}
+	return "", fmt.Errorf("%q: %w", name, ErrUnknownPolicy)
+}
+
+// Export writes every record of the source to w and returns the number of written records.
+func Export(ctx context.Context, src Source, w io.Writer, format string, progress Progress) (int, error) {
+	enc, err := newEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	written := 0
+	err = src.ExportURLs(ctx, func(record models.URLRecord) error {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+		written++
+		if written%BatchSize == 0 {
+			report(progress, written)
+		}
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("failed to export urls: %w", err)
+	}
+	if err = enc.Flush(); err != nil {
+		return written, err
+	}
+	report(progress, written)
+	return written, nil
+}
+
+// Import reads records from r and stores them into the sink in batches.
+//
+// Batches stored before a failure stay in the sink.
+func Import(
+	ctx context.Context, dst Sink, r io.Reader, format string, policy models.ConflictPolicy, progress Progress,
+) (models.ImportResult, error) {
+	var result models.ImportResult
+	dec, err := newDecoder(r, format)
+	if err != nil {
+		return result, err
+	}
+	processed := 0
+	batch := make([]models.URLRecord, 0, BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		res, err := dst.ImportURLs(ctx, batch, policy)
+		if err != nil {
+			return fmt.Errorf("failed to import urls: %w", err)
+		}
+		result = result.Add(res)
+		processed += len(batch)
+		batch = batch[:0]
+		report(progress, processed)
+		return nil
+	}
+	for line := 1; ; line++ {
+		record, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("record %d: %w: %w", line, ErrInvalidRecord, err)
+		}
+		if record.ShortURL == "" || record.OriginalURL == "" {
+			return result, fmt.Errorf("record %d: short_url and original_url are required: %w", line, ErrInvalidRecord)
+		}
+		batch = append(batch, record)
+		if len(batch) == BatchSize {
+			if err = flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func report(progress Progress, processed int) {
+	if progress != nil {
+		progress(processed)
+	}
+}
@@ -0,0 +1,115 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"shortener/internal/models"
+)
+
+type store struct {
+	records []models.URLRecord
+	batches int
+}
+
+func (s *store) ExportURLs(_ context.Context, fn func(models.URLRecord) error) error {
+	for _, r := range s.records {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *store) ImportURLs(
+	_ context.Context, records []models.URLRecord, _ models.ConflictPolicy,
+) (models.ImportResult, error) {
+	s.batches++
+	s.records = append(s.records, records...)
+	return models.ImportResult{Imported: len(records)}, nil
+}
+
+func TestExportImport(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []models.URLRecord{
+		{UUID: "1", ShortURL: "abc", OriginalURL: "https://example.com/a", UserID: "u1"},
+		{UUID: "2", ShortURL: "def", OriginalURL: "https://example.com/b,c", UserID: "u2", Deleted: true,
+			ExpiresAt: &expiresAt},
+	}
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			var reported []int
+			n, err := Export(context.Background(), &store{records: records}, &buf, format, func(processed int) {
+				reported = append(reported, processed)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+			assert.Equal(t, []int{2}, reported)
+
+			dst := &store{}
+			res, err := Import(context.Background(), dst, &buf, format, models.ConflictSkip, nil)
+			require.NoError(t, err)
+			assert.Equal(t, models.ImportResult{Imported: 2}, res)
+			assert.Equal(t, records, dst.records)
+		})
+	}
+}
+
+func TestImport_Batches(t *testing.T) {
+	var sb strings.Builder
+	total := BatchSize*2 + 1
+	for i := 0; i < total; i++ {
+		sb.WriteString(`{"short_url":"s","original_url":"https://example.com"}` + "\n")
+	}
+	dst := &store{}
+	var reported []int
+	res, err := Import(context.Background(), dst, strings.NewReader(sb.String()), FormatJSONL, models.ConflictSkip,
+		func(processed int) { reported = append(reported, processed) })
+	require.NoError(t, err)
+	assert.Equal(t, total, res.Imported)
+	assert.Equal(t, 3, dst.batches)
+	assert.Equal(t, []int{BatchSize, BatchSize * 2, total}, reported)
+}
+
+func TestImport_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		body    string
+		wantErr error
+	}{
+		{name: "unknown format", format: "xml", body: "", wantErr: ErrUnknownFormat},
+		{name: "malformed json", format: FormatJSONL, body: "{", wantErr: ErrInvalidRecord},
+		{name: "missing short", format: FormatJSONL, body: `{"original_url":"https://example.com"}`,
+			wantErr: ErrInvalidRecord},
+		{name: "bad csv flag", format: FormatCSV, body: "short_url,original_url,is_deleted\na,https://a.com,maybe\n",
+			wantErr: ErrInvalidRecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(context.Background(), &store{}, strings.NewReader(tt.body), tt.format,
+				models.ConflictSkip, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, models.ConflictSkip, policy)
+
+	policy, err = ParsePolicy("overwrite")
+	require.NoError(t, err)
+	assert.Equal(t, models.ConflictOverwrite, policy)
+
+	_, err = ParsePolicy("merge")
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
@@ -0,0 +1,254 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        v5.28.2
+// source: proto/click_stats.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ClickStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Short string `protobuf:"bytes,1,opt,name=short,proto3" json:"short,omitempty"`
+}
+
+func (x *ClickStatsRequest) Reset() {
+	*x = ClickStatsRequest{}
+	mi := &file_proto_click_stats_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClickStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickStatsRequest) ProtoMessage() {}
+
+func (x *ClickStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_click_stats_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickStatsRequest.ProtoReflect.Descriptor instead.
+func (*ClickStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_click_stats_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ClickStatsRequest) GetShort() string {
+	if x != nil {
+		return x.Short
+	}
+	return ""
+}
+
+type DayClicks struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Day    string `protobuf:"bytes,1,opt,name=day,proto3" json:"day,omitempty"`
+	Clicks int64  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+}
+
+func (x *DayClicks) Reset() {
+	*x = DayClicks{}
+	mi := &file_proto_click_stats_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DayClicks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DayClicks) ProtoMessage() {}
+
+func (x *DayClicks) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_click_stats_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DayClicks.ProtoReflect.Descriptor instead.
+func (*DayClicks) Descriptor() ([]byte, []int) {
+	return file_proto_click_stats_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DayClicks) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *DayClicks) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+type ClickStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Short string       `protobuf:"bytes,1,opt,name=short,proto3" json:"short,omitempty"`
+	Total int64        `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Days  []*DayClicks `protobuf:"bytes,3,rep,name=days,proto3" json:"days,omitempty"`
+}
+
+func (x *ClickStatsResponse) Reset() {
+	*x = ClickStatsResponse{}
+	mi := &file_proto_click_stats_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClickStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickStatsResponse) ProtoMessage() {}
+
+func (x *ClickStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_click_stats_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickStatsResponse.ProtoReflect.Descriptor instead.
+func (*ClickStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_click_stats_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClickStatsResponse) GetShort() string {
+	if x != nil {
+		return x.Short
+	}
+	return ""
+}
+
+func (x *ClickStatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ClickStatsResponse) GetDays() []*DayClicks {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+var File_proto_click_stats_proto protoreflect.FileDescriptor
+
+var file_proto_click_stats_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x5f, 0x73, 0x74,
+	0x61, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x29, 0x0a, 0x11, 0x43, 0x6c, 0x69,
+	0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x68, 0x6f, 0x72, 0x74, 0x22, 0x35, 0x0a, 0x09, 0x44, 0x61, 0x79, 0x43, 0x6c, 0x69, 0x63, 0x6b,
+	0x73, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x64, 0x61, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x73, 0x22, 0x60, 0x0a, 0x12, 0x43,
+	0x6c, 0x69, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x1e, 0x0a,
+	0x04, 0x64, 0x61, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x44, 0x61,
+	0x79, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x73, 0x52, 0x04, 0x64, 0x61, 0x79, 0x73, 0x42, 0x1d, 0x5a,
+	0x1b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_click_stats_proto_rawDescOnce sync.Once
+	file_proto_click_stats_proto_rawDescData = file_proto_click_stats_proto_rawDesc
+)
+
+func file_proto_click_stats_proto_rawDescGZIP() []byte {
+	file_proto_click_stats_proto_rawDescOnce.Do(func() {
+		file_proto_click_stats_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_click_stats_proto_rawDescData)
+	})
+	return file_proto_click_stats_proto_rawDescData
+}
+
+var file_proto_click_stats_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_click_stats_proto_goTypes = []any{
+	(*ClickStatsRequest)(nil),  // 0: ClickStatsRequest
+	(*DayClicks)(nil),          // 1: DayClicks
+	(*ClickStatsResponse)(nil), // 2: ClickStatsResponse
+}
+var file_proto_click_stats_proto_depIdxs = []int32{
+	1, // 0: ClickStatsResponse.days:type_name -> DayClicks
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_click_stats_proto_init() }
+func file_proto_click_stats_proto_init() {
+	if File_proto_click_stats_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_click_stats_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_click_stats_proto_goTypes,
+		DependencyIndexes: file_proto_click_stats_proto_depIdxs,
+		MessageInfos:      file_proto_click_stats_proto_msgTypes,
+	}.Build()
+	File_proto_click_stats_proto = out.File
+	file_proto_click_stats_proto_rawDesc = nil
+	file_proto_click_stats_proto_goTypes = nil
+	file_proto_click_stats_proto_depIdxs = nil
+}
@@ -33,74 +33,138 @@ var file_proto_service_proto_rawDesc = []byte{
 	0x74, 0x5f, 0x75, 0x72, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x2f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x5f, 0x75, 0x72, 0x6c,
-	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72,
-	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0x87, 0x03, 0x0a, 0x13, 0x55, 0x52, 0x4c, 0x53,
-	0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x42, 0x0a, 0x04, 0x53, 0x61, 0x76, 0x65, 0x12, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x0d, 0x2e, 0x42,
-	0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x42, 0x61,
-	0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x0a, 0x44,
-	0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d, 0x61, 0x6e, 0x79, 0x12, 0x0e, 0x2e, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x20, 0x0a, 0x03, 0x47, 0x65,
-	0x74, 0x12, 0x0b, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c,
-	0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x04,
-	0x50, 0x69, 0x6e, 0x67, 0x12, 0x0c, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x2c, 0x0a, 0x07, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x12, 0x0f, 0x2e, 0x53,
-	0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e,
-	0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x26, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x0d, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x0b, 0x53, 0x61, 0x76, 0x65, 0x64,
-	0x42, 0x79, 0x55, 0x73, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x64, 0x42, 0x79,
-	0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x53, 0x61,
-	0x76, 0x65, 0x64, 0x42, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x42, 0x1d, 0x5a, 0x1b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x2f, 0x70,
-	0x6b, 0x67, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63,
+	0x6c, 0x69, 0x63, 0x6b, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x11, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x32, 0xfc, 0x06, 0x0a, 0x13, 0x55, 0x52, 0x4c, 0x53, 0x68, 0x6f, 0x72, 0x74,
+	0x65, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x04, 0x53,
+	0x61, 0x76, 0x65, 0x12, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x1a, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x26, 0x0a, 0x05, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x0d, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x0b, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x13, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x1a, 0x0e, 0x2e, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12,
+	0x40, 0x0a, 0x0d, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x12, 0x13, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x45,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30,
+	0x01, 0x12, 0x2d, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d, 0x61, 0x6e, 0x79, 0x12,
+	0x0e, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0f, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x20, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0b, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x23, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x0c, 0x2e, 0x50, 0x69, 0x6e,
+	0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x53, 0x68, 0x6f, 0x72, 0x74,
+	0x65, 0x6e, 0x12, 0x0f, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x53, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x0d,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a,
+	0x0b, 0x53, 0x61, 0x76, 0x65, 0x64, 0x42, 0x79, 0x55, 0x73, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x53,
+	0x61, 0x76, 0x65, 0x64, 0x42, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x14, 0x2e, 0x53, 0x61, 0x76, 0x65, 0x64, 0x42, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x55,
+	0x73, 0x65, 0x72, 0x55, 0x52, 0x4c, 0x73, 0x12, 0x14, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x73,
+	0x65, 0x72, 0x55, 0x52, 0x4c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x04, 0x2e,
+	0x55, 0x52, 0x4c, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0a, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x12, 0x12, 0x2e, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x0b,
+	0x41, 0x64, 0x6d, 0x69, 0x6e, 0x47, 0x65, 0x74, 0x55, 0x52, 0x4c, 0x12, 0x10, 0x2e, 0x41, 0x64,
+	0x6d, 0x69, 0x6e, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e,
+	0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2f, 0x0a, 0x0e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55,
+	0x52, 0x4c, 0x12, 0x10, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55, 0x52, 0x4c, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x12, 0x30, 0x0a, 0x0f, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x55, 0x52, 0x4c, 0x12, 0x10, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55, 0x52, 0x4c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x12, 0x3a, 0x0a, 0x0d, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55, 0x73, 0x65, 0x72,
+	0x55, 0x52, 0x4c, 0x73, 0x12, 0x11, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55, 0x73, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x55,
+	0x73, 0x65, 0x72, 0x55, 0x52, 0x4c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x41, 0x0a, 0x14, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x53, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x44,
+	0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x1c, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x53,
+	0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x42, 0x1d, 0x5a, 0x1b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x2f,
+	0x70, 0x6b, 0x67, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var file_proto_service_proto_goTypes = []any{
-	(*wrapperspb.StringValue)(nil), // 0: google.protobuf.StringValue
-	(*BatchRequest)(nil),           // 1: BatchRequest
-	(*DeleteRequest)(nil),          // 2: DeleteRequest
-	(*GetRequest)(nil),             // 3: GetRequest
-	(*PingRequest)(nil),            // 4: PingRequest
-	(*ShortenRequest)(nil),         // 5: ShortenRequest
-	(*StatsRequest)(nil),           // 6: StatsRequest
-	(*SavedByUserRequest)(nil),     // 7: SavedByUserRequest
-	(*BatchResponse)(nil),          // 8: BatchResponse
-	(*DeleteResponse)(nil),         // 9: DeleteResponse
-	(*GetResponse)(nil),            // 10: GetResponse
-	(*PingResponse)(nil),           // 11: PingResponse
-	(*ShortenResponse)(nil),        // 12: ShortenResponse
-	(*StatsResponse)(nil),          // 13: StatsResponse
-	(*SavedByUserResponse)(nil),    // 14: SavedByUserResponse
+	(*wrapperspb.StringValue)(nil),      // 0: google.protobuf.StringValue
+	(*BatchRequest)(nil),                // 1: BatchRequest
+	(*BatchRequestEntity)(nil),          // 2: BatchRequestEntity
+	(*DeleteRequest)(nil),               // 3: DeleteRequest
+	(*GetRequest)(nil),                  // 4: GetRequest
+	(*PingRequest)(nil),                 // 5: PingRequest
+	(*ShortenRequest)(nil),              // 6: ShortenRequest
+	(*StatsRequest)(nil),                // 7: StatsRequest
+	(*SavedByUserRequest)(nil),          // 8: SavedByUserRequest
+	(*ListUserURLsRequest)(nil),         // 9: ListUserURLsRequest
+	(*ClickStatsRequest)(nil),           // 10: ClickStatsRequest
+	(*AdminURLRequest)(nil),             // 11: AdminURLRequest
+	(*AdminUserRequest)(nil),            // 12: AdminUserRequest
+	(*AdminSetUserDisabledRequest)(nil), // 13: AdminSetUserDisabledRequest
+	(*BatchResponse)(nil),               // 14: BatchResponse
+	(*ShortenStreamResponse)(nil),       // 15: ShortenStreamResponse
+	(*DeleteResponse)(nil),              // 16: DeleteResponse
+	(*GetResponse)(nil),                 // 17: GetResponse
+	(*PingResponse)(nil),                // 18: PingResponse
+	(*ShortenResponse)(nil),             // 19: ShortenResponse
+	(*StatsResponse)(nil),               // 20: StatsResponse
+	(*SavedByUserResponse)(nil),         // 21: SavedByUserResponse
+	(*URL)(nil),                         // 22: URL
+	(*ClickStatsResponse)(nil),          // 23: ClickStatsResponse
+	(*AdminURLResponse)(nil),            // 24: AdminURLResponse
+	(*AdminEmpty)(nil),                  // 25: AdminEmpty
+	(*AdminUserURLsResponse)(nil),       // 26: AdminUserURLsResponse
 }
 var file_proto_service_proto_depIdxs = []int32{
 	0,  // 0: URLShortenerService.Save:input_type -> google.protobuf.StringValue
 	1,  // 1: URLShortenerService.Batch:input_type -> BatchRequest
-	2,  // 2: URLShortenerService.DeleteMany:input_type -> DeleteRequest
-	3,  // 3: URLShortenerService.Get:input_type -> GetRequest
-	4,  // 4: URLShortenerService.Ping:input_type -> PingRequest
-	5,  // 5: URLShortenerService.Shorten:input_type -> ShortenRequest
-	6,  // 6: URLShortenerService.Stats:input_type -> StatsRequest
-	7,  // 7: URLShortenerService.SavedByUser:input_type -> SavedByUserRequest
-	0,  // 8: URLShortenerService.Save:output_type -> google.protobuf.StringValue
-	8,  // 9: URLShortenerService.Batch:output_type -> BatchResponse
-	9,  // 10: URLShortenerService.DeleteMany:output_type -> DeleteResponse
-	10, // 11: URLShortenerService.Get:output_type -> GetResponse
-	11, // 12: URLShortenerService.Ping:output_type -> PingResponse
-	12, // 13: URLShortenerService.Shorten:output_type -> ShortenResponse
-	13, // 14: URLShortenerService.Stats:output_type -> StatsResponse
-	14, // 15: URLShortenerService.SavedByUser:output_type -> SavedByUserResponse
-	8,  // [8:16] is the sub-list for method output_type
-	0,  // [0:8] is the sub-list for method input_type
+	2,  // 2: URLShortenerService.BatchStream:input_type -> BatchRequestEntity
+	2,  // 3: URLShortenerService.ShortenStream:input_type -> BatchRequestEntity
+	3,  // 4: URLShortenerService.DeleteMany:input_type -> DeleteRequest
+	4,  // 5: URLShortenerService.Get:input_type -> GetRequest
+	5,  // 6: URLShortenerService.Ping:input_type -> PingRequest
+	6,  // 7: URLShortenerService.Shorten:input_type -> ShortenRequest
+	7,  // 8: URLShortenerService.Stats:input_type -> StatsRequest
+	8,  // 9: URLShortenerService.SavedByUser:input_type -> SavedByUserRequest
+	9,  // 10: URLShortenerService.ListUserURLs:input_type -> ListUserURLsRequest
+	10, // 11: URLShortenerService.ClickStats:input_type -> ClickStatsRequest
+	11, // 12: URLShortenerService.AdminGetURL:input_type -> AdminURLRequest
+	11, // 13: URLShortenerService.AdminDeleteURL:input_type -> AdminURLRequest
+	11, // 14: URLShortenerService.AdminRestoreURL:input_type -> AdminURLRequest
+	12, // 15: URLShortenerService.AdminUserURLs:input_type -> AdminUserRequest
+	13, // 16: URLShortenerService.AdminSetUserDisabled:input_type -> AdminSetUserDisabledRequest
+	0,  // 17: URLShortenerService.Save:output_type -> google.protobuf.StringValue
+	14, // 18: URLShortenerService.Batch:output_type -> BatchResponse
+	14, // 19: URLShortenerService.BatchStream:output_type -> BatchResponse
+	15, // 20: URLShortenerService.ShortenStream:output_type -> ShortenStreamResponse
+	16, // 21: URLShortenerService.DeleteMany:output_type -> DeleteResponse
+	17, // 22: URLShortenerService.Get:output_type -> GetResponse
+	18, // 23: URLShortenerService.Ping:output_type -> PingResponse
+	19, // 24: URLShortenerService.Shorten:output_type -> ShortenResponse
+	20, // 25: URLShortenerService.Stats:output_type -> StatsResponse
+	21, // 26: URLShortenerService.SavedByUser:output_type -> SavedByUserResponse
+	22, // 27: URLShortenerService.ListUserURLs:output_type -> URL
+	23, // 28: URLShortenerService.ClickStats:output_type -> ClickStatsResponse
+	24, // 29: URLShortenerService.AdminGetURL:output_type -> AdminURLResponse
+	25, // 30: URLShortenerService.AdminDeleteURL:output_type -> AdminEmpty
+	25, // 31: URLShortenerService.AdminRestoreURL:output_type -> AdminEmpty
+	26, // 32: URLShortenerService.AdminUserURLs:output_type -> AdminUserURLsResponse
+	25, // 33: URLShortenerService.AdminSetUserDisabled:output_type -> AdminEmpty
+	17, // [17:34] is the sub-list for method output_type
+	0,  // [0:17] is the sub-list for method input_type
 	0,  // [0:0] is the sub-list for extension type_name
 	0,  // [0:0] is the sub-list for extension extendee
 	0,  // [0:0] is the sub-list for field type_name
@@ -118,6 +182,8 @@ func file_proto_service_proto_init() {
 	file_proto_get_url_proto_init()
 	file_proto_batch_proto_init()
 	file_proto_delete_urls_proto_init()
+	file_proto_click_stats_proto_init()
+	file_proto_admin_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
@@ -20,14 +20,23 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	URLShortenerService_Save_FullMethodName        = "/URLShortenerService/Save"
-	URLShortenerService_Batch_FullMethodName       = "/URLShortenerService/Batch"
-	URLShortenerService_DeleteMany_FullMethodName  = "/URLShortenerService/DeleteMany"
-	URLShortenerService_Get_FullMethodName         = "/URLShortenerService/Get"
-	URLShortenerService_Ping_FullMethodName        = "/URLShortenerService/Ping"
-	URLShortenerService_Shorten_FullMethodName     = "/URLShortenerService/Shorten"
-	URLShortenerService_Stats_FullMethodName       = "/URLShortenerService/Stats"
-	URLShortenerService_SavedByUser_FullMethodName = "/URLShortenerService/SavedByUser"
+	URLShortenerService_Save_FullMethodName                 = "/URLShortenerService/Save"
+	URLShortenerService_Batch_FullMethodName                = "/URLShortenerService/Batch"
+	URLShortenerService_BatchStream_FullMethodName          = "/URLShortenerService/BatchStream"
+	URLShortenerService_ShortenStream_FullMethodName        = "/URLShortenerService/ShortenStream"
+	URLShortenerService_DeleteMany_FullMethodName           = "/URLShortenerService/DeleteMany"
+	URLShortenerService_Get_FullMethodName                  = "/URLShortenerService/Get"
+	URLShortenerService_Ping_FullMethodName                 = "/URLShortenerService/Ping"
+	URLShortenerService_Shorten_FullMethodName              = "/URLShortenerService/Shorten"
+	URLShortenerService_Stats_FullMethodName                = "/URLShortenerService/Stats"
+	URLShortenerService_SavedByUser_FullMethodName          = "/URLShortenerService/SavedByUser"
+	URLShortenerService_ListUserURLs_FullMethodName         = "/URLShortenerService/ListUserURLs"
+	URLShortenerService_ClickStats_FullMethodName           = "/URLShortenerService/ClickStats"
+	URLShortenerService_AdminGetURL_FullMethodName          = "/URLShortenerService/AdminGetURL"
+	URLShortenerService_AdminDeleteURL_FullMethodName       = "/URLShortenerService/AdminDeleteURL"
+	URLShortenerService_AdminRestoreURL_FullMethodName      = "/URLShortenerService/AdminRestoreURL"
+	URLShortenerService_AdminUserURLs_FullMethodName        = "/URLShortenerService/AdminUserURLs"
+	URLShortenerService_AdminSetUserDisabled_FullMethodName = "/URLShortenerService/AdminSetUserDisabled"
 )
 
 // URLShortenerServiceClient is the client API for URLShortenerService service.
@@ -36,12 +45,21 @@ const (
 type URLShortenerServiceClient interface {
 	Save(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*wrapperspb.StringValue, error)
 	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	BatchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchRequestEntity, BatchResponse], error)
+	ShortenStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchRequestEntity, ShortenStreamResponse], error)
 	DeleteMany(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
 	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error)
 	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
 	SavedByUser(ctx context.Context, in *SavedByUserRequest, opts ...grpc.CallOption) (*SavedByUserResponse, error)
+	ListUserURLs(ctx context.Context, in *ListUserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[URL], error)
+	ClickStats(ctx context.Context, in *ClickStatsRequest, opts ...grpc.CallOption) (*ClickStatsResponse, error)
+	AdminGetURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminURLResponse, error)
+	AdminDeleteURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminEmpty, error)
+	AdminRestoreURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminEmpty, error)
+	AdminUserURLs(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUserURLsResponse, error)
+	AdminSetUserDisabled(ctx context.Context, in *AdminSetUserDisabledRequest, opts ...grpc.CallOption) (*AdminEmpty, error)
 }
 
 type uRLShortenerServiceClient struct {
@@ -72,6 +90,32 @@ func (c *uRLShortenerServiceClient) Batch(ctx context.Context, in *BatchRequest,
 	return out, nil
 }
 
+func (c *uRLShortenerServiceClient) BatchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchRequestEntity, BatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortenerService_ServiceDesc.Streams[0], URLShortenerService_BatchStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchRequestEntity, BatchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_BatchStreamClient = grpc.BidiStreamingClient[BatchRequestEntity, BatchResponse]
+
+func (c *uRLShortenerServiceClient) ShortenStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BatchRequestEntity, ShortenStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortenerService_ServiceDesc.Streams[1], URLShortenerService_ShortenStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchRequestEntity, ShortenStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ShortenStreamClient = grpc.BidiStreamingClient[BatchRequestEntity, ShortenStreamResponse]
+
 func (c *uRLShortenerServiceClient) DeleteMany(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DeleteResponse)
@@ -132,18 +176,106 @@ func (c *uRLShortenerServiceClient) SavedByUser(ctx context.Context, in *SavedBy
 	return out, nil
 }
 
+func (c *uRLShortenerServiceClient) ListUserURLs(ctx context.Context, in *ListUserURLsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[URL], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortenerService_ServiceDesc.Streams[2], URLShortenerService_ListUserURLs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListUserURLsRequest, URL]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ListUserURLsClient = grpc.ServerStreamingClient[URL]
+
+func (c *uRLShortenerServiceClient) ClickStats(ctx context.Context, in *ClickStatsRequest, opts ...grpc.CallOption) (*ClickStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClickStatsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ClickStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AdminGetURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminURLResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_AdminGetURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AdminDeleteURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminEmpty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminEmpty)
+	err := c.cc.Invoke(ctx, URLShortenerService_AdminDeleteURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AdminRestoreURL(ctx context.Context, in *AdminURLRequest, opts ...grpc.CallOption) (*AdminEmpty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminEmpty)
+	err := c.cc.Invoke(ctx, URLShortenerService_AdminRestoreURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AdminUserURLs(ctx context.Context, in *AdminUserRequest, opts ...grpc.CallOption) (*AdminUserURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminUserURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_AdminUserURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AdminSetUserDisabled(ctx context.Context, in *AdminSetUserDisabledRequest, opts ...grpc.CallOption) (*AdminEmpty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminEmpty)
+	err := c.cc.Invoke(ctx, URLShortenerService_AdminSetUserDisabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLShortenerServiceServer is the server API for URLShortenerService service.
 // All implementations must embed UnimplementedURLShortenerServiceServer
 // for forward compatibility.
 type URLShortenerServiceServer interface {
 	Save(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
 	Batch(context.Context, *BatchRequest) (*BatchResponse, error)
+	BatchStream(grpc.BidiStreamingServer[BatchRequestEntity, BatchResponse]) error
+	ShortenStream(grpc.BidiStreamingServer[BatchRequestEntity, ShortenStreamResponse]) error
 	DeleteMany(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
 	Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error)
 	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
 	SavedByUser(context.Context, *SavedByUserRequest) (*SavedByUserResponse, error)
+	ListUserURLs(*ListUserURLsRequest, grpc.ServerStreamingServer[URL]) error
+	ClickStats(context.Context, *ClickStatsRequest) (*ClickStatsResponse, error)
+	AdminGetURL(context.Context, *AdminURLRequest) (*AdminURLResponse, error)
+	AdminDeleteURL(context.Context, *AdminURLRequest) (*AdminEmpty, error)
+	AdminRestoreURL(context.Context, *AdminURLRequest) (*AdminEmpty, error)
+	AdminUserURLs(context.Context, *AdminUserRequest) (*AdminUserURLsResponse, error)
+	AdminSetUserDisabled(context.Context, *AdminSetUserDisabledRequest) (*AdminEmpty, error)
 	mustEmbedUnimplementedURLShortenerServiceServer()
 }
 
@@ -160,6 +292,12 @@ func (UnimplementedURLShortenerServiceServer) Save(context.Context, *wrapperspb.
 func (UnimplementedURLShortenerServiceServer) Batch(context.Context, *BatchRequest) (*BatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
 }
+func (UnimplementedURLShortenerServiceServer) BatchStream(grpc.BidiStreamingServer[BatchRequestEntity, BatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchStream not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ShortenStream(grpc.BidiStreamingServer[BatchRequestEntity, ShortenStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ShortenStream not implemented")
+}
 func (UnimplementedURLShortenerServiceServer) DeleteMany(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteMany not implemented")
 }
@@ -178,6 +316,27 @@ func (UnimplementedURLShortenerServiceServer) Stats(context.Context, *StatsReque
 func (UnimplementedURLShortenerServiceServer) SavedByUser(context.Context, *SavedByUserRequest) (*SavedByUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SavedByUser not implemented")
 }
+func (UnimplementedURLShortenerServiceServer) ListUserURLs(*ListUserURLsRequest, grpc.ServerStreamingServer[URL]) error {
+	return status.Errorf(codes.Unimplemented, "method ListUserURLs not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ClickStats(context.Context, *ClickStatsRequest) (*ClickStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClickStats not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AdminGetURL(context.Context, *AdminURLRequest) (*AdminURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminGetURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AdminDeleteURL(context.Context, *AdminURLRequest) (*AdminEmpty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminDeleteURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AdminRestoreURL(context.Context, *AdminURLRequest) (*AdminEmpty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRestoreURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AdminUserURLs(context.Context, *AdminUserRequest) (*AdminUserURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminUserURLs not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AdminSetUserDisabled(context.Context, *AdminSetUserDisabledRequest) (*AdminEmpty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminSetUserDisabled not implemented")
+}
 func (UnimplementedURLShortenerServiceServer) mustEmbedUnimplementedURLShortenerServiceServer() {}
 func (UnimplementedURLShortenerServiceServer) testEmbeddedByValue()                             {}
 
@@ -235,6 +394,20 @@ func _URLShortenerService_Batch_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortenerService_BatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(URLShortenerServiceServer).BatchStream(&grpc.GenericServerStream[BatchRequestEntity, BatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_BatchStreamServer = grpc.BidiStreamingServer[BatchRequestEntity, BatchResponse]
+
+func _URLShortenerService_ShortenStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(URLShortenerServiceServer).ShortenStream(&grpc.GenericServerStream[BatchRequestEntity, ShortenStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ShortenStreamServer = grpc.BidiStreamingServer[BatchRequestEntity, ShortenStreamResponse]
+
 func _URLShortenerService_DeleteMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteRequest)
 	if err := dec(in); err != nil {
@@ -343,6 +516,125 @@ func _URLShortenerService_SavedByUser_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortenerService_ListUserURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListUserURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(URLShortenerServiceServer).ListUserURLs(m, &grpc.GenericServerStream[ListUserURLsRequest, URL]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortenerService_ListUserURLsServer = grpc.ServerStreamingServer[URL]
+
+func _URLShortenerService_ClickStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClickStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ClickStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ClickStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ClickStats(ctx, req.(*ClickStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AdminGetURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AdminGetURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AdminGetURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AdminGetURL(ctx, req.(*AdminURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AdminDeleteURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AdminDeleteURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AdminDeleteURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AdminDeleteURL(ctx, req.(*AdminURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AdminRestoreURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AdminRestoreURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AdminRestoreURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AdminRestoreURL(ctx, req.(*AdminURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AdminUserURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AdminUserURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AdminUserURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AdminUserURLs(ctx, req.(*AdminUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AdminSetUserDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminSetUserDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AdminSetUserDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AdminSetUserDisabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AdminSetUserDisabled(ctx, req.(*AdminSetUserDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLShortenerService_ServiceDesc is the grpc.ServiceDesc for URLShortenerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -382,7 +674,49 @@ var URLShortenerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SavedByUser",
 			Handler:    _URLShortenerService_SavedByUser_Handler,
 		},
+		{
+			MethodName: "ClickStats",
+			Handler:    _URLShortenerService_ClickStats_Handler,
+		},
+		{
+			MethodName: "AdminGetURL",
+			Handler:    _URLShortenerService_AdminGetURL_Handler,
+		},
+		{
+			MethodName: "AdminDeleteURL",
+			Handler:    _URLShortenerService_AdminDeleteURL_Handler,
+		},
+		{
+			MethodName: "AdminRestoreURL",
+			Handler:    _URLShortenerService_AdminRestoreURL_Handler,
+		},
+		{
+			MethodName: "AdminUserURLs",
+			Handler:    _URLShortenerService_AdminUserURLs_Handler,
+		},
+		{
+			MethodName: "AdminSetUserDisabled",
+			Handler:    _URLShortenerService_AdminSetUserDisabled_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchStream",
+			Handler:       _URLShortenerService_BatchStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ShortenStream",
+			Handler:       _URLShortenerService_ShortenStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListUserURLs",
+			Handler:       _URLShortenerService_ListUserURLs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/service.proto",
 }